@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os/exec"
+	"time"
+
+	"golang.org/x/image/font"
+
+	"periodic-table-tiles/render"
+)
+
+// animation is a sequence of frames drawn one after another onto canvas.
+type animation struct {
+	canvas *image.RGBA
+	// colours is an image using every colour the frames do, to choose a
+	// GIF palette from.
+	colours image.Image
+	// next draws the next frame and returns the part of the canvas that
+	// changed, or false when there are no more.
+	next func() (changed image.Rectangle, ok bool)
+}
+
+// animFlags are the output flags shared by the animation modes.
+type animFlags struct {
+	format string
+	video  string
+	delay  time.Duration
+	hold   time.Duration
+}
+
+func addAnimFlags(fs *flag.FlagSet, delay, hold time.Duration) *animFlags {
+	a := &animFlags{}
+	fs.StringVar(&a.format, "format", "gif", "gif, or frames for a numbered PNG per frame")
+	fs.StringVar(&a.video, "video", "", "pipe the frames through ffmpeg into this video file (e.g. out.mp4) instead")
+	fs.DurationVar(&a.delay, "frame-delay", delay, "time each frame is shown")
+	fs.DurationVar(&a.hold, "hold", hold, "time the last frame is shown before the animation loops or ends")
+	return a
+}
+
+func (a *animFlags) check() error {
+	if a.format != "gif" && a.format != "frames" {
+		return fmt.Errorf("unknown -format %q (want gif or frames)", a.format)
+	}
+	if a.delay < 10*time.Millisecond {
+		return errors.New("-frame-delay must be at least 10ms")
+	}
+	if a.video != "" {
+		if _, err := exec.LookPath("ffmpeg"); err != nil {
+			return fmt.Errorf("-video needs ffmpeg: %w", err)
+		}
+	}
+	return nil
+}
+
+// saveAnimation writes anim as name.gif, as name_0001.png onwards, or as a
+// video, as the flags ask.
+func saveAnimation(o *options, name string, anim animation, a *animFlags) error {
+	switch {
+	case a.video != "":
+		if err := writeVideo(a.video, anim, a); err != nil {
+			return err
+		}
+		logger.Info("Written", "path", a.video)
+		return nil
+	case a.format == "frames":
+		return saveFiles(o, func(st store) ([]string, error) {
+			var fnames []string
+			for {
+				if _, ok := anim.next(); !ok {
+					return fnames, nil
+				}
+				fname := fmt.Sprintf("%s_%04d.png", name, len(fnames)+1)
+				if err := savePNG(st, fname, anim.canvas, o); err != nil {
+					return nil, err
+				}
+				fnames = append(fnames, fname)
+			}
+		})
+	}
+	fname := name + ".gif"
+	g := encodeGIF(anim, a)
+	return saveFile(o, fname, func(st store) error {
+		return st.put(fname, func(w io.Writer) error { return gif.EncodeAll(w, g) })
+	})
+}
+
+// encodeGIF converts the frames to a looping GIF sharing one palette. Each
+// frame only covers the area that changed.
+func encodeGIF(anim animation, a *animFlags) *gif.GIF {
+	q, _ := quantise(anim.colours, 256)
+	conv := newPaletteConverter(q.Palette)
+	g := &gif.GIF{}
+	for {
+		area, ok := anim.next()
+		if !ok {
+			break
+		}
+		g.Image = append(g.Image, conv.convert(anim.canvas, area))
+		g.Delay = append(g.Delay, gifDelay(a.delay))
+		g.Disposal = append(g.Disposal, gif.DisposalNone)
+	}
+	if len(g.Delay) > 0 {
+		g.Delay[len(g.Delay)-1] = gifDelay(a.hold)
+	}
+	return g
+}
+
+// writeVideo pipes the frames to ffmpeg as raw RGBA at one frame per
+// -frame-delay, repeating the last to make up -hold. The canvas is padded
+// to even dimensions, which H.264 needs.
+func writeVideo(path string, anim animation, a *animFlags) error {
+	b := anim.canvas.Bounds()
+	cmd := exec.Command("ffmpeg", "-y", "-loglevel", "error",
+		"-f", "rawvideo", "-pixel_format", "rgba",
+		"-video_size", fmt.Sprintf("%dx%d", b.Dx(), b.Dy()),
+		"-framerate", fmt.Sprintf("%d/%d", time.Second, a.delay),
+		"-i", "-",
+		"-vf", "pad=ceil(iw/2)*2:ceil(ih/2)*2:color=white", "-pix_fmt", "yuv420p",
+		path)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	frames := 0
+	for {
+		if _, ok := anim.next(); !ok {
+			break
+		}
+		if _, err = in.Write(anim.canvas.Pix); err != nil {
+			break
+		}
+		frames++
+	}
+	for i := 1; err == nil && frames > 0 && i < int(a.hold/a.delay); i++ {
+		_, err = in.Write(anim.canvas.Pix)
+	}
+	in.Close()
+	if werr := cmd.Wait(); werr != nil {
+		return fmt.Errorf("ffmpeg: %w\n%s", werr, stderr.Bytes())
+	}
+	return err
+}
+
+// Animations of the full table show a caption, such as the year or the
+// temperature, in the empty space above the transition metals.
+
+// captionBox is the space between columns 3 and 12 of the first three rows.
+func captionBox(t tableLayout) image.Rectangle {
+	return image.Rectangle{t.pos(Element{XPos: 3, YPos: 1}), t.pos(Element{XPos: 13, YPos: 3})}
+}
+
+// captionFont loads a face sized to fill half the height of box.
+func captionFont(r *render.Renderer, box image.Rectangle) (font.Face, error) {
+	return r.CardFont(float64(box.Dy()) / 2)
+}
+
+// drawCaption blanks box and centres label in it.
+func drawCaption(img *image.RGBA, face font.Face, box image.Rectangle, label string) {
+	draw.Draw(img, box, image.NewUniform(color.White), image.Point{}, draw.Src)
+	w := render.MeasureText(face, label).Round()
+	y := box.Min.Y + (box.Dy()+face.Metrics().Ascent.Round())/2
+	render.DrawText(img, face, box.Min.X+(box.Dx()-w)/2, y, label, color.Black)
+}
+
+// gifDelay converts a frame duration to GIF delay units of 10ms.
+func gifDelay(d time.Duration) int { return int(d / (10 * time.Millisecond)) }
+
+// paletteConverter maps pixels to the nearest colour in a fixed palette,
+// remembering each answer since frames share almost all their colours.
+type paletteConverter struct {
+	pal   color.Palette
+	cache map[color.RGBA]uint8
+}
+
+func newPaletteConverter(pal color.Palette) paletteConverter {
+	return paletteConverter{pal: pal, cache: map[color.RGBA]uint8{}}
+}
+
+func (pc paletteConverter) convert(img *image.RGBA, area image.Rectangle) *image.Paletted {
+	out := image.NewPaletted(area, pc.pal)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			idx, ok := pc.cache[c]
+			if !ok {
+				idx = uint8(pc.pal.Index(c))
+				pc.cache[c] = idx
+			}
+			out.Pix[out.PixOffset(x, y)] = idx
+		}
+	}
+	return out
+}
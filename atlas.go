@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"strings"
+)
+
+// Game engines atlas can write metadata for.
+const (
+	engineUnity = "unity"
+	engineGodot = "godot"
+)
+
+// atlasName is the sprite sheet's file name, without the extension.
+const atlasName = "elements_atlas"
+
+// atlasSprite is where a card is on the sprite sheet.
+type atlasSprite struct {
+	Name   string `json:"name"`
+	Number int    `json:"number"`
+	Symbol string `json:"symbol"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	W      int    `json:"w"`
+	H      int    `json:"h"`
+}
+
+// runAtlas packs every card into one sprite sheet, with a JSON index of
+// where each one is and, for the -engines asked for, files to import it
+// into a game engine as one sprite per element.
+func runAtlas(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("atlas", flag.ExitOnError)
+	o := addFlags(fs)
+	cols := fs.Int("columns", 0, "cards in each row of the sheet (default: as square a sheet as it can)")
+	padding := fs.Int("padding", 2, "transparent px around each card, so neighbours don't bleed into each other when scaled")
+	engines := fs.String("engines", engineUnity+","+engineGodot, "comma-separated engines to write import files for: unity for a .png.meta slicing the sheet, godot for an AtlasTexture .tres per card")
+	godotPath := fs.String("godot-path", "res://"+atlasName+".png", "where the sheet will be in the Godot project, for the .tres files to load it from")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	want := map[string]bool{}
+	for _, e := range strings.Split(*engines, ",") {
+		switch e = strings.TrimSpace(e); e {
+		case "":
+		case engineUnity, engineGodot:
+			want[e] = true
+		default:
+			return fmt.Errorf("unknown engine %q (want %s or %s)", e, engineUnity, engineGodot)
+		}
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	if len(elements) == 0 {
+		return fmt.Errorf("no elements to put on the sheet")
+	}
+
+	n := *cols
+	if n <= 0 {
+		n = int(math.Ceil(math.Sqrt(float64(len(elements)))))
+	}
+	pad := max(0, *padding)
+	cellW, cellH := r.TileW+2*pad, r.TileH+2*pad
+	rows := (len(elements) + n - 1) / n
+	sheet := image.NewRGBA(image.Rect(0, 0, min(n, len(elements))*cellW, rows*cellH))
+	var sprites []atlasSprite
+	for i, e := range elements {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted")
+		}
+		at := image.Pt(i%n*cellW+pad, i/n*cellH+pad)
+		draw.Draw(sheet, image.Rectangle{at, at.Add(image.Pt(r.TileW, r.TileH))}, r.Tile(e), image.Point{}, draw.Src)
+		sprites = append(sprites, atlasSprite{
+			Name: strings.TrimSuffix(tileFilename(e), ".png"), Number: e.Number, Symbol: e.Symbol,
+			X: at.X, Y: at.Y, W: r.TileW, H: r.TileH,
+		})
+	}
+
+	return saveFiles(o, func(st store) ([]string, error) {
+		png := atlasName + ".png"
+		if err := savePNG(st, png, sheet, o); err != nil {
+			return nil, err
+		}
+		files := []string{png}
+		put := func(name string, write func(w io.Writer) error) error {
+			files = append(files, name)
+			return st.put(name, write)
+		}
+		if err := put(atlasName+".json", func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]any{"image": png, "width": sheet.Rect.Dx(), "height": sheet.Rect.Dy(), "sprites": sprites})
+		}); err != nil {
+			return nil, err
+		}
+		if want[engineUnity] {
+			if err := put(png+".meta", func(w io.Writer) error { return writeUnityMeta(w, png, sheet.Rect.Dy(), sprites) }); err != nil {
+				return nil, err
+			}
+		}
+		if want[engineGodot] {
+			for _, s := range sprites {
+				if err := put(s.Name+".tres", func(w io.Writer) error { return writeGodotAtlasTexture(w, *godotPath, s) }); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return files, nil
+	})
+}
+
+// unityID makes a stable Unity GUID or sprite ID from a name, so running
+// again doesn't break references to the sprites in a project.
+func unityID(name string) string {
+	sum := md5.Sum([]byte("periodic-table-tiles/" + name))
+	return hex.EncodeToString(sum[:])
+}
+
+// writeUnityMeta writes the import settings Unity keeps next to a texture,
+// setting the sheet up as a sprite in Multiple mode with one sprite per
+// card. Unity measures the rects from the bottom of the image, and fills
+// in every setting left out with its default.
+func writeUnityMeta(w io.Writer, png string, height int, sprites []atlasSprite) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "fileFormatVersion: 2\nguid: %s\nTextureImporter:\n", unityID(png))
+	b.WriteString("  serializedVersion: 12\n  textureType: 8\n  spriteMode: 2\n  alphaIsTransparency: 1\n  mipmaps:\n    enableMipMap: 0\n")
+	b.WriteString("  spriteSheet:\n    serializedVersion: 2\n    sprites:\n")
+	for _, s := range sprites {
+		fmt.Fprintf(&b, "    - serializedVersion: 2\n      name: %s\n", s.Name)
+		fmt.Fprintf(&b, "      rect:\n        serializedVersion: 2\n        x: %d\n        y: %d\n        width: %d\n        height: %d\n", s.X, height-s.Y-s.H, s.W, s.H)
+		fmt.Fprintf(&b, "      alignment: 0\n      pivot: {x: 0.5, y: 0.5}\n      border: {x: 0, y: 0, z: 0, w: 0}\n      spriteID: %s\n", unityID(png+"/"+s.Name))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeGodotAtlasTexture writes a Godot 4 AtlasTexture resource showing one
+// card from the sheet at path.
+func writeGodotAtlasTexture(w io.Writer, path string, s atlasSprite) error {
+	_, err := fmt.Fprintf(w, `[gd_resource type="AtlasTexture" load_steps=2 format=3]
+
+[ext_resource type="Texture2D" path=%q id="1"]
+
+[resource]
+resource_name = %q
+atlas = ExtResource("1")
+region = Rect2(%d, %d, %d, %d)
+`, path, s.Symbol, s.X, s.Y, s.W, s.H)
+	return err
+}
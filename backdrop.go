@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// backdrop is a picture to draw the table over, such as school branding.
+type backdrop struct {
+	img     image.Image
+	opacity float64 // of the cards, from 0 to 1
+	blend   string
+}
+
+func loadBackdrop(path string, opacity float64, blend string) (*backdrop, error) {
+	if opacity < 0 || opacity > 1 {
+		return nil, fmt.Errorf("-tile-opacity must be from 0 to 1")
+	}
+	if err := checkBlend(blend); err != nil {
+		return nil, fmt.Errorf("-blend: %w", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &backdrop{img, opacity, blend}, nil
+}
+
+// canvas returns the picture scaled to cover a w×h image, cropping
+// whichever sides stick out equally, on white in case it has transparency.
+func (b *backdrop) canvas(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	src := b.img.Bounds()
+	scale := max(float64(w)/float64(src.Dx()), float64(h)/float64(src.Dy()))
+	cw, ch := int(float64(w)/scale), int(float64(h)/scale)
+	crop := image.Rect(0, 0, cw, ch).Add(src.Min).Add(image.Pt((src.Dx()-cw)/2, (src.Dy()-ch)/2))
+	xdraw.CatmullRom.Scale(img, img.Bounds(), b.img, crop, xdraw.Over, nil)
+	return img
+}
+
+// drawTile blends a card onto the canvas at at.
+func (b *backdrop) drawTile(dst, tile *image.RGBA, at image.Point) {
+	blendOnto(dst, tile, at, b.opacity, b.blend)
+}
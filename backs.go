@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"periodic-table-tiles/render"
+)
+
+// The text on the backs is smaller than the notes, to fit a paragraph.
+const backSize = 28
+
+// backFilename names a card's back after its front, so the two sort
+// together for printing.
+func backFilename(e Element) string {
+	return fmt.Sprintf("%03d_%s_back.png", e.Number, e.Symbol)
+}
+
+// runBacks renders the reverse side of every card, for printing them
+// two-sided: a thumbnail of the front with the element's key data beside it,
+// and the summary from the element data below.
+func runBacks(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("backs", flag.ExitOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if o.Shape != render.ShapeRect {
+		return fmt.Errorf("backs are laid out on %s cards only", render.ShapeRect)
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	face, err := r.CardFont(float64(r.TileH) / backSize)
+	if err != nil {
+		return fmt.Errorf("loading font: %w", err)
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	return saveFiles(o, func(st store) ([]string, error) {
+		var fnames []string
+		for _, e := range elements {
+			if ctx.Err() != nil {
+				return fnames, errors.New("interrupted")
+			}
+			fname := backFilename(e)
+			if err := savePNG(st, fname, r.Back(e, face), o); err != nil {
+				return fnames, fmt.Errorf("%s: %w", fname, err)
+			}
+			fnames = append(fnames, fname)
+		}
+		return fnames, nil
+	})
+}
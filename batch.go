@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// batchSpec is a batch file: render jobs run one after another, each a
+// mode with its flags on top of the shared defaults. In YAML:
+//
+//	defaults:
+//	  font: Roboto-Bold.ttf
+//	  data: elements.json
+//	jobs:
+//	  - name: print
+//	    flags: {height: 63mm, dpi: 300, outdir: print}
+//	  - name: web poster
+//	    mode: table
+//	    flags:
+//	      height: 120
+//	      format: svg
+//	      outdir: web
+//	  - mode: export
+//	    args: [periodic.db]
+//
+// Flag values are written as they would be on the command line; lists are
+// joined with commas and true turns a flag on.
+//
+// Jobs that are variations on each other can share templates: named jobs
+// that don't run themselves but that jobs and other templates extend,
+// taking their mode, flags and args and overriding only what differs.
+// With several, later ones override earlier ones, so small templates can
+// be mixed in like partials:
+//
+//	templates:
+//	  print: {flags: {dpi: 300}}
+//	  card: {flags: {height: 63mm, outdir: cards}}
+//	  poster: {mode: table, flags: {height: 30mm, format: tiff, colour-space: cmyk}}
+//	jobs:
+//	  - {name: flashcards, extends: [print, card]}
+//	  - {name: poster, extends: [print, poster], flags: {outdir: poster}}
+type batchSpec struct {
+	Defaults  map[string]any      `json:"defaults"`
+	Templates map[string]batchJob `json:"templates"`
+	Jobs      []batchJob          `json:"jobs"`
+}
+
+type batchJob struct {
+	Name    string         `json:"name"`
+	Extends names          `json:"extends"` // templates, by name
+	Mode    string         `json:"mode"`    // default: the cards
+	Flags   map[string]any `json:"flags"`
+	Args    []string       `json:"args"` // after the flags, such as export's file
+}
+
+// names is a list of names that may be written as a single one.
+type names []string
+
+func (n *names) UnmarshalJSON(bs []byte) error {
+	var one string
+	if err := json.Unmarshal(bs, &one); err == nil {
+		*n = names{one}
+		return nil
+	}
+	return json.Unmarshal(bs, (*[]string)(n))
+}
+
+// resolve returns the job with the templates it extends, and the ones
+// they extend, filled in under its own mode, flags and args. seen are the
+// templates being resolved already, to catch loops.
+func (spec batchSpec) resolve(j batchJob, seen []string) (batchJob, error) {
+	out := batchJob{Name: j.Name, Flags: map[string]any{}}
+	for _, name := range j.Extends {
+		if slices.Contains(seen, name) {
+			return out, fmt.Errorf("templates extend each other in a loop: %s", strings.Join(append(seen, name), " > "))
+		}
+		t, ok := spec.Templates[name]
+		if !ok {
+			return out, fmt.Errorf("no template %q", name)
+		}
+		t, err := spec.resolve(t, append(slices.Clip(seen), name))
+		if err != nil {
+			return out, err
+		}
+		if t.Mode != "" {
+			out.Mode = t.Mode
+		}
+		maps.Copy(out.Flags, t.Flags)
+		if t.Args != nil {
+			out.Args = t.Args
+		}
+	}
+	if j.Mode != "" {
+		out.Mode = j.Mode
+	}
+	maps.Copy(out.Flags, j.Flags)
+	if j.Args != nil {
+		out.Args = j.Args
+	}
+	return out, nil
+}
+
+func init() {
+	// Registered here, as runBatch looks up the other modes in commands.
+	commands["batch"] = runBatch
+}
+
+// runBatch runs every job in a batch file. The element data is only
+// fetched once however many jobs use it.
+func runBatch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "log the command line of each job instead of running it")
+	keepGoing := fs.Bool("keep-going", false, "carry on with the other jobs when one fails")
+	notifyURL := fs.String("notify-url", "", "POST a JSON report to this URL when the batch finishes, saying which jobs failed")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("usage: batch [flags] jobs.yaml")
+	}
+	spec, err := loadBatch(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	type run struct {
+		name, mode string
+		cmd        func(ctx context.Context, args []string) error
+		args       []string
+	}
+	var runs []run
+	for i, j := range spec.Jobs {
+		name := j.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		j, err := spec.resolve(j, nil)
+		if err != nil {
+			return fmt.Errorf("job %s: %w", name, err)
+		}
+		mode, cmd := "tiles", runTiles
+		if j.Mode != "" && j.Mode != mode {
+			c, ok := commands[j.Mode]
+			if !ok || j.Mode == "batch" || j.Mode == "serve" {
+				return fmt.Errorf("job %s: can't run mode %q in a batch", name, j.Mode)
+			}
+			mode, cmd = j.Mode, c
+		}
+		flags := maps.Clone(spec.Defaults)
+		if flags == nil {
+			flags = map[string]any{}
+		}
+		maps.Copy(flags, j.Flags)
+		var args []string
+		for _, k := range slices.Sorted(maps.Keys(flags)) {
+			args = append(args, fmt.Sprintf("-%s=%s", k, batchValue(flags[k])))
+		}
+		args = append(args, j.Args...)
+		runs = append(runs, run{name, mode, cmd, args})
+	}
+
+	elementsCache.enable()
+	batchFlags = true
+	start := time.Now()
+	var failed []string
+	err = func() error {
+		for _, r := range runs {
+			if *dryRun {
+				logger.Info("Would run", "name", r.name, "mode", r.mode, "args", strings.Join(r.args, " "))
+				continue
+			}
+			if ctx.Err() != nil {
+				return errors.New("interrupted")
+			}
+			logger.Info("Job", "name", r.name)
+			began := time.Now()
+			if err := r.cmd(ctx, r.args); err != nil {
+				failed = append(failed, r.name)
+				if !*keepGoing {
+					return fmt.Errorf("job %s: %w", r.name, err)
+				}
+				logger.Error("Job failed", "name", r.name, "error", err)
+				continue
+			}
+			logger.Info("Job finished", "name", r.name, "elapsed", time.Since(began).Round(time.Millisecond))
+		}
+		if len(failed) > 0 {
+			return fmt.Errorf("%d of %d jobs failed: %s", len(failed), len(runs), strings.Join(failed, ", "))
+		}
+		return nil
+	}()
+	if *notifyURL != "" && !*dryRun {
+		rep := report{
+			Mode:     "batch",
+			Seconds:  time.Since(start).Seconds(),
+			Jobs:     len(runs),
+			Failed:   failed,
+			Finished: time.Now().UTC(),
+		}
+		if err != nil {
+			rep.Error = err.Error()
+		}
+		if nerr := notify(*notifyURL, rep); nerr != nil {
+			logger.Warn("Notify failed", "error", nerr)
+		}
+	}
+	return err
+}
+
+// batchValue writes a flag value from a batch file as it would be given on
+// the command line.
+func batchValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		parts := make([]string, len(v))
+		for i, p := range v {
+			parts[i] = batchValue(p)
+		}
+		return strings.Join(parts, ",")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// loadBatch reads a batch file, as JSON if it is named .json and as YAML
+// otherwise.
+func loadBatch(path string) (batchSpec, error) {
+	var spec batchSpec
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return spec, err
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		doc, err := parseYAML(string(bs))
+		if err != nil {
+			return spec, fmt.Errorf("%s: %w", path, err)
+		}
+		if bs, err = json.Marshal(doc); err != nil {
+			return spec, err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	// Numbers are kept as written, so a seed of 1234567 isn't passed on
+	// as 1.234567e+06.
+	dec.UseNumber()
+	if err := dec.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(spec.Jobs) == 0 {
+		return spec, fmt.Errorf("%s: no jobs", path)
+	}
+	return spec, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadBatchKeepsNumbers(t *testing.T) {
+	for name, src := range map[string]string{
+		"jobs.yaml": "jobs:\n  - flags: {seed: 1234567, height: 63mm, gamma: 1.25}\n",
+		"jobs.json": `{"jobs": [{"flags": {"seed": 1234567, "height": "63mm", "gamma": 1.25}}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			spec, err := loadBatch(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fs := flag.NewFlagSet("cards", flag.ContinueOnError)
+			o := addFlags(fs)
+			for k, v := range spec.Jobs[0].Flags {
+				if err := fs.Set(k, batchValue(v)); err != nil {
+					t.Errorf("-%s=%s: %v", k, batchValue(v), err)
+				}
+			}
+			if o.Seed != 1234567 {
+				t.Errorf("seed = %d, want 1234567", o.Seed)
+			}
+		})
+	}
+}
+
+func TestBatchValue(t *testing.T) {
+	for _, tc := range []struct {
+		v    any
+		want string
+	}{
+		{int64(1234567), "1234567"},
+		{float64(1234567), "1234567"},
+		{0.25, "0.25"},
+		{true, "true"},
+		{nil, ""},
+		{[]any{"Fe", int64(27), "Ni"}, "Fe,27,Ni"},
+	} {
+		if got := batchValue(tc.v); got != tc.want {
+			t.Errorf("batchValue(%#v) = %q, want %q", tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestBatchNotify(t *testing.T) {
+	t.Cleanup(func() { batchFlags = false })
+	reports := make(chan report, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var rep report
+		if err := json.NewDecoder(req.Body).Decode(&rep); err != nil {
+			t.Error(err)
+		}
+		reports <- rep
+	}))
+	defer srv.Close()
+
+	// Both jobs fail on a flag their mode doesn't have, without rendering.
+	path := filepath.Join(t.TempDir(), "jobs.yaml")
+	src := "jobs:\n  - {name: cards, flags: {bogus: 1}}\n  - {name: strip, mode: strip, flags: {bogus: 2}}\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := runBatch(context.Background(), []string{"-keep-going", "-notify-url", srv.URL, path})
+	if err == nil || !strings.Contains(err.Error(), "2 of 2 jobs failed") {
+		t.Fatalf("error = %v", err)
+	}
+	select {
+	case rep := <-reports:
+		if rep.Mode != "batch" || rep.Jobs != 2 || !reflect.DeepEqual(rep.Failed, []string{"cards", "strip"}) || rep.Error != err.Error() {
+			t.Errorf("report = %+v", rep)
+		}
+	default:
+		t.Fatal("no report")
+	}
+}
+
+func TestBatchResolve(t *testing.T) {
+	spec := batchSpec{Templates: map[string]batchJob{
+		"print":  {Flags: map[string]any{"dpi": 300, "height": "1in"}},
+		"card":   {Flags: map[string]any{"height": "63mm", "outdir": "cards"}, Args: []string{"card"}},
+		"poster": {Extends: names{"print"}, Mode: "table", Flags: map[string]any{"height": "30mm"}},
+		"export": {Mode: "export", Args: []string{"periodic.db"}},
+	}}
+	for _, tt := range []struct {
+		name string
+		job  batchJob
+		want batchJob
+	}{
+		{"none", batchJob{Flags: map[string]any{"seed": 1}}, batchJob{Flags: map[string]any{"seed": 1}}},
+		// Later templates override earlier ones, and the job overrides
+		// them all.
+		{"order", batchJob{Extends: names{"card", "poster"}, Flags: map[string]any{"outdir": "poster"}},
+			batchJob{Mode: "table", Flags: map[string]any{"dpi": 300, "height": "30mm", "outdir": "poster"}, Args: []string{"card"}}},
+		{"reversed", batchJob{Extends: names{"poster", "card"}},
+			batchJob{Mode: "table", Flags: map[string]any{"dpi": 300, "height": "63mm", "outdir": "cards"}, Args: []string{"card"}}},
+		{"own mode and args", batchJob{Extends: names{"poster", "export"}, Mode: "strip", Args: []string{}},
+			batchJob{Mode: "strip", Flags: map[string]any{"dpi": 300, "height": "30mm"}, Args: []string{}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.job.Name, tt.want.Name = tt.name, tt.name
+			got, err := spec.resolve(tt.job, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+	if spec.Templates["poster"].Flags["dpi"] != nil {
+		t.Error("resolve changed a template")
+	}
+}
+
+func TestBatchResolveErrors(t *testing.T) {
+	spec := batchSpec{Templates: map[string]batchJob{
+		"self": {Extends: names{"self"}},
+		"a":    {Extends: names{"b"}},
+		"b":    {Extends: names{"ok", "c"}},
+		"c":    {Extends: names{"a"}},
+		"ok":   {Flags: map[string]any{"dpi": 300}},
+		"lost": {Extends: names{"ok", "nowhere"}},
+	}}
+	for _, tt := range []struct {
+		extends names
+		want    string
+	}{
+		{names{"self"}, "templates extend each other in a loop: self > self"},
+		{names{"ok", "a"}, "templates extend each other in a loop: a > b > c > a"},
+		{names{"lost"}, `no template "nowhere"`},
+		{names{"ok", "missing"}, `no template "missing"`},
+	} {
+		_, err := spec.resolve(batchJob{Extends: tt.extends}, nil)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("extends %q: error = %v, want %q", tt.extends, err, tt.want)
+		}
+	}
+}
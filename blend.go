@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"image"
+)
+
+// Blend modes for combining a layer, such as the cards or an overlay
+// item, with what is under it.
+const (
+	blendNormal   = "normal"
+	blendMultiply = "multiply"
+	blendScreen   = "screen"
+	blendOverlay  = "overlay"
+)
+
+func checkBlend(mode string) error {
+	switch mode {
+	case blendNormal, blendMultiply, blendScreen, blendOverlay:
+		return nil
+	}
+	return fmt.Errorf("unknown blend mode %q (want %s, %s, %s or %s)", mode, blendNormal, blendMultiply, blendScreen, blendOverlay)
+}
+
+// blendOnto draws src onto the opaque image dst with its top-left corner
+// at at, combining them with the blend mode and fading src to opacity,
+// from 0 to 1.
+func blendOnto(dst, src *image.RGBA, at image.Point, opacity float64, mode string) {
+	r := src.Bounds().Add(at).Intersect(dst.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			s := src.Pix[src.PixOffset(x-at.X, y-at.Y):][:4:4]
+			if s[3] == 0 {
+				continue
+			}
+			d := dst.Pix[dst.PixOffset(x, y):][:4:4]
+			a := float64(s[3]) / 255 * opacity
+			for i := range 3 {
+				cb, cs := float64(d[i])/255, float64(s[i])/float64(s[3])
+				c := blendChannel(mode, cb, cs)
+				d[i] = uint8((cb*(1-a)+c*a)*255 + 0.5)
+			}
+		}
+	}
+}
+
+// blendChannel applies a blend mode to a channel of the backdrop and the
+// layer over it, both from 0 to 1.
+func blendChannel(mode string, cb, cs float64) float64 {
+	switch mode {
+	case blendMultiply:
+		return cb * cs
+	case blendScreen:
+		return cb + cs - cb*cs
+	case blendOverlay:
+		if cb < 0.5 {
+			return 2 * cb * cs
+		}
+		return 1 - 2*(1-cb)*(1-cs)
+	}
+	return cs
+}
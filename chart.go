@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"periodic-table-tiles/render"
+)
+
+type chartSeries struct {
+	Label  string
+	Colour color.RGBA
+	Points []render.Point
+	Line   bool // join the points in order instead of drawing markers
+}
+
+// chartNote is a text label placed next to a data point.
+type chartNote struct {
+	At   render.Point
+	Text string
+}
+
+// chart is a simple x/y plot with a title, labelled axes, a grid and a
+// legend. The axis ranges come from the data unless FixedX/FixedY are set.
+type chart struct {
+	Title, XLabel, YLabel string
+	Series                []chartSeries
+	Notes                 []chartNote
+
+	XMin, XMax, YMin, YMax float64
+	FixedX, FixedY         bool
+}
+
+func (c *chart) bounds() {
+	if !c.FixedX {
+		c.XMin, c.XMax = math.Inf(1), math.Inf(-1)
+	}
+	if !c.FixedY {
+		c.YMin, c.YMax = math.Inf(1), math.Inf(-1)
+	}
+	for _, s := range c.Series {
+		for _, p := range s.Points {
+			if !c.FixedX {
+				c.XMin, c.XMax = math.Min(c.XMin, p.X), math.Max(c.XMax, p.X)
+			}
+			if !c.FixedY {
+				c.YMin, c.YMax = math.Min(c.YMin, p.Y), math.Max(c.YMax, p.Y)
+			}
+		}
+	}
+	if math.IsInf(c.XMin, 0) || c.XMin == c.XMax {
+		c.XMin, c.XMax = c.XMin-1, c.XMin+1
+	}
+	if math.IsInf(c.YMin, 0) || c.YMin == c.YMax {
+		c.YMin, c.YMax = c.YMin-1, c.YMin+1
+	}
+}
+
+// niceStep picks a round tick spacing giving roughly n ticks over span.
+func niceStep(span float64, n int) float64 {
+	raw := span / float64(n)
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 2.5, 5, 10} {
+		if raw <= m*mag {
+			return m * mag
+		}
+	}
+	return 10 * mag
+}
+
+func (c chart) render(fontPath string, w, h int) (*image.RGBA, error) {
+	titleFont, err := loadFont(fontPath, float64(h)/18)
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %w", err)
+	}
+	labelFont, err := loadFont(fontPath, float64(h)/32)
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %w", err)
+	}
+	c.bounds()
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	lh := labelFont.Metrics().Height.Round()
+	th := titleFont.Metrics().Height.Round()
+	plot := image.Rect(w/10, th*2+lh, w-w/20, h-lh*4)
+
+	tw := render.MeasureText(titleFont, c.Title).Round()
+	render.DrawText(img, titleFont, (w-tw)/2, th+lh/2, c.Title, color.Black)
+
+	px := func(x float64) float64 {
+		return float64(plot.Min.X) + (x-c.XMin)/(c.XMax-c.XMin)*float64(plot.Dx())
+	}
+	py := func(y float64) float64 {
+		return float64(plot.Max.Y) - (y-c.YMin)/(c.YMax-c.YMin)*float64(plot.Dy())
+	}
+
+	grid := color.RGBA{0xdd, 0xdd, 0xdd, 255}
+	stroke := math.Max(1, float64(h)/600)
+
+	// Grid and tick labels
+	xs := niceStep(c.XMax-c.XMin, 10)
+	for x := math.Ceil(c.XMin/xs) * xs; x <= c.XMax+xs/1e6; x += xs {
+		render.DrawLine(img, px(x), float64(plot.Min.Y), px(x), float64(plot.Max.Y), stroke, grid)
+		t := formatTick(x, xs)
+		render.DrawText(img, labelFont, int(px(x))-render.MeasureText(labelFont, t).Round()/2, plot.Max.Y+lh*3/2, t, color.Black)
+	}
+	ys := niceStep(c.YMax-c.YMin, 8)
+	for y := math.Ceil(c.YMin/ys) * ys; y <= c.YMax+ys/1e6; y += ys {
+		render.DrawLine(img, float64(plot.Min.X), py(y), float64(plot.Max.X), py(y), stroke, grid)
+		t := formatTick(y, ys)
+		render.DrawText(img, labelFont, plot.Min.X-lh/2-render.MeasureText(labelFont, t).Round(), int(py(y))+lh/3, t, color.Black)
+	}
+
+	// Axes and labels
+	render.DrawLine(img, float64(plot.Min.X), float64(plot.Max.Y), float64(plot.Max.X), float64(plot.Max.Y), stroke*2, color.Black)
+	render.DrawLine(img, float64(plot.Min.X), float64(plot.Min.Y), float64(plot.Min.X), float64(plot.Max.Y), stroke*2, color.Black)
+	xw := render.MeasureText(labelFont, c.XLabel).Round()
+	render.DrawText(img, labelFont, plot.Min.X+(plot.Dx()-xw)/2, plot.Max.Y+lh*3, c.XLabel, color.Black)
+	render.DrawText(img, labelFont, plot.Min.X-lh, plot.Min.Y-lh/2, c.YLabel, color.Black)
+
+	// Data
+	r := math.Max(2, float64(h)/250)
+	for _, s := range c.Series {
+		for i, p := range s.Points {
+			if s.Line {
+				if i > 0 {
+					q := s.Points[i-1]
+					render.DrawLine(img, px(q.X), py(q.Y), px(p.X), py(p.Y), stroke*2, s.Colour)
+				}
+				continue
+			}
+			render.FillCircle(img, px(p.X), py(p.Y), r, s.Colour)
+		}
+	}
+	for _, n := range c.Notes {
+		render.DrawText(img, labelFont, int(px(n.At.X)+r*2), int(py(n.At.Y)-r*2), n.Text, color.Black)
+	}
+
+	// Legend (bottom-right of the plot area)
+	ly := plot.Max.Y - lh/2
+	for i := len(c.Series) - 1; i >= 0; i-- {
+		s := c.Series[i]
+		lw := render.MeasureText(labelFont, s.Label).Round()
+		x := plot.Max.X - lh/2 - lw
+		render.DrawText(img, labelFont, x, ly, s.Label, color.Black)
+		render.FillCircle(img, float64(x-lh/2), float64(ly-lh/3), r*1.5, s.Colour)
+		ly -= lh
+	}
+	return img, nil
+}
+
+func formatTick(v, step float64) string {
+	if step >= 1 {
+		return fmt.Sprintf("%.0f", v)
+	}
+	d := int(math.Ceil(-math.Log10(step)))
+	return fmt.Sprintf("%.*f", d, v)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// runClean deletes what earlier runs wrote into -outdir: the files its
+// manifest lists, the manifest and SHA256SUMS, and then the folder if
+// nothing else is left in it. Files the manifest doesn't list are kept.
+// With -stale it deletes the other -out-versioned folders beside -outdir
+// instead, those drawn from other data or with other settings.
+func runClean(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	o := addFlags(fs)
+	stale := fs.Bool("stale", false, "with -out-versioned, delete the versioned folders beside the current one instead of it")
+	dryRun := fs.Bool("dry-run", false, "log the files that would be deleted without deleting them")
+	force := fs.Bool("force", false, "clean a folder even if its manifest was written with different options")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if *stale && !o.outVersioned {
+		return errors.New("-stale needs -out-versioned")
+	}
+	_, archive := archiveFormats[strings.ToLower(filepath.Ext(o.outdir))]
+	if strings.Contains(o.outdir, "://") || archive {
+		return fmt.Errorf("clean needs an -outdir folder, not %s", o.outdir)
+	}
+	base := o.outdir
+	if o.outVersioned {
+		// The folder is named after the data.
+		if _, err := loadElements(ctx, o); err != nil {
+			return err
+		}
+	}
+
+	if !*stale {
+		return cleanDir(o.outdir, o.settings, *force, *dryRun)
+	}
+	dirs, err := versionedDirs(base)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, dir := range dirs {
+		if filepath.Clean(dir) == filepath.Clean(o.outdir) {
+			continue
+		}
+		if err := cleanDir(dir, nil, true, *dryRun); err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		logger.Info("No stale folders", "beside", o.outdir)
+	}
+	return nil
+}
+
+// versionedDirs returns the folders -out-versioned has named from base
+// that have a manifest.
+func versionedDirs(base string) ([]string, error) {
+	matches, err := filepath.Glob(strings.TrimRight(base, `/\`) + "-*")
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, m := range matches {
+		// The name ends in the hash of the settings.
+		hash := m[strings.LastIndexByte(m, '-')+1:]
+		if len(hash) != 8 || strings.Trim(hash, "0123456789abcdef") != "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(m, manifestName)); err == nil {
+			dirs = append(dirs, m)
+		}
+	}
+	return dirs, nil
+}
+
+// cleanDir deletes the files the manifest in dir lists, with it and
+// SHA256SUMS, and then any folders left empty. Unless force is set, the
+// manifest must have been written with the same settings.
+func cleanDir(dir string, settings map[string]string, force, dryRun bool) error {
+	m, err := loadManifest(dirStore(dir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("nothing to clean: no %s in %s", manifestName, dir)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", dir, err)
+	}
+	if !force && !sameSettings(m.Options, settings) {
+		return fmt.Errorf("%s in %s was written with different options; -force cleans it anyway", manifestName, dir)
+	}
+
+	names := []string{manifestName, checksumsName}
+	for _, e := range m.Entries {
+		// Never anything outside the folder, whatever the manifest says.
+		if filepath.IsLocal(filepath.FromSlash(e.Path)) {
+			names = append(names, e.Path)
+		}
+	}
+	deleted := 0
+	for _, name := range names {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if dryRun {
+			if _, err := os.Stat(p); err == nil {
+				logger.Info("Would delete", "path", p)
+				deleted++
+			}
+			continue
+		}
+		err := os.Remove(p)
+		switch {
+		case err == nil:
+			deleted++
+		case !errors.Is(err, fs.ErrNotExist):
+			return err
+		}
+	}
+	if dryRun {
+		logger.Info("Would clean", "dir", dir, "files", deleted)
+		return nil
+	}
+
+	// Then the folders the files were in, deepest first, and dir itself,
+	// wherever nothing else is left.
+	var subdirs []string
+	for _, name := range names {
+		for d := path.Dir(name); d != "."; d = path.Dir(d) {
+			subdirs = append(subdirs, d)
+		}
+	}
+	slices.SortFunc(subdirs, func(a, b string) int {
+		if n := strings.Count(b, "/") - strings.Count(a, "/"); n != 0 {
+			return n
+		}
+		return strings.Compare(a, b)
+	})
+	for _, d := range slices.Compact(subdirs) {
+		os.Remove(filepath.Join(dir, filepath.FromSlash(d))) // fails if not empty
+	}
+	os.Remove(dir)
+	left, _ := os.ReadDir(dir)
+	logger.Info("Cleaned", "dir", dir, "files", deleted, "kept", len(left))
+	return nil
+}
+
+// sameSettings reports whether two sets of settings agree on the flags
+// both have. Each mode has flags of its own, and the manifest holds those
+// of whichever ran last.
+func sameSettings(a, b map[string]string) bool {
+	for k, v := range a {
+		if bv, ok := b[k]; ok && bv != v {
+			return false
+		}
+	}
+	return true
+}
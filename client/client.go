@@ -0,0 +1,225 @@
+// Package client talks to a periodic-table-tiles server started with
+// "serve", following the OpenAPI document it serves at /openapi.json:
+//
+//	c := client.New("http://localhost:8080", os.Getenv("TILES_KEY"))
+//	png, err := c.Tile(ctx, "Fe", 1200)
+//	job, err := c.CreateJob(ctx, client.JobRequest{Elements: []string{"Fe", "Co"}})
+//	job, err = c.Wait(ctx, job.ID, time.Second)
+//	zip, err := c.JobResult(ctx, job.ID)
+//
+// or, for cards wanted now, Render.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client makes requests to one server.
+type Client struct {
+	BaseURL    string       // such as http://localhost:8080
+	APIKey     string       // sent as a bearer token, if not ""
+	HTTPClient *http.Client // http.DefaultClient if nil
+}
+
+// New returns a client for the server at baseURL, sending apiKey with
+// every request if it isn't "".
+func New(baseURL, apiKey string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), APIKey: apiKey}
+}
+
+// JobRequest asks for a set of cards to be rendered into a zip.
+type JobRequest struct {
+	Elements  []string `json:"elements,omitempty"`   // symbols or atomic numbers; all of them if empty
+	Height    int      `json:"height,omitempty"`     // card height in px; the server's if 0
+	NotifyURL string   `json:"notify_url,omitempty"` // where to POST a report when the job finishes, on one of the server's -notify-hosts
+}
+
+// RenderRequest asks for every element at every height in every theme.
+type RenderRequest struct {
+	Elements []string `json:"elements,omitempty"` // symbols or atomic numbers; all of them if empty
+	Heights  []int    `json:"heights,omitempty"`  // card heights in px; the server's if empty
+	Themes   []string `json:"themes,omitempty"`   // colours files on the server, by name; its own if empty
+}
+
+// Job is how a job is getting on.
+type Job struct {
+	ID       string     `json:"id"`
+	Status   string     `json:"status"` // queued, running, done or failed
+	Elements []string   `json:"elements,omitempty"`
+	Height   int        `json:"height"`
+	Summary  *Summary   `json:"summary,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Created  time.Time  `json:"created"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Result   string     `json:"result,omitempty"`
+}
+
+// Over reports whether the job is done or failed.
+func (j *Job) Over() bool { return j.Status == "done" || j.Status == "failed" }
+
+// Summary counts the cards of a finished job.
+type Summary struct {
+	Total     int  `json:"total"`
+	Written   int  `json:"written"`
+	Skipped   int  `json:"skipped"`
+	Changed   int  `json:"changed"`
+	Failed    int  `json:"failed"`
+	Cancelled bool `json:"cancelled"`
+}
+
+// Error is a response from the server other than a success.
+type Error struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // for a 429, how long to wait
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Tile renders one card, by symbol or atomic number, and returns it as a
+// PNG. A height of 0 is the server's.
+func (c *Client) Tile(ctx context.Context, element string, height int) ([]byte, error) {
+	path := "/tiles/" + url.PathEscape(element)
+	if height != 0 {
+		path += "?height=" + strconv.Itoa(height)
+	}
+	return c.do(ctx, http.MethodGet, path, nil, nil)
+}
+
+// CreateJob queues a job, or returns the one already asked for with the
+// same cards.
+func (c *Client) CreateJob(ctx context.Context, jr JobRequest) (*Job, error) {
+	body, err := json.Marshal(jr)
+	if err != nil {
+		return nil, err
+	}
+	var j Job
+	if _, err := c.do(ctx, http.MethodPost, "/jobs", body, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// Job reports how a job is getting on.
+func (c *Client) Job(ctx context.Context, id string) (*Job, error) {
+	var j Job
+	if _, err := c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id), nil, &j); err != nil {
+		return nil, err
+	}
+	return &j, nil
+}
+
+// JobResult downloads a finished job's cards as a zip.
+func (c *Client) JobResult(ctx context.Context, id string) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, "/jobs/"+url.PathEscape(id)+"/result", nil, nil)
+}
+
+// Render renders a set of cards while the client waits and returns the
+// zip of them as it streams in, with entries named <theme>/<height>/<card>.
+// The caller must close it. A zip that is cut short failed on the server.
+func (c *Client) Render(ctx context.Context, rr RenderRequest) (io.ReadCloser, error) {
+	body, err := json.Marshal(rr)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(ctx, http.MethodPost, "/render", body)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// OpenAPI returns the OpenAPI document describing the server's API.
+func (c *Client) OpenAPI(ctx context.Context) ([]byte, error) {
+	return c.do(ctx, http.MethodGet, "/openapi.json", nil, nil)
+}
+
+// Wait asks how a job is getting on every interval until it has finished,
+// and returns it. A failed job is returned with an error.
+func (c *Client) Wait(ctx context.Context, id string, interval time.Duration) (*Job, error) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		j, err := c.Job(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if j.Status == "failed" {
+			return j, fmt.Errorf("job %s failed: %s", id, j.Error)
+		}
+		if j.Over() {
+			return j, nil
+		}
+		select {
+		case <-ctx.Done():
+			return j, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// do makes a request with an optional JSON body, decodes a JSON response
+// into out if it isn't nil, and otherwise returns the response body.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any) ([]byte, error) {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if out != nil {
+		return bs, json.Unmarshal(bs, out)
+	}
+	return bs, nil
+}
+
+// send makes a request with an optional JSON body and returns the response
+// if it is a success, or else an *Error.
+func (c *Client) send(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, rd)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		bs, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		e := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(bs))}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return nil, e
+	}
+	return resp, nil
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// spec is the part of the server's OpenAPI document the client follows.
+type spec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+	bs, err := os.ReadFile("../data/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sp spec
+	if err := json.Unmarshal(bs, &sp); err != nil {
+		t.Fatal(err)
+	}
+	return sp
+}
+
+// TestCoversSpec calls every client method against a server that records
+// the requests, and checks that they are exactly the operations in
+// data/openapi.json, so a path added to the server needs a method here.
+func TestCoversSpec(t *testing.T) {
+	sp := loadSpec(t)
+	var mu sync.Mutex
+	var got []string // "METHOD path"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		got = append(got, req.Method+" "+req.URL.Path)
+		mu.Unlock()
+		if strings.HasPrefix(req.URL.Path, "/jobs") && !strings.HasSuffix(req.URL.Path, "/result") {
+			io.WriteString(w, `{"id": "1", "status": "done"}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := New(srv.URL, "")
+	calls := map[string]func() error{
+		"Tile":      func() error { _, err := c.Tile(ctx, "Fe", 120); return err },
+		"CreateJob": func() error { _, err := c.CreateJob(ctx, JobRequest{}); return err },
+		"Job":       func() error { _, err := c.Job(ctx, "1"); return err },
+		"JobResult": func() error { _, err := c.JobResult(ctx, "1"); return err },
+		"Render": func() error {
+			rc, err := c.Render(ctx, RenderRequest{})
+			if err == nil {
+				rc.Close()
+			}
+			return err
+		},
+		"OpenAPI": func() error { _, err := c.OpenAPI(ctx); return err },
+	}
+	for name, call := range calls {
+		if err := call(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	// Each operation in the spec, as a pattern of the requests for it.
+	ops := map[string]*regexp.Regexp{}
+	for path, methods := range sp.Paths {
+		segs := strings.Split(path, "/")
+		for i, seg := range segs {
+			if strings.HasPrefix(seg, "{") {
+				segs[i] = "[^/]+"
+			} else {
+				segs[i] = regexp.QuoteMeta(seg)
+			}
+		}
+		pattern := strings.Join(segs, "/")
+		for method := range methods {
+			ops[strings.ToUpper(method)+" "+path] = regexp.MustCompile("^" + strings.ToUpper(method) + " " + pattern + "$")
+		}
+	}
+	for op, re := range ops {
+		if !slices.ContainsFunc(got, re.MatchString) {
+			t.Errorf("no client method for %s", op)
+		}
+	}
+	for _, req := range got {
+		matched := false
+		for _, re := range ops {
+			matched = matched || re.MatchString(req)
+		}
+		if !matched {
+			t.Errorf("the client makes %s, which isn't in the spec", req)
+		}
+	}
+}
+
+// TestSchemas checks that the client's types have the fields of the
+// spec's schemas of the same name.
+func TestSchemas(t *testing.T) {
+	sp := loadSpec(t)
+	for _, v := range []any{JobRequest{}, RenderRequest{}, Job{}, Summary{}} {
+		typ := reflect.TypeOf(v)
+		schema, ok := sp.Components.Schemas[typ.Name()]
+		if !ok {
+			t.Errorf("no schema %s", typ.Name())
+			continue
+		}
+		var fields, props []string
+		for i := range typ.NumField() {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			fields = append(fields, name)
+		}
+		for p := range schema.Properties {
+			props = append(props, p)
+		}
+		slices.Sort(fields)
+		slices.Sort(props)
+		if !slices.Equal(fields, props) {
+			t.Errorf("%s has fields %v, want %v", typ.Name(), fields, props)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cloudStores opens a bucket store from the rest of an -outdir URL after
+// the scheme, i.e. "bucket/prefix".
+var cloudStores = map[string]func(rest string) (store, error){
+	"s3": openS3,
+	"gs": openGCS,
+	"az": openAzure,
+}
+
+// bucketStore uploads each file to an object store with a single PUT.
+// Files are encoded into memory first so that a failed encode uploads
+// nothing, and the hash of every upload is kept so the manifest doesn't
+// have to download them again.
+type bucketStore struct {
+	name   string // the -outdir it was opened from
+	prefix string
+	url    func(key string) string
+	sign   func(req *http.Request, body []byte) error
+	client *http.Client
+	sums   map[string]storedFile
+}
+
+type storedFile struct {
+	sum  string
+	size int64
+}
+
+func newBucketStore(name, prefix string) *bucketStore {
+	return &bucketStore{
+		name:   name,
+		prefix: strings.Trim(prefix, "/"),
+		client: &http.Client{Timeout: 2 * time.Minute},
+		sums:   map[string]storedFile{},
+	}
+}
+
+// splitBucket splits "bucket/some/prefix" into the bucket and prefix.
+func splitBucket(scheme, rest string) (bucket, prefix string, err error) {
+	bucket, prefix, _ = strings.Cut(rest, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("-outdir %s://%s: missing bucket name", scheme, rest)
+	}
+	return bucket, prefix, nil
+}
+
+func (b *bucketStore) key(name string) string { return path.Join(b.prefix, name) }
+
+// do sends a request for a file. Errors never include the URL's query,
+// which may be a credential such as an Azure SAS token.
+func (b *bucketStore) do(method, name string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, b.url(b.key(name)), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: bad URL", method, b.key(name))
+	}
+	if method == http.MethodPut {
+		if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+			req.Header.Set("Content-Type", t)
+		}
+	}
+	if err := b.sign(req, body); err != nil {
+		return nil, err
+	}
+	resp, err := b.client.Do(req)
+	var ue *url.Error
+	if errors.As(err, &ue) {
+		ue.URL = redactURL(req.URL)
+	}
+	return resp, err
+}
+
+func (b *bucketStore) put(name string, write func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	resp, err := b.do(http.MethodPut, name, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError(resp)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	b.sums[name] = storedFile{hex.EncodeToString(sum[:]), int64(buf.Len())}
+	return nil
+}
+
+func (b *bucketStore) get(name string) ([]byte, error) {
+	resp, err := b.do(http.MethodGet, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", b.key(name), os.ErrNotExist)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (b *bucketStore) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	bs, err := b.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return (&memFile{name: name, data: bs}).open(), nil
+}
+
+func (b *bucketStore) sha256(name string) (string, int64, error) {
+	if f, ok := b.sums[name]; ok {
+		return f.sum, f.size, nil
+	}
+	bs, err := b.get(name)
+	if err != nil {
+		return "", 0, err
+	}
+	sum := sha256.Sum256(bs)
+	b.sums[name] = storedFile{hex.EncodeToString(sum[:]), int64(len(bs))}
+	return b.sums[name].sum, int64(len(bs)), nil
+}
+
+func (b *bucketStore) Close() error   { return nil }
+func (b *bucketStore) String() string { return b.name }
+
+// responseError turns a failed response into an error, including the start
+// of the body since the object stores explain themselves there.
+func responseError(resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s %s: %s %s", resp.Request.Method, redactURL(resp.Request.URL), resp.Status, bytes.TrimSpace(msg))
+}
+
+// redactURL writes a URL without its user info or query, either of which
+// may hold a credential, or its fragment.
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User, c.RawQuery, c.ForceQuery, c.Fragment, c.RawFragment = nil, "", false, "", ""
+	return c.String()
+}
+
+// openS3 opens s3://bucket/prefix using the standard AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION variables.
+// AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL) points it at an S3-compatible
+// service such as MinIO instead, using path-style addressing.
+func openS3(rest string) (store, error) {
+	bucket, prefix, err := splitBucket("s3", rest)
+	if err != nil {
+		return nil, err
+	}
+	keyID, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if keyID == "" || secret == "" {
+		return nil, errors.New("s3: AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	region := cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	endpoint := strings.TrimSuffix(cmp.Or(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")), "/")
+
+	b := newBucketStore("s3://"+rest, prefix)
+	b.url = func(key string) string {
+		if endpoint != "" {
+			return endpoint + "/" + bucket + "/" + awsEscape(key)
+		}
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, awsEscape(key))
+	}
+	b.sign = func(req *http.Request, body []byte) error {
+		signV4(req, body, keyID, secret, os.Getenv("AWS_SESSION_TOKEN"), region, time.Now().UTC())
+		return nil
+	}
+	return b, nil
+}
+
+// awsEscape percent-encodes an object key the way Signature Version 4
+// expects: everything but unreserved characters and slashes.
+func awsEscape(key string) string { return awsEncode(key, true) }
+
+// awsEncode percent-encodes everything but unreserved characters, and
+// slashes if keepSlash is set.
+func awsEncode(s string, keepSlash bool) string {
+	var sb strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/' && keepSlash:
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// signV4 adds an AWS Signature Version 4 Authorization header for S3,
+// signing the host and every header already set on req.
+func signV4(req *http.Request, body []byte, keyID, secret, token, region string, now time.Time) {
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, vs := range req.Header {
+		var values []string
+		for _, v := range vs {
+			values = append(values, strings.Join(strings.Fields(v), " "))
+		}
+		headers[strings.ToLower(k)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	var params []string
+	for k, vs := range query {
+		for _, v := range vs {
+			params = append(params, awsEncode(k, false)+"="+awsEncode(v, false))
+		}
+	}
+	sort.Strings(params)
+	canonical := strings.Join([]string{
+		req.Method,
+		awsEscape(cmp.Or(req.URL.Path, "/")),
+		strings.Join(params, "&"),
+		canonHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	canonHash := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonHash[:])
+
+	k := hmacSHA256([]byte("AWS4"+secret), date)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, "s3")
+	k = hmacSHA256(k, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(k, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", keyID, scope, signed, sig))
+}
+
+// openGCS opens gs://bucket/prefix through the Cloud Storage XML API with
+// the OAuth token in GOOGLE_OAUTH_ACCESS_TOKEN, or else the one gcloud
+// prints for the active account.
+func openGCS(rest string) (store, error) {
+	bucket, prefix, err := splitBucket("gs", rest)
+	if err != nil {
+		return nil, err
+	}
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+		if err != nil {
+			return nil, fmt.Errorf("gs: set GOOGLE_OAUTH_ACCESS_TOKEN or log in with gcloud: %w", err)
+		}
+		token = strings.TrimSpace(string(out))
+	}
+
+	b := newBucketStore("gs://"+rest, prefix)
+	b.url = func(key string) string {
+		return "https://storage.googleapis.com/" + bucket + "/" + awsEscape(key)
+	}
+	b.sign = func(req *http.Request, _ []byte) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	return b, nil
+}
+
+// openAzure opens az://account/container/prefix in Azure Blob Storage,
+// authorised by the SAS token in AZURE_STORAGE_SAS_TOKEN.
+func openAzure(rest string) (store, error) {
+	account, rest2, _ := strings.Cut(rest, "/")
+	container, prefix, err := splitBucket("az", rest2)
+	if account == "" || err != nil {
+		return nil, fmt.Errorf("-outdir az://%s: want az://account/container/prefix", rest)
+	}
+	sas := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+	if sas == "" {
+		return nil, errors.New("az: AZURE_STORAGE_SAS_TOKEN must be set")
+	}
+
+	b := newBucketStore("az://"+rest, prefix)
+	b.url = func(key string) string {
+		return fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s?%s", account, container, awsEscape(key), sas)
+	}
+	b.sign = func(req *http.Request, _ []byte) error {
+		req.Header.Set("X-Ms-Version", "2021-08-06")
+		if req.Method == http.MethodPut {
+			req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+		}
+		return nil
+	}
+	return b, nil
+}
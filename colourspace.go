@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"math"
+
+	"periodic-table-tiles/render"
+)
+
+// iccProfiles are the names and ICC profiles the RGB output is tagged
+// with, by -colour-space.
+var iccProfiles = map[string]struct {
+	name    string
+	profile []byte
+}{
+	render.ColourSpaceSRGB: {"sRGB", iccProfile("sRGB", [3][3]float64{
+		{0.4360747, 0.2225045, 0.0139322},
+		{0.3850649, 0.7168786, 0.0971045},
+		{0.1430804, 0.0606169, 0.7141733},
+	})},
+	render.ColourSpaceDisplayP3: {"Display P3", iccProfile("Display P3", [3][3]float64{
+		{0.5151215, 0.2411957, -0.0010533},
+		{0.2919769, 0.6922445, 0.0418854},
+		{0.1571045, 0.0665599, 0.7840679},
+	})},
+}
+
+// iccProfile builds a version 2 ICC display profile of an RGB space with
+// the sRGB tone curve and the given red, green and blue primaries, as XYZ
+// adapted to the D50 white the profile connection space uses.
+func iccProfile(name string, primaries [3][3]float64) []byte {
+	be := binary.BigEndian
+	s15 := func(b []byte, v float64) { be.PutUint32(b, uint32(int32(math.Round(v*65536)))) }
+	xyz := func(x [3]float64) []byte {
+		b := make([]byte, 20)
+		copy(b, "XYZ ")
+		for i, v := range x {
+			s15(b[8+4*i:], v)
+		}
+		return b
+	}
+	d50 := [3]float64{0.9642, 1, 0.8249}
+
+	desc := make([]byte, 12, 12+len(name)+1+4+4+3+67)
+	copy(desc, "desc")
+	be.PutUint32(desc[8:], uint32(len(name)+1))
+	desc = append(desc, name...)
+	desc = append(desc, make([]byte, 1+4+4+3+67)...) // no Unicode or ScriptCode names
+	cprt := append([]byte("text\x00\x00\x00\x00"), "No copyright, use freely\x00"...)
+
+	const points = 1024
+	trc := make([]byte, 12+2*points)
+	copy(trc, "curv")
+	be.PutUint32(trc[8:], points)
+	for i := range points {
+		v := float64(i) / (points - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		be.PutUint16(trc[12+2*i:], uint16(math.Round(v*65535)))
+	}
+
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	tags := []tag{
+		{"desc", desc}, {"cprt", cprt}, {"wtpt", xyz(d50)},
+		{"rXYZ", xyz(primaries[0])}, {"gXYZ", xyz(primaries[1])}, {"bXYZ", xyz(primaries[2])},
+		{"rTRC", trc}, {"gTRC", trc}, {"bTRC", trc},
+	}
+
+	header := make([]byte, 128, 128+4+12*len(tags))
+	be.PutUint32(header[8:], 0x02100000) // version 2.1
+	copy(header[12:], "mntrRGB XYZ ")
+	copy(header[36:], "acsp")
+	for i, v := range d50 {
+		s15(header[68+4*i:], v)
+	}
+	table := be.AppendUint32(nil, uint32(len(tags)))
+	var data []byte
+	offsets := map[*byte]int{} // the curves are shared by the three channels
+	start := len(header) + 4 + 12*len(tags)
+	for _, t := range tags {
+		off, ok := offsets[&t.data[0]]
+		if !ok {
+			off = start + len(data)
+			offsets[&t.data[0]] = off
+			data = append(data, t.data...)
+			for len(data)%4 != 0 {
+				data = append(data, 0)
+			}
+		}
+		table = append(table, t.sig...)
+		table = be.AppendUint32(table, uint32(off))
+		table = be.AppendUint32(table, uint32(len(t.data)))
+	}
+	profile := append(append(header, table...), data...)
+	be.PutUint32(profile, uint32(len(profile)))
+	return profile
+}
+
+// withICC adds an iCCP chunk tagging an encoded PNG with the -colour-space
+// profile.
+func withICC(png []byte, space string) []byte {
+	icc := iccProfiles[space]
+	var b bytes.Buffer
+	b.WriteString(icc.name)
+	b.Write([]byte{0, 0}) // the end of the name, then zlib compression
+	zw := zlib.NewWriter(&b)
+	zw.Write(icc.profile)
+	zw.Close()
+	return withChunk(png, "iCCP", b.Bytes())
+}
+
+// srgbToP3 turns linear sRGB into linear Display P3, which share a white.
+var srgbToP3 = [3][3]float64{
+	{0.8224621, 0.1775380, 0},
+	{0.0331941, 0.9668058, 0},
+	{0.0170827, 0.0723974, 0.9105199},
+}
+
+// toDisplayP3 converts an sRGB image to Display P3, leaving it looking the
+// same.
+func toDisplayP3(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(b)
+	draw.Draw(out, b, img, b.Min, draw.Src)
+	converted := map[[3]uint8][3]uint8{} // cards have few colours but many pixels
+	for i := 0; i < len(out.Pix); i += 4 {
+		px := (*[3]uint8)(out.Pix[i : i+3])
+		c, ok := converted[*px]
+		if !ok {
+			lin := [3]float64{render.Linear(px[0]), render.Linear(px[1]), render.Linear(px[2])}
+			for j, row := range srgbToP3 {
+				c[j] = render.Unlinear(row[0]*lin[0] + row[1]*lin[1] + row[2]*lin[2])
+			}
+			converted[*px] = c
+		}
+		*px = c
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"periodic-table-tiles/render"
+)
+
+// TestICCProfile checks the layout of each profile and that its primaries
+// add up to the D50 white, as they must for white to stay white. The
+// published sRGB primaries were adapted to a D50 with a Z of 0.8252 rather
+// than the profile connection space's 0.8249, as in every sRGB profile.
+func TestICCProfile(t *testing.T) {
+	be := binary.BigEndian
+	s15 := func(b []byte) float64 { return float64(int32(be.Uint32(b))) / 65536 }
+	for space, icc := range iccProfiles {
+		t.Run(space, func(t *testing.T) {
+			p := icc.profile
+			if int(be.Uint32(p)) != len(p) || string(p[36:40]) != "acsp" || string(p[12:24]) != "mntrRGB XYZ " {
+				t.Fatalf("bad header: % x", p[:40])
+			}
+			tags := map[string][]byte{}
+			offsets := map[string]uint32{}
+			for i := range int(be.Uint32(p[128:])) {
+				entry := p[132+12*i:]
+				sig, off, n := string(entry[:4]), be.Uint32(entry[4:]), be.Uint32(entry[8:])
+				if off%4 != 0 || int(off+n) > len(p) {
+					t.Fatalf("tag %s at %d of %d bytes runs past the end or isn't aligned", sig, off, n)
+				}
+				tags[sig], offsets[sig] = p[off:off+n], off
+			}
+			for _, sig := range []string{"desc", "cprt", "wtpt", "rXYZ", "gXYZ", "bXYZ", "rTRC", "gTRC", "bTRC"} {
+				if tags[sig] == nil {
+					t.Errorf("no %s tag", sig)
+				}
+			}
+			if name := tags["desc"][12 : 12+len(icc.name)]; string(name) != icc.name {
+				t.Errorf("description %q, want %q", name, icc.name)
+			}
+			if offsets["rTRC"] != offsets["gTRC"] || offsets["rTRC"] != offsets["bTRC"] {
+				t.Errorf("the tone curves aren't shared: %v", offsets)
+			}
+			for i := range 3 {
+				var sum float64
+				for _, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+					sum += s15(tags[sig][8+4*i:])
+				}
+				if white := s15(tags["wtpt"][8+4*i:]); math.Abs(sum-white) > 5e-4 {
+					t.Errorf("primaries add up to %.5f, want the white's %.5f", sum, white)
+				}
+			}
+		})
+	}
+}
+
+// TestEncodePNGColourSpace checks the PNGs carry the profile of their
+// -colour-space and still decode.
+func TestEncodePNGColourSpace(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for _, space := range []string{render.ColourSpaceSRGB, render.ColourSpaceDisplayP3} {
+		t.Run(space, func(t *testing.T) {
+			o := &options{Options: render.Options{ColourSpace: space}}
+			var b bytes.Buffer
+			if err := encodePNG(&b, img, o); err != nil {
+				t.Fatal(err)
+			}
+			bs := b.Bytes()
+			if _, err := png.Decode(bytes.NewReader(bs)); err != nil {
+				t.Fatal(err)
+			}
+			// The iCCP chunk goes straight after IHDR.
+			const at = 8 + 4 + 4 + 13 + 4
+			n := binary.BigEndian.Uint32(bs[at:])
+			if typ := string(bs[at+4 : at+8]); typ != "iCCP" {
+				t.Fatalf("chunk after IHDR is %s, want iCCP", typ)
+			}
+			name, compressed, _ := bytes.Cut(bs[at+8:at+8+n], []byte{0})
+			if string(name) != iccProfiles[space].name || compressed[0] != 0 {
+				t.Errorf("profile %q compressed with method %d", name, compressed[0])
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(compressed[1:]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			profile, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(profile, iccProfiles[space].profile) {
+				t.Errorf("the profile isn't %s's", space)
+			}
+		})
+	}
+
+	o := &options{Options: render.Options{ColourSpace: render.ColourSpaceCMYK}}
+	if err := encodePNG(io.Discard, img, o); err == nil || !strings.Contains(err.Error(), "use table -format tiff") {
+		t.Errorf("cmyk PNG: error = %v", err)
+	}
+}
+
+func TestToDisplayP3(t *testing.T) {
+	for _, tt := range []struct {
+		in, want color.NRGBA
+	}{
+		{color.NRGBA{255, 255, 255, 255}, color.NRGBA{255, 255, 255, 255}},
+		{color.NRGBA{0, 0, 0, 255}, color.NRGBA{0, 0, 0, 255}},
+		{color.NRGBA{128, 128, 128, 64}, color.NRGBA{128, 128, 128, 64}},
+		// The sRGB primaries lie inside Display P3's.
+		{color.NRGBA{255, 0, 0, 255}, color.NRGBA{234, 51, 35, 255}},
+		{color.NRGBA{0, 255, 0, 128}, color.NRGBA{117, 251, 76, 128}},
+		{color.NRGBA{0, 0, 255, 255}, color.NRGBA{0, 0, 245, 255}},
+	} {
+		img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+		img.SetNRGBA(0, 0, tt.in)
+		img.SetNRGBA(1, 0, tt.in)
+		out := toDisplayP3(img)
+		for x := range 2 {
+			got := out.NRGBAAt(x, 0)
+			if channelDiff(got.R, tt.want.R) > 1 || channelDiff(got.G, tt.want.G) > 1 || channelDiff(got.B, tt.want.B) > 1 || got.A != tt.want.A {
+				t.Errorf("toDisplayP3(%v) = %v, want %v", tt.in, got, tt.want)
+			}
+		}
+	}
+}
+
+// channelDiff is how far apart two channel values are.
+func channelDiff(a, b uint8) uint8 { return max(a, b) - min(a, b) }
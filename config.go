@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// loadConfig reads a -config file: flag values by name, written as they
+// would be on the command line, with a section for each mode that needs
+// flags of its own. As YAML:
+//
+//	font: Roboto-Bold.ttf
+//	colours: themes/dark.json
+//	height: 63mm
+//	dpi: 300
+//	mass-format: iupac
+//	valence: true
+//	categories: [halogen, noble gas]
+//	table:
+//	  format: svg
+//	  highlight: Fe,Co,Ni
+//
+// or as TOML if it is named .toml, with [table] for the section, or JSON if
+// it is named .json.
+//
+// A file can extend others, such as a base layout, with extends: a path or
+// a list of them, relative to the file. It takes their flags and sections
+// and overrides only the flags it sets, later files overriding earlier
+// ones, so variants such as flashcards, poster tiles and icons needn't
+// repeat the whole file:
+//
+//	extends: [base.yaml, print.yaml]
+//	height: 30mm
+//	table:
+//	  format: tiff
+func loadConfig(path string) (map[string]any, error) {
+	return extendConfig(filepath.Clean(path), nil)
+}
+
+// extendConfig reads the config file at path over the ones it extends.
+// seen are the files extending it already, to catch loops.
+func extendConfig(path string, seen []string) (map[string]any, error) {
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	var bases []string
+	switch v := config["extends"].(type) {
+	case nil:
+	case string:
+		bases = []string{v}
+	case []any:
+		for _, b := range v {
+			name, ok := b.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: extends: want file names, not %v", path, b)
+			}
+			bases = append(bases, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: extends: want a file name or a list of them", path)
+	}
+	delete(config, "extends")
+
+	seen = append(slices.Clip(seen), path)
+	out := map[string]any{}
+	for _, base := range bases {
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(path), base)
+		}
+		if slices.Contains(seen, base) {
+			return nil, fmt.Errorf("config files extend each other in a loop: %s", strings.Join(append(seen, base), " > "))
+		}
+		b, err := extendConfig(base, seen)
+		if err != nil {
+			return nil, err
+		}
+		mergeConfig(out, b)
+	}
+	mergeConfig(out, config)
+	return out, nil
+}
+
+// mergeConfig sets the flags in src over those in dst, merging the
+// sections for each mode flag by flag.
+func mergeConfig(dst, src map[string]any) {
+	for k, v := range src {
+		section, ok := v.(map[string]any)
+		base, isSection := dst[k].(map[string]any)
+		if ok && isSection {
+			base = maps.Clone(base)
+			maps.Copy(base, section)
+			v = base
+		}
+		dst[k] = v
+	}
+}
+
+// readConfig parses one config file.
+func readConfig(path string) (map[string]any, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		doc, err = parseTOML(string(bs))
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(bs))
+		dec.UseNumber() // as in batch files
+		err = dec.Decode(&doc)
+	default:
+		doc, err = parseYAML(string(bs))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if doc == nil {
+		return map[string]any{}, nil
+	}
+	config, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: want flag names and values", path)
+	}
+	return config, nil
+}
+
+// applyConfig sets the flags the -config file gives, with those in its
+// section for the mode fs is for taking over, except for the ones given on
+// the command line. The sections for other modes are left alone.
+func applyConfig(fs *flag.FlagSet, path string) error {
+	config, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	values := map[string]any{}
+	for k, v := range config {
+		if _, ok := v.(map[string]any); !ok {
+			values[k] = v
+		} else if fs.Lookup(k) != nil {
+			return fmt.Errorf("%s: %s: want a value, not a section", path, k)
+		}
+	}
+	if section, ok := config[fs.Name()].(map[string]any); ok {
+		maps.Copy(values, section)
+	}
+
+	given := flagsGiven(fs)
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		switch {
+		case name == "config":
+			return fmt.Errorf("%s: a config file can't name another", path)
+		case given[name]:
+			continue
+		case fs.Lookup(name) == nil:
+			logger.Warn("Config flag not used by this mode", "path", path, "flag", name, "mode", fs.Name())
+			continue
+		}
+		if err := fs.Set(name, batchValue(values[name])); err != nil {
+			return fmt.Errorf("%s: -%s: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// flagsGiven returns the names of the flags given on the command line,
+// along with the other names of each, such as -out for -outdir, which set
+// the same value.
+func flagsGiven(fs *flag.FlagSet) map[string]bool {
+	var values []flag.Value
+	fs.Visit(func(f *flag.Flag) { values = append(values, f.Value) })
+	given := map[string]bool{}
+	fs.VisitAll(func(f *flag.Flag) {
+		for _, v := range values {
+			if sameValue(f.Value, v) {
+				given[f.Name] = true
+			}
+		}
+	})
+	return given
+}
+
+// sameValue reports whether two flags set the same value. Values that
+// can't be compared, such as flag.Func's, are never the same.
+func sameValue(a, b flag.Value) bool {
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// configFlags parses args with the shared flags after writing a -config
+// file named name.
+func configFlags(t *testing.T, name, src string, args ...string) *options {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("table", flag.ContinueOnError)
+	o := addFlags(fs)
+	if err := fs.Parse(append([]string{"-config", path}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyConfig(fs, path); err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestConfigNumbers(t *testing.T) {
+	for name, src := range map[string]string{
+		"c.json": `{"seed": 1234567, "gamma": 1.25}`,
+		"c.yaml": "seed: 1234567\ngamma: 1.25\n",
+		"c.toml": "seed = 1_234_567\ngamma = 1.25\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			o := configFlags(t, name, src)
+			if o.Seed != 1234567 || o.Gamma != 1.25 {
+				t.Errorf("seed, gamma = %d, %g, want 1234567, 1.25", o.Seed, o.Gamma)
+			}
+		})
+	}
+}
+
+func TestConfigOtherNames(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		args []string
+		want func(o *options) string
+		is   string
+	}{
+		{"outdir: config\n", []string{"-out", "cli"}, func(o *options) string { return o.outdir }, "cli"},
+		{"out: config\n", []string{"-outdir", "cli"}, func(o *options) string { return o.outdir }, "cli"},
+		{"colours: config.json\n", []string{"-colors", "cli.json"}, func(o *options) string { return o.ColoursPath }, "cli.json"},
+		{"outdir: config\n", nil, func(o *options) string { return o.outdir }, "config"},
+	} {
+		o := configFlags(t, "c.yaml", tc.src, tc.args...)
+		if got := tc.want(o); got != tc.is {
+			t.Errorf("%q with %q: got %q, want %q", tc.src, tc.args, got, tc.is)
+		}
+	}
+}
+
+// writeConfigs writes config files, by path, into a temporary directory and
+// returns it.
+func writeConfigs(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestConfigExtends(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"base.yaml": "shape: hex\nseed: 1\ngamma: 1.5\ntable:\n  format: svg\n  highlight: Fe\n",
+		// Paths are relative to the file that names them, and a file can
+		// be extended more than once as long as it isn't in a loop.
+		"partials/print.toml": "extends = \"../base.yaml\"\nseed = 2\ndpi = 300\n[table]\nformat = \"tiff\"\n",
+		"cards.yaml":          "extends: [base.yaml, partials/print.toml]\ngamma: 2\ntable:\n  highlight: Co\n",
+		"one.json":            `{"extends": "cards.yaml", "shape": "circle"}`,
+	})
+	for _, tt := range []struct {
+		name string
+		want map[string]any
+	}{
+		{"base.yaml", map[string]any{
+			"shape": "hex", "seed": int64(1), "gamma": 1.5,
+			"table": map[string]any{"format": "svg", "highlight": "Fe"},
+		}},
+		{"cards.yaml", map[string]any{
+			"shape": "hex", "seed": int64(2), "gamma": int64(2), "dpi": int64(300),
+			"table": map[string]any{"format": "tiff", "highlight": "Co"},
+		}},
+		{"one.json", map[string]any{
+			"shape": "circle", "seed": int64(2), "gamma": int64(2), "dpi": int64(300),
+			"table": map[string]any{"format": "tiff", "highlight": "Co"},
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadConfig(filepath.Join(dir, tt.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigExtendsErrors(t *testing.T) {
+	dir := writeConfigs(t, map[string]string{
+		"self.yaml":    "extends: self.yaml\n",
+		"a.yaml":       "extends: sub/b.yaml\n",
+		"sub/b.yaml":   "extends: [../c.yaml, ../a.yaml]\n",
+		"c.yaml":       "height: 100\n",
+		"missing.yaml": "extends: nowhere.yaml\n",
+		"number.yaml":  "extends: 3\n",
+		"list.yaml":    "extends: [c.yaml, 3]\n",
+	})
+	for _, tt := range []struct {
+		name, want string
+	}{
+		{"self.yaml", "in a loop: self.yaml > self.yaml"},
+		{"a.yaml", "in a loop: a.yaml > sub/b.yaml > a.yaml"},
+		{"missing.yaml", "nowhere.yaml"},
+		{"number.yaml", "extends: want a file name or a list of them"},
+		{"list.yaml", "extends: want file names, not 3"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig(filepath.Join(dir, tt.name))
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if msg := strings.ReplaceAll(filepath.ToSlash(err.Error()), filepath.ToSlash(dir)+"/", ""); !strings.Contains(msg, tt.want) {
+				t.Errorf("error = %q, want %q", msg, tt.want)
+			}
+		})
+	}
+}
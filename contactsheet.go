@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"slices"
+
+	xdraw "golang.org/x/image/draw"
+
+	"periodic-table-tiles/render"
+)
+
+// runContactSheet puts thumbnails of the cards already generated in
+// -outdir, as listed in its manifest, side by side in one image with the
+// file name under each, to look over a whole run at once.
+func runContactSheet(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("contact-sheet", flag.ExitOnError)
+	o := addFlags(fs)
+	cols := fs.Int("columns", 10, "thumbnails in each row")
+	thumbH := fs.Int("thumb-height", 120, "height of each thumbnail in px")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if *cols < 1 || *thumbH < 8 {
+		return fmt.Errorf("-columns must be at least 1 and -thumb-height at least 8")
+	}
+
+	st, err := openStore(o)
+	if err != nil {
+		return err
+	}
+	m, err := loadManifest(st)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no cards in %s: generate them first", st)
+	}
+	if err != nil {
+		return err
+	}
+	var cards []manifestEntry
+	for _, e := range m.Entries {
+		if e.Number > 0 {
+			cards = append(cards, e)
+		}
+	}
+	if len(cards) == 0 {
+		return fmt.Errorf("no cards in %s: generate them first", st)
+	}
+	slices.SortFunc(cards, func(a, b manifestEntry) int { return a.Number - b.Number })
+
+	var thumbs []*image.RGBA
+	var names []string
+	for _, c := range cards {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted")
+		}
+		img, err := readStoredImage(st, c.Path)
+		if err != nil {
+			return err
+		}
+		b := img.Bounds()
+		w := max(1, b.Dx()**thumbH/b.Dy())
+		thumb := image.NewRGBA(image.Rect(0, 0, w, *thumbH))
+		xdraw.CatmullRom.Scale(thumb, thumb.Bounds(), img, b, xdraw.Src, nil)
+		thumbs = append(thumbs, thumb)
+		names = append(names, c.Path)
+	}
+	sheet, err := contactSheet(o.FontPath, thumbs, names, *cols)
+	if err != nil {
+		return err
+	}
+	return saveAsset(o, "contact_sheet.png", sheet)
+}
+
+// readStoredImage decodes an image written to st earlier.
+func readStoredImage(st store, name string) (image.Image, error) {
+	f, err := st.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return img, nil
+}
+
+// contactSheet lays the thumbnails out in a grid of cols columns, each in
+// a cell as wide as the widest, with its name in small type below.
+func contactSheet(fontPath string, thumbs []*image.RGBA, names []string, cols int) (*image.RGBA, error) {
+	cellW, thumbH := 0, 0
+	for _, t := range thumbs {
+		cellW, thumbH = max(cellW, t.Rect.Dx()), max(thumbH, t.Rect.Dy())
+	}
+	face, err := loadFont(fontPath, max(8, float64(thumbH)/10))
+	if err != nil {
+		return nil, err
+	}
+	pad := max(2, thumbH/20)
+	labelH := face.Metrics().Height.Round()
+	cellW += pad
+	cellH := thumbH + labelH + 2*pad
+	cols = min(cols, len(thumbs))
+	rows := (len(thumbs) + cols - 1) / cols
+	sheet := image.NewRGBA(image.Rect(0, 0, cols*cellW+pad, rows*cellH+pad))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for i, t := range thumbs {
+		cell := image.Pt(pad+i%cols*cellW, pad+i/cols*cellH)
+		at := cell.Add(image.Pt((cellW-pad-t.Rect.Dx())/2, 0))
+		draw.Draw(sheet, t.Rect.Add(at), t, image.Point{}, draw.Over)
+		label := render.FitText(face, names[i], cellW-pad)
+		x := cell.X + (cellW-pad-render.MeasureText(face, label).Round())/2
+		render.DrawText(sheet, face, x, cell.Y+thumbH+pad+face.Metrics().Ascent.Round(), label, color.Black)
+	}
+	return sheet, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"periodic-table-tiles/render"
+)
+
+// runCSS writes the card colours as CSS custom properties, so a web page
+// can use the same colours as the images made with the same flags.
+func runCSS(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("css", flag.ExitOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	const fname = "elements.css"
+	return saveFile(o, fname, func(st store) error {
+		return st.put(fname, func(w io.Writer) error { return writeCSS(w, r, elements) })
+	})
+}
+
+// cssName turns a category into the part of a custom property name after
+// --el-category-, e.g. "noble gas" into noble-gas.
+func cssName(category string) string {
+	var b strings.Builder
+	for _, c := range category {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == ' ' || c == '-':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// writeCSS writes a :root rule with a property for every category with a
+// colour, and one for every element named like its id in the SVG sprite.
+func writeCSS(w io.Writer, r *render.Renderer, elements []Element) error {
+	seen := map[string]bool{}
+	for category := range r.Colours {
+		seen[category] = true
+	}
+	for _, e := range elements {
+		seen[r.Category(e.Type)] = true
+	}
+	var b strings.Builder
+	b.WriteString(":root {\n")
+	for _, category := range slices.Sorted(maps.Keys(seen)) {
+		fmt.Fprintf(&b, "  --el-category-%s: %s;\n", cssName(category), render.RGBHex(r.CategoryColour(category)))
+	}
+	for _, e := range elements {
+		fmt.Fprintf(&b, "  --%s: %s; /* %s */\n", spriteID(e), render.RGBHex(r.CategoryColour(e.Type)), e.Symbol)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+
+	"golang.org/x/image/font"
+
+	"periodic-table-tiles/render"
+)
+
+// elementDiff is what changed between two versions of the element data.
+type elementDiff struct {
+	Added   []diffElement `json:"added"`
+	Removed []diffElement `json:"removed"`
+	Changed []diffChange  `json:"changed"`
+}
+
+type diffElement struct {
+	Number int    `json:"number"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+}
+
+type diffChange struct {
+	diffElement
+	Fields []fieldChange `json:"fields"`
+}
+
+type fieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// loadRawElements reads a dataset in the upstream PeriodicTableJSON format,
+// keeping every field, by atomic number.
+func loadRawElements(path string) (map[int]map[string]any, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root struct {
+		Elements []map[string]any `json:"elements"`
+	}
+	if err := json.Unmarshal(bs, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	es := map[int]map[string]any{}
+	for i, e := range root.Elements {
+		n, ok := e["number"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s: element %d has no number", path, i+1)
+		}
+		es[int(n)] = e
+	}
+	return es, nil
+}
+
+func diffElements(old, new map[int]map[string]any) elementDiff {
+	ident := func(n int, e map[string]any) diffElement {
+		sym, _ := e["symbol"].(string)
+		name, _ := e["name"].(string)
+		return diffElement{n, sym, name}
+	}
+	d := elementDiff{Added: []diffElement{}, Removed: []diffElement{}, Changed: []diffChange{}}
+	var numbers []int
+	for n := range old {
+		numbers = append(numbers, n)
+	}
+	for n := range new {
+		if _, ok := old[n]; !ok {
+			numbers = append(numbers, n)
+		}
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		o, inOld := old[n]
+		e, inNew := new[n]
+		switch {
+		case !inOld:
+			d.Added = append(d.Added, ident(n, e))
+		case !inNew:
+			d.Removed = append(d.Removed, ident(n, o))
+		default:
+			var fields []string
+			for k := range o {
+				fields = append(fields, k)
+			}
+			for k := range e {
+				if _, ok := o[k]; !ok {
+					fields = append(fields, k)
+				}
+			}
+			slices.Sort(fields)
+			c := diffChange{diffElement: ident(n, e)}
+			for _, f := range fields {
+				if !reflect.DeepEqual(o[f], e[f]) {
+					c.Fields = append(c.Fields, fieldChange{f, o[f], e[f]})
+				}
+			}
+			if len(c.Fields) > 0 {
+				d.Changed = append(d.Changed, c)
+			}
+		}
+	}
+	return d
+}
+
+// printDiff writes d to w for reading, one line per change.
+func printDiff(w io.Writer, d elementDiff) {
+	for _, e := range d.Added {
+		fmt.Fprintf(w, "+ %d %s (%s)\n", e.Number, e.Symbol, e.Name)
+	}
+	for _, e := range d.Removed {
+		fmt.Fprintf(w, "- %d %s (%s)\n", e.Number, e.Symbol, e.Name)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(w, "~ %d %s (%s)\n", c.Number, c.Symbol, c.Name)
+		for _, f := range c.Fields {
+			fmt.Fprintf(w, "    %s: %s -> %s\n", f.Field, diffValue(f.Old), diffValue(f.New))
+		}
+	}
+}
+
+// diffValue formats a field value, cutting long ones such as summaries short.
+func diffValue(v any) string {
+	if v == nil {
+		return "(none)"
+	}
+	bs, _ := json.Marshal(v)
+	s := []rune(string(bs))
+	if len(s) > 60 {
+		return string(s[:57]) + "..."
+	}
+	return string(s)
+}
+
+func runData(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "diff" {
+		return fmt.Errorf("usage: data diff [flags] old.json new.json")
+	}
+	fs := flag.NewFlagSet("data diff", flag.ExitOnError)
+	o := addFlags(fs)
+	format := fs.String("format", "text", "text, or json for a machine-readable report")
+	poster := fs.Bool("poster", false, "also draw the table with the changes marked on it, in data_diff.png, using the card flags")
+	if err := parseFlags(fs, o, args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: data diff [flags] old.json new.json")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown -format %q (want text or json)", *format)
+	}
+	old, err := loadRawElements(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := loadRawElements(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	d := diffElements(old, new)
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+	} else {
+		printDiff(os.Stdout, d)
+	}
+	logger.Info("Data diff", "added", len(d.Added), "removed", len(d.Removed), "changed", len(d.Changed))
+	if !*poster {
+		return nil
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	oldElements, _, err := elementsCache.fetch(ctx, fs.Arg(0), "")
+	if err != nil {
+		return err
+	}
+	newElements, _, err := elementsCache.fetch(ctx, fs.Arg(1), "")
+	if err != nil {
+		return err
+	}
+	face, err := r.CardFont(float64(r.TileH) / 6)
+	if err != nil {
+		return err
+	}
+	return saveAsset(o, "data_diff.png", diffPoster(r, face, d, oldElements, newElements))
+}
+
+// The colours marking elements added and removed on the data diff poster.
+var (
+	diffAdded   = color.RGBA{0x2c, 0xa0, 0x2c, 0xff}
+	diffRemoved = color.RGBA{0xd6, 0x27, 0x28, 0xff}
+)
+
+// diffPoster draws the table as of the new data, with the elements it
+// removed in their old places. Cards that didn't change are grey. The rest
+// have a bar along the bottom split between a colour for each field that
+// changed, or for being added or removed, and a key to the colours goes
+// below the table.
+func diffPoster(r *render.Renderer, face font.Face, d elementDiff, old, new []Element) *image.RGBA {
+	elements := slices.Clone(new)
+	for _, e := range old {
+		if slices.ContainsFunc(d.Removed, func(rm diffElement) bool { return rm.Number == e.Number }) {
+			elements = append(elements, e)
+		}
+	}
+
+	// A colour for every field that changed, in name order, round the
+	// colour wheel.
+	var fields []string
+	marks := map[int][]string{}
+	for _, c := range d.Changed {
+		for _, f := range c.Fields {
+			marks[c.Number] = append(marks[c.Number], f.Field)
+			if !slices.Contains(fields, f.Field) {
+				fields = append(fields, f.Field)
+			}
+		}
+	}
+	slices.Sort(fields)
+	colours := map[string]color.RGBA{}
+	for i, f := range fields {
+		colours[f] = render.HSLToRGB(200+360*float64(i)/float64(len(fields)), 0.7, 0.45)
+	}
+	key := fields
+	if len(d.Added) > 0 {
+		key = append(key, "added")
+		colours["added"] = diffAdded
+	}
+	if len(d.Removed) > 0 {
+		key = append(key, "removed")
+		colours["removed"] = diffRemoved
+	}
+	for _, e := range d.Added {
+		marks[e.Number] = []string{"added"}
+	}
+	for _, e := range d.Removed {
+		marks[e.Number] = []string{"removed"}
+	}
+
+	hl := highlight{numbers: map[int]bool{}, dim: true}
+	for _, c := range d.Changed {
+		hl.numbers[c.Number] = true
+	}
+	for _, e := range d.Added {
+		hl.numbers[e.Number] = true
+	}
+	table := renderTable(r, elements, layoutGrid, hl, nil)
+	t := newTableLayout(r, elements, layoutGrid)
+	barH := r.TileH / 8
+	for _, e := range elements {
+		at := t.pos(e)
+		for i, f := range marks[e.Number] {
+			n := len(marks[e.Number])
+			bar := image.Rect(at.X+r.TileW*i/n, at.Y+r.TileH-barH, at.X+r.TileW*(i+1)/n, at.Y+r.TileH)
+			draw.Draw(table, bar, image.NewUniform(colours[f]), image.Point{}, draw.Src)
+		}
+	}
+
+	// The key: a swatch and name for each colour, in rows as wide as the
+	// table.
+	pad := r.TileH / 8
+	lineH := face.Metrics().Height.Round()
+	type entry struct {
+		at   image.Point
+		name string
+	}
+	var entries []entry
+	x, y := pad, t.H+pad
+	for _, name := range key {
+		w := lineH + pad/2 + render.MeasureText(face, name).Round()
+		if x > pad && x+w > t.W-pad {
+			x, y = pad, y+lineH+pad/2
+		}
+		entries = append(entries, entry{image.Pt(x, y), name})
+		x += w + pad*2
+	}
+	h := t.H
+	if len(entries) > 0 {
+		h = y + lineH + pad
+	}
+	img := image.NewRGBA(image.Rect(0, 0, t.W, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, table.Bounds(), table, image.Point{}, draw.Src)
+	for _, en := range entries {
+		swatch := image.Rect(en.at.X, en.at.Y, en.at.X+lineH, en.at.Y+lineH).Inset(lineH / 8)
+		draw.Draw(img, swatch, image.NewUniform(colours[en.name]), image.Point{}, draw.Src)
+		render.DrawText(img, face, en.at.X+lineH+pad/2, en.at.Y+face.Metrics().Ascent.Round(), en.name, color.Black)
+	}
+	return img
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periodic-table-tiles/render"
+)
+
+// writeDataset writes elements in the upstream format and returns the path.
+func writeDataset(t *testing.T, name, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(`{"elements": [`+src+`]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiffElements(t *testing.T) {
+	old, err := loadRawElements(writeDataset(t, "old.json", `
+		{"number": 1, "symbol": "H", "name": "Hydrogen", "atomic_mass": 1.008},
+		{"number": 2, "symbol": "He", "name": "Helium", "atomic_mass": 4.0026, "phase": "Gas"},
+		{"number": 26, "symbol": "Fe", "name": "Iron", "atomic_mass": 55.845, "shells": [2, 8, 14, 2]},
+		{"number": 119, "symbol": "Uue", "name": "Ununennium"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	new, err := loadRawElements(writeDataset(t, "new.json", `
+		{"number": 26, "symbol": "Fe", "name": "Iron", "atomic_mass": 55.845, "shells": [2, 8, 14, 2]},
+		{"number": 2, "symbol": "He", "name": "Helium", "atomic_mass": 4.002602, "summary": "A noble gas."},
+		{"number": 1, "symbol": "H", "name": "Hydrogen", "atomic_mass": 1.008},
+		{"number": 117, "symbol": "Ts", "name": "Tennessine"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := diffElements(old, new)
+	want := elementDiff{
+		Added:   []diffElement{{117, "Ts", "Tennessine"}},
+		Removed: []diffElement{{119, "Uue", "Ununennium"}},
+		Changed: []diffChange{{diffElement{2, "He", "Helium"}, []fieldChange{
+			{"atomic_mass", 4.0026, 4.002602},
+			{"phase", "Gas", nil},
+			{"summary", nil, "A noble gas."},
+		}}},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("got %+v\nwant %+v", d, want)
+	}
+
+	var b bytes.Buffer
+	printDiff(&b, d)
+	wantText := `+ 117 Ts (Tennessine)
+- 119 Uue (Ununennium)
+~ 2 He (Helium)
+    atomic_mass: 4.0026 -> 4.002602
+    phase: "Gas" -> (none)
+    summary: (none) -> "A noble gas."
+`
+	if b.String() != wantText {
+		t.Errorf("printDiff wrote\n%s\nwant\n%s", b.String(), wantText)
+	}
+}
+
+func TestDiffElementsSame(t *testing.T) {
+	es := map[int]map[string]any{26: {"number": 26.0, "symbol": "Fe"}}
+	d := diffElements(es, es)
+	// Empty lists rather than null, for the JSON report.
+	bs, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"added":[],"removed":[],"changed":[]}`; string(bs) != want {
+		t.Errorf("got %s, want %s", bs, want)
+	}
+	var b bytes.Buffer
+	printDiff(&b, d)
+	if b.Len() != 0 {
+		t.Errorf("printDiff wrote %q", b.String())
+	}
+}
+
+func TestDiffValue(t *testing.T) {
+	for _, tt := range []struct {
+		v    any
+		want string
+	}{
+		{nil, "(none)"},
+		{55.845, "55.845"},
+		{"Gas", `"Gas"`},
+		{[]any{2.0, 8.0}, "[2,8]"},
+		{strings.Repeat("é", 100), `"` + strings.Repeat("é", 56) + "..."},
+	} {
+		if got := diffValue(tt.v); got != tt.want {
+			t.Errorf("diffValue(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRawElementsErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		{"no number", `{"elements": [{"number": 1}, {"symbol": "He"}]}`, "element 2 has no number"},
+		{"not json", `elements`, "invalid character"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "data.json")
+			if err := os.WriteFile(path, []byte(tt.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadRawElements(path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestDiffPoster checks the bars along the bottom of the cards: a colour
+// for the field changed on helium, and green for hydrogen, which was
+// added. Removed iron is drawn in its old place.
+func TestDiffPoster(t *testing.T) {
+	r := testRenderer(t, "-height", "60")
+	face, err := r.CardFont(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iron := Element{Number: 26, Symbol: "Fe", Name: "Iron", Mass: 55.845, Type: "transition metal", XPos: 8, YPos: 4}
+	hydrogen := Element{Number: 1, Symbol: "H", Name: "Hydrogen", Mass: 1.008, Type: "reactive nonmetal", XPos: 1, YPos: 1}
+	d := elementDiff{
+		Added:   []diffElement{{1, "H", "Hydrogen"}},
+		Removed: []diffElement{{26, "Fe", "Iron"}},
+		Changed: []diffChange{{diffElement{2, "He", "Helium"}, []fieldChange{{"phase", "Gas", nil}}}},
+	}
+	img := diffPoster(r, face, d, []Element{testHelium, iron}, []Element{hydrogen, testHelium})
+
+	layout := newTableLayout(r, []Element{hydrogen, testHelium, iron}, layoutGrid)
+	if img.Bounds().Dx() != layout.W || img.Bounds().Dy() <= layout.H {
+		t.Fatalf("poster is %v, want %d wide with a key under the %d px table", img.Bounds(), layout.W, layout.H)
+	}
+	bar := func(e Element) image.Point {
+		at := layout.pos(e)
+		return image.Pt(at.X+r.TileW/2, at.Y+r.TileH-r.TileH/16)
+	}
+	for _, tt := range []struct {
+		e    Element
+		want color.RGBA
+	}{
+		{hydrogen, diffAdded},
+		{testHelium, render.HSLToRGB(200, 0.7, 0.45)},
+		{iron, diffRemoved},
+	} {
+		p := bar(tt.e)
+		if got := img.RGBAAt(p.X, p.Y); got != tt.want {
+			t.Errorf("%s's bar is %v, want %v", tt.e.Symbol, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+)
+
+// runDiff compares two images with the same perceptual measure as verify,
+// printing how much of them differs and, with -out, drawing a heatmap of
+// where: the first image faded to grey, with the differences over it from
+// yellow for slight to red for strong.
+func runDiff(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	out := fs.String("out", "", "write the heatmap to this PNG file")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errors.New("usage: diff [-out diff.png] a.png b.png")
+	}
+	var imgs [2]image.Image
+	for i := range imgs {
+		f, err := os.Open(fs.Arg(i))
+		if err != nil {
+			return err
+		}
+		imgs[i], _, err = image.Decode(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", fs.Arg(i), err)
+		}
+	}
+	heat, frac := diffHeatmap(imgs[0], imgs[1])
+	fmt.Printf("%.2f%% of pixels look different\n", frac*100)
+	if *out == "" {
+		return nil
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, heat); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out, b.Bytes(), 0o644); err != nil {
+		return err
+	}
+	logger.Info("Written", "path", *out)
+	return nil
+}
+
+// diffHeatmap draws where two images differ and returns the fraction of
+// pixels that look different, as perceptualDiff counts them. Where only
+// one image has pixels, because they are different sizes, counts as the
+// strongest difference.
+func diffHeatmap(a, b image.Image) (*image.RGBA, float64) {
+	ab, bb := a.Bounds(), b.Bounds()
+	w, h := max(ab.Dx(), bb.Dx()), max(ab.Dy(), bb.Dy())
+	heat := image.NewRGBA(image.Rect(0, 0, w, h))
+	n := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pa, pb := image.Pt(ab.Min.X+x, ab.Min.Y+y), image.Pt(bb.Min.X+x, bb.Min.Y+y)
+			strength := 1.0
+			var base color.Color = color.White
+			if pa.In(ab) {
+				base = a.At(pa.X, pa.Y)
+				if pb.In(bb) {
+					strength = math.Sqrt(yiqDelta(base, b.At(pb.X, pb.Y)) / yiqMaxDelta)
+				}
+			}
+			// The first image, as a pale grey on white.
+			r, g, bl, al := base.RGBA()
+			wh := float64(0xffff - al)
+			grey := ((float64(r)+wh)*0.299 + (float64(g)+wh)*0.587 + (float64(bl)+wh)*0.114) / 257
+			v := uint8(255 - (255-grey)/4)
+			c := color.RGBA{v, v, v, 255}
+			if strength > perceptualThreshold {
+				n++
+				// From yellow to red, over the grey.
+				hot := color.RGBA{255, uint8(230 * (1 - strength)), 0, 255}
+				t := 0.5 + strength/2
+				c = color.RGBA{mixByte(c.R, hot.R, t), mixByte(c.G, hot.G, t), mixByte(c.B, hot.B, t), 255}
+			}
+			heat.SetRGBA(x, y, c)
+		}
+	}
+	return heat, float64(n) / float64(w*h)
+}
+
+func mixByte(a, b uint8, t float64) uint8 { return uint8(float64(a)*(1-t) + float64(b)*t + 0.5) }
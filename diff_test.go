@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestYIQDelta(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		c1, c2 color.Color
+		want   float64
+	}{
+		{"red and cyan", color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 255, 255}, yiqMaxDelta},
+		{"black and white", color.Black, color.White, 32857},
+		{"same", color.RGBA{200, 30, 60, 255}, color.RGBA{200, 30, 60, 255}, 0},
+		// Both are composited on white first.
+		{"transparent", color.Transparent, color.White, 0},
+		{"half black", color.NRGBA{0, 0, 0, 128}, color.NRGBA{127, 127, 127, 255}, 0},
+	} {
+		if got := yiqDelta(tt.c1, tt.c2); math.Abs(got-tt.want) > 1 {
+			t.Errorf("%s: yiqDelta = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
+
+// diffImages returns a white image of w by h px and a copy with the given
+// pixels changed.
+func diffImages(w, h int, changed map[image.Point]color.Color) (a, b *image.RGBA) {
+	a, b = image.NewRGBA(image.Rect(0, 0, w, h)), image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			a.Set(x, y, color.White)
+			b.Set(x, y, color.White)
+		}
+	}
+	for p, c := range changed {
+		b.Set(p.X, p.Y, c)
+	}
+	return a, b
+}
+
+func TestPerceptualDiff(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		changed map[image.Point]color.Color
+		want    float64
+	}{
+		{"same", nil, 0},
+		{"one black", map[image.Point]color.Color{{1, 1}: color.Black}, 0.25},
+		// A shade or two of anti-aliasing doesn't count.
+		{"slight", map[image.Point]color.Color{{0, 0}: color.Gray{250}, {1, 0}: color.Gray{240}}, 0},
+		{"half", map[image.Point]color.Color{{0, 0}: color.Gray{200}, {1, 0}: color.RGBA{255, 0, 0, 255}}, 0.5},
+	} {
+		a, b := diffImages(2, 2, tt.changed)
+		if got := perceptualDiff(a, b); got != tt.want {
+			t.Errorf("%s: perceptualDiff = %g, want %g", tt.name, got, tt.want)
+		}
+		if _, got := diffHeatmap(a, b); got != tt.want {
+			t.Errorf("%s: diffHeatmap's fraction = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+
+	// Images are compared from their corners, wherever their bounds are.
+	a, b := diffImages(3, 3, map[image.Point]color.Color{{1, 1}: color.Black})
+	sub := b.SubImage(image.Rect(1, 1, 3, 3))
+	if got := perceptualDiff(sub, a); got != 0.25 {
+		t.Errorf("sub-image: perceptualDiff = %g, want 0.25", got)
+	}
+}
+
+func TestDiffHeatmap(t *testing.T) {
+	a, b := diffImages(3, 1, map[image.Point]color.Color{{1, 0}: color.Gray{215}, {2, 0}: color.RGBA{0, 255, 255, 255}})
+	a.Set(2, 0, color.RGBA{255, 0, 0, 255})
+	heat, frac := diffHeatmap(a, b)
+	if want := 2.0 / 3; frac != want {
+		t.Errorf("fraction = %g, want %g", frac, want)
+	}
+	// The same pixel stays as the first image, faded; a slight difference
+	// is yellowish and a strong one red.
+	if got := heat.RGBAAt(0, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("unchanged white is %v", got)
+	}
+	if got := heat.RGBAAt(1, 0); got.R != 255 || got.G < 200 || got.B > 128 {
+		t.Errorf("slight difference is %v, want yellowish", got)
+	}
+	if got := heat.RGBAAt(2, 0); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("red to cyan is %v, want red", got)
+	}
+
+	// Over the grey of a black first image.
+	black := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	black.Set(0, 0, color.Black)
+	heat, _ = diffHeatmap(black, black)
+	if got := heat.RGBAAt(0, 0); got != (color.RGBA{191, 191, 191, 255}) {
+		t.Errorf("unchanged black is %v, want a pale grey", got)
+	}
+
+	// Where only one image has pixels counts as the strongest difference.
+	a, b = diffImages(2, 2, nil)
+	heat, frac = diffHeatmap(a.SubImage(image.Rect(0, 0, 2, 1)), b)
+	if heat.Bounds() != b.Bounds() || frac != 0.5 {
+		t.Errorf("heatmap %v with %g different, want %v with 0.5", heat.Bounds(), frac, b.Bounds())
+	}
+	if got := heat.RGBAAt(1, 1); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("missing pixel is %v, want red", got)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	a, b := diffImages(4, 2, map[image.Point]color.Color{{3, 1}: color.Black})
+	write := func(name string, img image.Image) string {
+		path := filepath.Join(dir, name)
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if err := png.Encode(f, img); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	pa, pb := write("a.png", a), write("b.png", b)
+	out := filepath.Join(dir, "diff.png")
+	if err := runDiff(context.Background(), []string{"-out", out, pa, pb}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	heat, err := png.Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heat.Bounds() != a.Bounds() {
+		t.Errorf("heatmap is %v, want %v", heat.Bounds(), a.Bounds())
+	}
+	if r, g, _, _ := heat.At(3, 1).RGBA(); r != 0xffff || g > 0x1000 {
+		t.Errorf("changed pixel is %v, want red", heat.At(3, 1))
+	}
+
+	notImage := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notImage, []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{pa}, "usage: diff"},
+		{[]string{pa, filepath.Join(dir, "missing.png")}, "missing.png: no such file"},
+		{[]string{notImage, pb}, "notes.txt: image: unknown format"},
+	} {
+		err := runDiff(context.Background(), tt.args)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sort"
+	"time"
+
+	"periodic-table-tiles/render"
+)
+
+// runDiscovery renders an animated GIF of the full table filling in one
+// element at a time in order of discovery, with the year shown in the gap
+// above the transition metals.
+func runDiscovery(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("discovery", flag.ExitOnError)
+	o := addFlags(fs)
+	layout := fs.String("layout", layoutGrid, "grid, or honeycomb to interlock the rows of -shape hex tiles")
+	af := addAnimFlags(fs, 150*time.Millisecond, 3*time.Second)
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if err := checkLayout(*layout, o.Shape); err != nil {
+		return err
+	}
+	if err := af.check(); err != nil {
+		return err
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	if err := r.CheckStrict(elements); err != nil {
+		return err
+	}
+	anim, err := discoveryAnimation(r, elements, *layout)
+	if err != nil {
+		return err
+	}
+	return saveAnimation(o, "discovery", anim, af)
+}
+
+// discoveryAnimation has a frame for each element in order of discovery.
+// Undiscovered elements are shown as faint ghosts of their cards. After the
+// first frame only the card that appears and the year change.
+func discoveryAnimation(r *render.Renderer, elements []Element, layout string) (animation, error) {
+	order := make([]Element, 0, len(elements))
+	for _, e := range elements {
+		if _, ok := render.DiscoveryYears[e.Symbol]; ok {
+			order = append(order, e)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return render.DiscoveryYears[order[i].Symbol] < render.DiscoveryYears[order[j].Symbol]
+	})
+	if len(order) == 0 {
+		return animation{}, fmt.Errorf("no discovery years for these elements")
+	}
+
+	t := newTableLayout(r, elements, layout)
+	canvas := image.NewRGBA(image.Rect(0, 0, t.W, t.H))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	tiles := map[int]*image.RGBA{}
+	for _, e := range elements {
+		tile := r.Tile(e)
+		tiles[e.Number] = tile
+		draw.Draw(canvas, tile.Bounds().Add(t.pos(e)), ghost(tile), image.Point{}, draw.Over)
+	}
+
+	yearBox := captionBox(t)
+	yearFont, err := captionFont(r, yearBox)
+	if err != nil {
+		return animation{}, fmt.Errorf("loading font: %w", err)
+	}
+
+	// The finished table with the longest year label has every colour.
+	final := image.NewRGBA(canvas.Bounds())
+	copy(final.Pix, canvas.Pix)
+	for _, e := range order {
+		draw.Draw(final, tiles[e.Number].Bounds().Add(t.pos(e)), tiles[e.Number], image.Point{}, draw.Over)
+	}
+	drawCaption(final, yearFont, yearBox, render.DiscoveryLabel(0))
+
+	i := 0
+	next := func() (image.Rectangle, bool) {
+		if i == len(order) {
+			return image.Rectangle{}, false
+		}
+		e := order[i]
+		tile := tiles[e.Number]
+		at := tile.Bounds().Add(t.pos(e))
+		draw.Draw(canvas, at, tile, image.Point{}, draw.Over)
+		drawCaption(canvas, yearFont, yearBox, render.DiscoveryLabel(render.DiscoveryYears[e.Symbol]))
+		i++
+		if i == 1 {
+			return canvas.Bounds(), true
+		}
+		return at.Union(yearBox), true
+	}
+	return animation{canvas: canvas, colours: final, next: next}, nil
+}
+
+// ghost returns a faded copy of a card.
+func ghost(tile *image.RGBA) *image.RGBA {
+	g := image.NewRGBA(tile.Bounds())
+	for i := 0; i < len(tile.Pix); i += 4 {
+		// Premultiplied, so fading to a fifth opacity fades every channel.
+		for c := range 4 {
+			g.Pix[i+c] = tile.Pix[i+c] / 5
+		}
+	}
+	return g
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// unitsPerInch converts the physical units a -height can be given in.
+var unitsPerInch = map[string]float64{
+	"in": 1,
+	"cm": 2.54,
+	"mm": 25.4,
+	"pt": 72,
+}
+
+// length is a size given on the command line in px, or in a physical
+// unit that -dpi turns into px, such as "600", "2in" or "50mm".
+type length struct {
+	value float64
+	unit  string // "px" or a key of unitsPerInch
+}
+
+func (l *length) String() string {
+	if l.value == 0 {
+		return ""
+	}
+	if l.unit == "px" {
+		return strconv.FormatFloat(l.value, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(l.value, 'f', -1, 64) + l.unit
+}
+
+func (l *length) Set(s string) error {
+	num, unit := strings.TrimSpace(s), "px"
+	for u := range unitsPerInch {
+		if n, ok := strings.CutSuffix(num, u); ok {
+			num, unit = n, u
+		}
+	}
+	num = strings.TrimSuffix(num, "px")
+	v, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || v <= 0 {
+		return fmt.Errorf("want a size in px, or in in, cm, mm or pt with -dpi")
+	}
+	l.value, l.unit = v, unit
+	return nil
+}
+
+// pixels returns the length in px at dpi dots per inch.
+func (l length) pixels(dpi float64) (int, error) {
+	if l.unit == "px" {
+		return int(math.Round(l.value)), nil
+	}
+	if dpi <= 0 {
+		return 0, fmt.Errorf("%s needs -dpi to turn it into pixels", l.String())
+	}
+	return int(math.Round(l.value / unitsPerInch[l.unit] * dpi)), nil
+}
+
+// withDPI adds a pHYs chunk recording dpi to an encoded PNG, so that
+// printers and layout programs show it at the size it was made for.
+func withDPI(png []byte, dpi float64) []byte {
+	ppm := uint32(math.Round(dpi / 0.0254))
+	data := make([]byte, 9)
+	binary.BigEndian.PutUint32(data, ppm)
+	binary.BigEndian.PutUint32(data[4:], ppm)
+	data[8] = 1 // per metre
+	return withChunk(png, "pHYs", data)
+}
+
+// withChunk adds a chunk to an encoded PNG right after its header, where
+// the chunks describing the image as a whole must come.
+func withChunk(png []byte, typ string, data []byte) []byte {
+	const ihdrEnd = 8 + 4 + 4 + 13 + 4 // signature, then IHDR's length, type, data and CRC
+	chunk := make([]byte, 4+4+len(data)+4)
+	binary.BigEndian.PutUint32(chunk, uint32(len(data)))
+	copy(chunk[4:], typ)
+	copy(chunk[8:], data)
+	binary.BigEndian.PutUint32(chunk[8+len(data):], crc32.ChecksumIEEE(chunk[4:8+len(data)]))
+
+	var b bytes.Buffer
+	b.Grow(len(png) + len(chunk))
+	b.Write(png[:ihdrEnd])
+	b.Write(chunk)
+	b.Write(png[ihdrEnd:])
+	return b.Bytes()
+}
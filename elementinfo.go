@@ -0,0 +1,102 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"periodic-table-tiles/render"
+)
+
+// elementsInfoName is the file written beside the cards describing them.
+const elementsInfoName = "elements.json"
+
+// stpKelvin is the temperature of standard temperature and pressure.
+const stpKelvin = 273.15
+
+// elementInfo is what elements.json says about an element: its data along
+// with what follows from it, so whoever uses the cards needn't work those
+// out. Anything unknown is left out.
+type elementInfo struct {
+	Number            int     `json:"number"`
+	Symbol            string  `json:"symbol"`
+	Name              string  `json:"name"`
+	Category          string  `json:"category"`
+	AtomicMass        float64 `json:"atomic_mass,omitempty"`
+	Block             string  `json:"block,omitempty"`  // s, p, d or f
+	Period            int     `json:"period,omitempty"` // row of the table, 1 to 7
+	Group             int     `json:"group,omitempty"`  // column 1 to 18; none for the f-block
+	ValenceElectrons  int     `json:"valence_electrons"`
+	Phase             string  `json:"phase,omitempty"` // solid, liquid or gas at STP
+	Configuration     string  `json:"electron_configuration,omitempty"`
+	Shells            []int   `json:"shells,omitempty"` // electrons in each shell from the inside
+	Electronegativity float64 `json:"electronegativity,omitempty"`
+	MagneticOrdering  string  `json:"magnetic_ordering,omitempty"`  // at room temperature
+	SuperconductingTc float64 `json:"superconducting_tc,omitempty"` // in kelvin
+	Occurrence        string  `json:"occurrence"`                   // primordial, from decay or synthetic
+	StableIsotopes    *int    `json:"stable_isotopes,omitempty"`    // nil if the isotope data doesn't have the element
+	Image             string  `json:"image,omitempty"`              // the card, relative to the output directory
+}
+
+func describeElement(e Element, image string) elementInfo {
+	info := elementInfo{
+		Number: e.Number, Symbol: e.Symbol, Name: e.Name, Category: e.Type, AtomicMass: e.Mass,
+		ValenceElectrons:  e.Valence,
+		Configuration:     cmp.Or(e.Configuration, render.AufbauConfiguration(e.Number)),
+		Shells:            render.ShellOccupancy(e),
+		Electronegativity: e.Electronegativity,
+		MagneticOrdering:  render.MagnetismBySymbol[e.Symbol].Ordering,
+		SuperconductingTc: render.MagnetismBySymbol[e.Symbol].SuperconductingK,
+		Occurrence:        render.Occurrence(e.Number),
+		Image:             image,
+	}
+	if n := render.StableIsotopes(e.Symbol); n >= 0 {
+		info.StableIsotopes = &n
+	}
+	switch {
+	case e.YPos == 9 || e.YPos == 10:
+		info.Block, info.Period = "f", e.YPos-3
+	case e.YPos >= 1 && e.YPos <= 7 && e.XPos >= 1 && e.XPos <= 18:
+		info.Period, info.Group = e.YPos, e.XPos
+		switch {
+		case e.XPos <= 2 || e.Number == 2:
+			info.Block = "s"
+		case e.XPos <= 12:
+			info.Block = "d"
+		default:
+			info.Block = "p"
+		}
+	}
+	if p := phaseAt(e, stpKelvin); p != render.PhaseUnknown {
+		info.Phase = p
+	} else {
+		info.Phase = strings.ToLower(e.Phase)
+	}
+	return info
+}
+
+// saveElementsInfo writes elements.json beside the cards and records it in
+// the manifest.
+func saveElementsInfo(st store, m *manifest, elements []Element) error {
+	infos := make([]elementInfo, len(elements))
+	for i, e := range elements {
+		infos[i] = describeElement(e, tileFilename(e))
+	}
+	err := st.put(elementsInfoName, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := m.record(st, manifestEntry{Path: elementsInfoName}); err != nil {
+		return err
+	}
+	if err := m.save(st); err != nil {
+		return err
+	}
+	logger.Info("Written", "path", elementsInfoName)
+	return nil
+}
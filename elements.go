@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"periodic-table-tiles/elements"
+)
+
+// Element is an element as the cards draw it.
+type Element = elements.Element
+
+// provenance records where data came from, for the manifest.
+type provenance = elements.Provenance
+
+// categories, normaliseCategory and indexElement are short for those of
+// package elements, whose name the []Element variables everywhere here
+// hide.
+var categories = elements.Categories
+
+func normaliseCategory(c string) string { return elements.NormaliseCategory(c) }
+
+func indexElement(es []Element, name string) int { return elements.Index(es, name) }
+
+// elementCache keeps the element data once it has been fetched, when
+// enabled, so modes running several jobs only read or download it once.
+type elementCache struct {
+	mu      sync.Mutex
+	enabled bool
+	data    map[[2]string]cachedElements // by -data and -data-columns
+}
+
+type cachedElements struct {
+	elements []Element
+	prov     provenance
+}
+
+var elementsCache elementCache
+
+func (c *elementCache) enable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.enabled = true
+}
+
+// fetch returns a copy of the element data and where it came from,
+// fetching it if it isn't cached.
+func (c *elementCache) fetch(ctx context.Context, path, columnsPath string) ([]Element, provenance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := [2]string{path, columnsPath}
+	if ce, ok := c.data[key]; ok {
+		return slices.Clone(ce.elements), ce.prov, nil
+	}
+	es, prov, err := fetchElements(ctx, path, columnsPath)
+	if err != nil || !c.enabled {
+		return es, prov, err
+	}
+	if c.data == nil {
+		c.data = map[[2]string]cachedElements{}
+	}
+	c.data[key] = cachedElements{es, prov}
+	return slices.Clone(es), prov, nil
+}
+
+// fetchElements reads the element data from the provider for a -data
+// value.
+func fetchElements(ctx context.Context, path, columnsPath string) ([]Element, provenance, error) {
+	es, prov, err := elements.Open(path, columnsPath).Elements(ctx)
+	prov.Elements = len(es)
+	return es, prov, err
+}
+
+// applyAtomicWeights takes the elements' standard atomic weights and
+// masses from an -atomic-weights table.
+func applyAtomicWeights(ctx context.Context, es []Element, src string) (provenance, error) {
+	weights, prov, err := elements.LoadAtomicWeights(ctx, src)
+	if err != nil {
+		return prov, err
+	}
+	prov.Elements = elements.ApplyAtomicWeights(es, weights)
+	return prov, nil
+}
@@ -0,0 +1,222 @@
+package elements
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// StandardWeight is a row of a CIAAW table of standard atomic weights.
+type StandardWeight struct {
+	Number   int // 0 if the table doesn't give it
+	Symbol   string
+	Weight   AtomicWeight
+	Abridged float64 // the abridged or conventional value, 0 if not given
+}
+
+// LoadAtomicWeights reads a table of atomic weights, from a file or URL: the
+// table of standard atomic weights published by IUPAC's Commission on
+// Isotopic Abundances and Atomic Weights, either the page at
+// https://www.ciaaw.org/atomic-weights.htm as it is or the table saved as
+// CSV or tab-separated text. A header row naming the Symbol and Standard
+// Atomic Weight columns picks them out, as does one for an Abridged or
+// Conventional value; without one the columns are taken to be Z, symbol,
+// name, weight and then any abridged value. Weights may be written
+// 55.845(2), 55.845 ± 0.002, [1.007 84, 1.008 11] or [209], and elements
+// the table gives no weight for are left alone.
+func LoadAtomicWeights(ctx context.Context, src string) ([]StandardWeight, Provenance, error) {
+	var bs []byte
+	var err error
+	if strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://") {
+		bs, err = httpGet(ctx, src, "")
+	} else {
+		bs, err = os.ReadFile(src)
+	}
+	if err != nil {
+		return nil, Provenance{}, err
+	}
+	sum := sha256.Sum256(bs)
+	prov := Provenance{Source: src, SHA256: hex.EncodeToString(sum[:])}
+
+	var rows [][]string
+	switch {
+	case bytes.Contains(bytes.ToLower(bs), []byte("<tr")):
+		rows = htmlTableRows(string(bs))
+	case strings.HasSuffix(strings.ToLower(src), ".csv"):
+		cr := csv.NewReader(bytes.NewReader(bs))
+		cr.FieldsPerRecord = -1
+		if rows, err = cr.ReadAll(); err != nil {
+			return nil, prov, fmt.Errorf("%s: %w", src, err)
+		}
+	default:
+		for _, line := range strings.Split(string(bs), "\n") {
+			rows = append(rows, strings.Split(strings.TrimRight(line, "\r"), "\t"))
+		}
+	}
+
+	cols := struct{ number, symbol, weight, abridged int }{0, 1, 3, 4}
+	var weights []StandardWeight
+	for i, row := range rows {
+		for j := range row {
+			row[j] = cleanCIAAWCell(row[j])
+		}
+		if symbol := indexFold(row, "symbol"); symbol >= 0 {
+			cols.symbol, cols.number, cols.weight, cols.abridged = symbol, indexFold(row, "z", "atomic number"), -1, -1
+			for j, h := range row {
+				h = strings.ToLower(h)
+				switch {
+				case strings.Contains(h, "abridged") || strings.Contains(h, "conventional"):
+					cols.abridged = j
+				case strings.Contains(h, "weight") && cols.weight < 0:
+					cols.weight = j
+				}
+			}
+			if cols.weight < 0 {
+				return nil, prov, fmt.Errorf("%s: line %d: no atomic weight column", src, i+1)
+			}
+			continue
+		}
+		if cols.weight >= len(row) || cols.symbol >= len(row) {
+			continue
+		}
+		symbol := row[cols.symbol]
+		if symbol == "" || !unicode.IsUpper([]rune(symbol)[0]) || len(symbol) > 3 {
+			continue // a title, a footnote or a blank line
+		}
+		w, ok, err := parseCIAAWWeight(row[cols.weight])
+		if err != nil {
+			return nil, prov, fmt.Errorf("%s: %s: %w", src, symbol, err)
+		}
+		if !ok {
+			continue
+		}
+		cw := StandardWeight{Symbol: symbol, Weight: w}
+		if cols.number >= 0 && cols.number < len(row) {
+			cw.Number, _ = strconv.Atoi(row[cols.number])
+		}
+		if cols.abridged >= 0 && cols.abridged < len(row) {
+			if a, ok, _ := parseCIAAWWeight(row[cols.abridged]); ok && !a.Interval {
+				cw.Abridged = a.Value
+			}
+		}
+		weights = append(weights, cw)
+	}
+	if len(weights) == 0 {
+		return nil, prov, fmt.Errorf("%s: no atomic weights found", src)
+	}
+	return weights, prov, nil
+}
+
+// ApplyAtomicWeights sets the standard atomic weight and mass of the
+// elements the table has, matched by symbol or else atomic number. The
+// mass is the abridged value if the table gives one, or else the weight,
+// or the middle of its interval. It returns how many it set.
+func ApplyAtomicWeights(elements []Element, weights []StandardWeight) int {
+	n := 0
+	for _, w := range weights {
+		i := Index(elements, w.Symbol)
+		if i < 0 && w.Number > 0 {
+			i = Index(elements, strconv.Itoa(w.Number))
+		}
+		if i < 0 {
+			continue
+		}
+		e := &elements[i]
+		e.Weight = w.Weight
+		e.Mass = w.Weight.Value
+		if w.Abridged > 0 {
+			e.Mass = w.Abridged
+		}
+		n++
+	}
+	return n
+}
+
+var (
+	htmlRow  = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	htmlCell = regexp.MustCompile(`(?is)<t[dh][^>]*>(.*?)</t[dh]>`)
+	htmlTag  = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// htmlTableRows returns the text of the cells of every table row on a
+// page.
+func htmlTableRows(page string) [][]string {
+	var rows [][]string
+	for _, tr := range htmlRow.FindAllStringSubmatch(page, -1) {
+		var row []string
+		for _, td := range htmlCell.FindAllStringSubmatch(tr[1], -1) {
+			row = append(row, html.UnescapeString(htmlTag.ReplaceAllString(td[1], " ")))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// cleanCIAAWCell collapses the thin and non-breaking spaces the tables
+// group digits with, and other runs of space, to single spaces.
+func cleanCIAAWCell(s string) string { return strings.Join(strings.Fields(s), " ") }
+
+// indexFold returns the index of the first cell that is name, ignoring
+// case, or -1.
+func indexFold(row []string, names ...string) int {
+	return slices.IndexFunc(row, func(s string) bool {
+		return slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(s, name) })
+	})
+}
+
+// parseCIAAWWeight reads a weight in any of the notations of the CIAAW
+// tables, reporting false for a cell without one, such as "–" for an
+// element with no stable isotope.
+func parseCIAAWWeight(s string) (AtomicWeight, bool, error) {
+	// Footnote letters, such as "g m r", follow the value.
+	s = strings.TrimRightFunc(s, func(r rune) bool { return unicode.IsLower(r) || r == ' ' || r == '*' })
+	switch s {
+	case "", "-", "–", "—":
+		return AtomicWeight{}, false, nil
+	}
+	cell := s
+	// Digits are grouped in threes with spaces, which only mean something
+	// after the comma of an interval.
+	s = strings.ReplaceAll(strings.ReplaceAll(s, ", ", ","), " ", "")
+	s = strings.ReplaceAll(s, ",", ", ")
+	if v, u, ok := strings.Cut(s, "±"); ok {
+		concise, err := conciseUncertainty(v, u)
+		if err != nil {
+			return AtomicWeight{}, false, fmt.Errorf("atomic weight %q: %w", cell, err)
+		}
+		s = concise
+	}
+	w, err := ParseAtomicWeight(s)
+	return w, err == nil, err
+}
+
+// conciseUncertainty writes a value and its uncertainty, "1.0080" and
+// "0.0002", in the concise notation the data uses, "1.0080(2)".
+func conciseUncertainty(v, u string) (string, error) {
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return "", fmt.Errorf("bad value")
+	}
+	if _, err := strconv.ParseFloat(u, 64); err != nil {
+		return "", fmt.Errorf("bad uncertainty")
+	}
+	_, vfrac, _ := strings.Cut(v, ".")
+	whole, ufrac, _ := strings.Cut(u, ".")
+	if len(ufrac) > len(vfrac) {
+		return "", fmt.Errorf("the uncertainty has more decimal places than the value")
+	}
+	digits := strings.TrimLeft(whole+ufrac+strings.Repeat("0", len(vfrac)-len(ufrac)), "0")
+	if digits == "" {
+		return v, nil
+	}
+	return v + "(" + digits + ")", nil
+}
@@ -0,0 +1,131 @@
+package elements
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The files in testdata copy rows of the CIAAW table of standard atomic
+// weights in each form LoadAtomicWeights reads: the web page, with its thin
+// and non-breaking spaces and footnotes column, a CSV export with footnote
+// letters after the weights, and tab-separated text without a header.
+func TestLoadAtomicWeights(t *testing.T) {
+	type row struct {
+		number   int
+		symbol   string
+		weight   string // as AtomicWeight.String writes it
+		abridged float64
+	}
+	for _, tt := range []struct {
+		file string
+		want []row
+	}{
+		{"ciaaw.htm", []row{
+			{1, "H", "[1.00784, 1.00811]", 1.008},
+			{2, "He", "4.002602(2)", 4.0026},
+			{3, "Li", "[6.938, 6.997]", 6.94},
+			{26, "Fe", "55.845(2)", 55.845},
+			{82, "Pb", "[206.14, 207.94]", 207.2},
+			{83, "Bi", "208.98040(1)", 208.98},
+		}},
+		{"ciaaw.csv", []row{
+			{1, "H", "[1.00784, 1.00811]", 1.008},
+			{2, "He", "4.002602(2)", 4.0026},
+			{3, "Li", "[6.938, 6.997]", 6.94},
+			{26, "Fe", "55.845(2)", 55.845},
+			{82, "Pb", "[206.14, 207.94]", 207.2},
+			{84, "Po", "[209]", 0},
+		}},
+		{"ciaaw.txt", []row{
+			{1, "H", "[1.00784, 1.00811]", 1.008},
+			{2, "He", "4.002602(2)", 4.0026},
+			{26, "Fe", "55.845(2)", 55.845},
+		}},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.file)
+			weights, prov, err := LoadAtomicWeights(context.Background(), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if prov.Source != path || len(prov.SHA256) != 64 {
+				t.Errorf("provenance = %+v", prov)
+			}
+			if len(weights) != len(tt.want) {
+				t.Fatalf("got %d weights, want %d: %+v", len(weights), len(tt.want), weights)
+			}
+			for i, want := range tt.want {
+				w := weights[i]
+				got := row{w.Number, w.Symbol, w.Weight.String(), w.Abridged}
+				if got != want {
+					t.Errorf("got %+v, want %+v", got, want)
+				}
+			}
+			// An interval's value is its middle.
+			if h := weights[0].Weight; !h.Interval || math.Abs(h.Value-1.007975) > 1e-9 {
+				t.Errorf("hydrogen = %+v", h)
+			}
+		})
+	}
+}
+
+func TestLoadAtomicWeightsErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		{"empty.txt", "", "no atomic weights found"},
+		// Only a .csv file is read as CSV; anything else without table
+		// rows is tab-separated.
+		{"commas.txt", "1,H,hydrogen,1.008\n", "no atomic weights found"},
+		{"no weight.csv", "Z,Symbol,Element\n1,H,hydrogen\n", "line 1: no atomic weight column"},
+		{"bad weight.csv", "Z,Symbol,Element,Atomic weight\n26,Fe,iron,55.8.45\n", "Fe: atomic weight"},
+		{"bad uncertainty.txt", "26\tFe\tiron\t55.845 ± 0.0002\n", "Fe: atomic weight \"55.845 ± 0.0002\": the uncertainty has more decimal places than the value"},
+		{"bad interval.htm", "<tr><td>1</td><td>H</td><td>hydrogen</td><td>[1.008 11, 1.007 84]</td></tr>", "H: atomic weight \"[1.00811, 1.00784]\": bad interval"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.name)
+			if err := os.WriteFile(path, []byte(tt.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, _, err := LoadAtomicWeights(context.Background(), path)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCIAAWWeight(t *testing.T) {
+	for _, tt := range []struct {
+		cell, want string
+		ok         bool
+	}{
+		{"55.845(2)", "55.845(2)", true},
+		{"55.845 ± 0.002", "55.845(2)", true},
+		{"4.002 602 ± 0.000 002", "4.002602(2)", true},
+		{"207.2 ± 1.1", "207.2(11)", true},
+		{"12.011 ± 0", "12.011", true},
+		{"[1.007 84, 1.008 11]", "[1.00784, 1.00811]", true},
+		{"[10.806,10.821]", "[10.806, 10.821]", true},
+		{"[209]", "[209]", true},
+		// Footnote markers after the value.
+		{"[6.938, 6.997] m", "[6.938, 6.997]", true},
+		{"4.002 602(2) g r", "4.002602(2)", true},
+		{"[209]*", "[209]", true},
+		// No weight.
+		{"", "", false},
+		{"–", "", false},
+		{"—", "", false},
+		{"-", "", false},
+		{"g r", "", false},
+	} {
+		w, ok, err := parseCIAAWWeight(tt.cell)
+		if err != nil || ok != tt.ok || w.String() != tt.want {
+			t.Errorf("parseCIAAWWeight(%q) = %q, %v, %v, want %q, %v", tt.cell, w, ok, err, tt.want, tt.ok)
+		}
+	}
+}
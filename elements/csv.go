@@ -0,0 +1,126 @@
+package elements
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// sourceFields maps the field names of the upstream JSON to their kinds,
+// so CSV cells can be turned back into the right JSON values.
+var sourceFields = func() map[string]reflect.Kind {
+	m := map[string]reflect.Kind{}
+	t := reflect.TypeOf(SourceRoot{}.Elements).Elem()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		m[name] = t.Field(i).Type.Kind()
+	}
+	return m
+}()
+
+// loadColumns reads -data-columns: a JSON object from upstream field names
+// to the CSV headers holding them, such as {"number": "Z"}. It returns the
+// field for each header it names.
+func loadColumns(path string) (map[string]string, error) {
+	columns := map[string]string{}
+	if path == "" {
+		return columns, nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return nil, err
+	}
+	for field, header := range raw {
+		if _, ok := sourceFields[field]; !ok {
+			return nil, fmt.Errorf("%s: unknown field %q", path, field)
+		}
+		columns[header] = field
+	}
+	return columns, nil
+}
+
+// csvElements turns a spreadsheet of elements, one per row under a row of
+// headers, into the upstream JSON. Columns are read as the field named by
+// columns, or else the field of the same name; others are ignored, as are
+// empty cells.
+func csvElements(rd io.Reader, columns map[string]string) ([]byte, error) {
+	cr := csv.NewReader(rd)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading the headers: %w", err)
+	}
+	fields := make([]string, len(header))
+	found := map[string]bool{}
+	for i, h := range header {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\uFEFF")) // spreadsheets often start with a BOM
+		f, ok := columns[h]
+		if !ok {
+			if _, known := sourceFields[h]; known {
+				f = h
+			}
+		}
+		fields[i] = f
+		found[f] = true
+	}
+	for _, f := range []string{"number", "symbol", "name"} {
+		if !found[f] {
+			return nil, fmt.Errorf("no %q column; map one to it with -data-columns", f)
+		}
+	}
+
+	var root struct {
+		Elements []map[string]any `json:"elements"`
+	}
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		e := map[string]any{}
+		for i, cell := range row {
+			cell = strings.TrimSpace(cell)
+			if i >= len(fields) || fields[i] == "" || cell == "" {
+				continue
+			}
+			switch sourceFields[fields[i]] {
+			case reflect.Int, reflect.Float64:
+				v, err := strconv.ParseFloat(cell, 64)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s %q isn't a number", line, header[i], cell)
+				}
+				e[fields[i]] = v
+			case reflect.Slice:
+				// Lists, such as shells, are written "2,8,14,2".
+				var vs []float64
+				for _, part := range strings.Split(cell, ",") {
+					v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+					if err != nil {
+						return nil, fmt.Errorf("line %d: %s %q isn't a list of numbers", line, header[i], cell)
+					}
+					vs = append(vs, v)
+				}
+				e[fields[i]] = vs
+			default:
+				e[fields[i]] = cell
+			}
+		}
+		if len(e) > 0 {
+			root.Elements = append(root.Elements, e)
+		}
+	}
+	return json.Marshal(root)
+}
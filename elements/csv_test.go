@@ -0,0 +1,78 @@
+package elements
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFile writes a file into a temporary directory and returns its path.
+func writeFile(t *testing.T, name, src string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFileCSV(t *testing.T) {
+	// As a spreadsheet saves it: a byte order mark, headers of its own
+	// mapped by -data-columns, a column the tool doesn't use, padding and
+	// empty cells.
+	data := writeFile(t, "elements.csv", "\uFEFFZ,symbol, Element ,atomic_mass,atomic_weight,category,xpos,ypos,shells,notes\r\n"+
+		"26,Fe,Iron,55.845,55.845(2),Transition Metals,8,4,\"2, 8, 14, 2\",magnetic\r\n"+
+		"1,H,Hydrogen,1.008,,diatomic nonmetal,1,1,1,\r\n"+
+		",,,,,,,,,\r\n")
+	columns := writeFile(t, "columns.json", `{"number": "Z", "name": "Element"}`)
+	got, prov, err := File{data, columns}.Elements(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prov.Source != data {
+		t.Errorf("source = %q", prov.Source)
+	}
+	want := []Element{
+		{
+			Number: 1, Symbol: "H", Name: "Hydrogen", Mass: 1.008, Type: "reactive nonmetal", XPos: 1, YPos: 1,
+			Valence: ValenceElectrons(1, 1, 1), Shells: []int{1},
+		},
+		{
+			Number: 26, Symbol: "Fe", Name: "Iron", Mass: 55.845, Type: "transition metal", XPos: 8, YPos: 4,
+			Valence: ValenceElectrons(26, 8, 4), Shells: []int{2, 8, 14, 2},
+			Weight: AtomicWeight{Value: 55.845, Text: "55.845", Uncertainty: "2"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFileCSVErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, columns, want string
+	}{
+		{"empty", "", "", "reading the headers: EOF"},
+		{"no number", "symbol,name\nFe,Iron\n", "", `no "number" column; map one to it with -data-columns`},
+		{"unmapped header", "Z,symbol,name\n26,Fe,Iron\n", "", `no "number" column`},
+		{"number", "number,symbol,name,atomic_mass\n26,Fe,Iron,55.845\n27,Co,Cobalt,heavy\n", "", `line 3: atomic_mass "heavy" isn't a number`},
+		{"list", "number,symbol,name,shells\n26,Fe,Iron,\"2,8,x\"\n", "", `line 2: shells "2,8,x" isn't a list of numbers`},
+		{"quotes", "number,symbol,name\n26,\"Fe,Iron\n", "", "extraneous or missing \" in quoted-field"},
+		{"unknown field", "number,symbol,name\n", `{"mass": "Mass"}`, `columns.json: unknown field "mass"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeFile(t, "elements.csv", tt.src)
+			var columns string
+			if tt.columns != "" {
+				columns = writeFile(t, "columns.json", tt.columns)
+			}
+			_, _, err := File{data, columns}.Elements(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+// Package elements reads the data the cards are drawn from: the elements
+// with their places in the table, masses, standard atomic weights and
+// properties, from the upstream JSON dataset, a CSV file, an SQLite
+// database or the copy built in, and the standard atomic weights
+// published by IUPAC's CIAAW.
+//
+//	es, prov, err := elements.Open("embedded", "").Elements(ctx)
+//	fe := es[elements.Index(es, "Fe")]
+package elements
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SourceRoot is the upstream JSON dataset.
+type SourceRoot struct {
+	Elements []struct {
+		Number     int     `json:"number"`
+		Symbol     string  `json:"symbol"`
+		Name       string  `json:"name"`
+		AtomicMass float64 `json:"atomic_mass"`
+		// AtomicWeight is only in extended datasets, such as "55.845(2)".
+		AtomicWeight string  `json:"atomic_weight"`
+		Category     string  `json:"category"`
+		Xpos         int     `json:"xpos"`
+		Ypos         int     `json:"ypos"`
+		Melt         float64 `json:"melt"`
+		Boil         float64 `json:"boil"`
+
+		Summary           string  `json:"summary"`
+		Phase             string  `json:"phase"`
+		Density           float64 `json:"density"`
+		Configuration     string  `json:"electron_configuration_semantic"`
+		Electronegativity float64 `json:"electronegativity_pauling"`
+		Shells            []int   `json:"shells"`
+	} `json:"elements"`
+}
+
+// Element is one element of the table.
+type Element struct {
+	Number int
+	Symbol string
+	Name   string
+	Mass   float64
+	Type   string  // category, as NormaliseCategory gives it
+	XPos   int     // column and row in the 18-column table, from 1; the
+	YPos   int     // lanthanides and actinides are on rows 9 and 10
+	Melt   float64 // melting and boiling points in kelvin, 0 if unknown
+	Boil   float64
+
+	Valence int          // valence electrons, see ValenceElectrons
+	Weight  AtomicWeight // standard atomic weight, if the data has one
+
+	// Printed on the card backs; empty or 0 if the data doesn't have them.
+	Summary           string
+	Phase             string  // at room temperature
+	Density           float64 // in g/cm³, or g/L for gases
+	Configuration     string  // electron configuration, such as "[Ar] 3d6 4s2"
+	Electronegativity float64 // Pauling scale
+	Shells            []int   // electrons in each shell from the inside, such as 2, 8, 14, 2 for iron
+}
+
+// Categories are the names NormaliseCategory returns for the categories in
+// the source data, in the usual order, ending with "unknown".
+var Categories = []string{
+	"alkali metal", "alkaline earth metal", "transition metal", "post-transition metal", "metalloid",
+	"reactive nonmetal", "halogen", "noble gas", "lanthanide", "actinide", "unknown",
+}
+
+// NormaliseCategory maps the spellings and older names of a category, from
+// the source data or a colours file, to the one in Categories. Others are
+// returned in lower case with their spaces tidied.
+func NormaliseCategory(c string) string {
+	c = strings.ToLower(c)
+	c = strings.ReplaceAll(c, "-", " ")
+	c = strings.ReplaceAll(c, "_", " ")
+	c = strings.Join(strings.Fields(c), " ")
+	switch c {
+	case "diatomic nonmetal", "polyatomic nonmetal", "reactive nonmetal", "other nonmetal", "nonmetal":
+		return "reactive nonmetal"
+	case "halogen", "halogens":
+		return "halogen"
+	case "noble gas", "noble gases":
+		return "noble gas"
+	case "alkali metal", "alkali metals":
+		return "alkali metal"
+	case "alkaline earth metal", "alkaline earth metals", "alkaline earth":
+		return "alkaline earth metal"
+	case "transition metal", "transition metals":
+		return "transition metal"
+	case "post transition metal", "post transition metals", "poor metal", "poor metals":
+		return "post-transition metal"
+	case "lanthanide", "lanthanoid", "lanthanoids", "lanthanides":
+		return "lanthanide"
+	case "actinide", "actinoid", "actinoids", "actinides":
+		return "actinide"
+	default:
+		return c
+	}
+}
+
+// ValenceElectrons counts an element's valence electrons from its place in
+// the table: the group number for the s- and d-blocks, the group number less
+// ten for the p-block, except helium's two, and three for the lanthanides
+// and actinides. Elements with no place have none.
+func ValenceElectrons(number, xpos, ypos int) int {
+	switch {
+	case number == 2:
+		return 2
+	case ypos == 9 || ypos == 10:
+		return 3
+	case xpos >= 1 && xpos <= 12:
+		return xpos
+	case xpos >= 13 && xpos <= 18:
+		return xpos - 10
+	}
+	return 0
+}
+
+// Index finds an element by symbol or atomic number, or returns -1.
+func Index(elements []Element, name string) int {
+	n, _ := strconv.Atoi(name)
+	return slices.IndexFunc(elements, func(e Element) bool {
+		return e.Number == n || strings.EqualFold(e.Symbol, name)
+	})
+}
+
+// Parse reads the upstream JSON dataset, in order of atomic number.
+func Parse(body []byte) ([]Element, error) {
+	var root SourceRoot
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, err
+	}
+	var es []Element
+	for _, e := range root.Elements {
+		w, err := ParseAtomicWeight(e.AtomicWeight)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", e.Symbol, err)
+		}
+		es = append(es, Element{
+			Number: e.Number,
+			Symbol: e.Symbol,
+			Name:   norm.NFC.String(e.Name),
+			Mass:   e.AtomicMass,
+			Type:   NormaliseCategory(e.Category),
+			XPos:   e.Xpos,
+			YPos:   e.Ypos,
+			Melt:   e.Melt,
+			Boil:   e.Boil,
+
+			Valence: ValenceElectrons(e.Number, e.Xpos, e.Ypos),
+			Weight:  w,
+
+			Summary:           norm.NFC.String(e.Summary),
+			Phase:             e.Phase,
+			Density:           e.Density,
+			Configuration:     e.Configuration,
+			Electronegativity: e.Electronegativity,
+			Shells:            e.Shells,
+		})
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].Number < es[j].Number })
+	if len(es) > 118 {
+		es = es[:118]
+	}
+	return es, nil
+}
@@ -0,0 +1,252 @@
+package elements
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Provider is where the element data comes from. Open picks one from a
+// -data value:
+//
+//	(nothing)                 the upstream dataset, downloaded
+//	embedded                  the copy built into the binary
+//	https://host/elements     any URL serving the upstream JSON format
+//	elements.db, sqlite:path  an SQLite database, such as export writes
+//	elements.json, .csv       a local file
+//
+// so a deployment can point every run at one central copy of the data.
+type Provider interface {
+	// Elements reads the elements, in order of atomic number, and
+	// reports where they came from.
+	Elements(ctx context.Context) ([]Element, Provenance, error)
+}
+
+// Provenance records where data came from, so a set of cards can be traced
+// back to the exact table.
+type Provenance struct {
+	Source   string `json:"source"` // path or URL
+	SHA256   string `json:"sha256"`
+	Version  string `json:"version,omitempty"` // as the data gives it, if it does
+	Elements int    `json:"elements"`          // how many elements it gave values for
+}
+
+// UpstreamURL is the dataset used when -data isn't given.
+const UpstreamURL = "https://raw.githubusercontent.com/Bowserinator/Periodic-Table-JSON/master/PeriodicTableJSON.json"
+
+// Open resolves a -data value, and -data-columns for a CSV file: a JSON
+// object from upstream field names to the CSV headers holding them, such
+// as {"number": "Z"}, or "" for none.
+func Open(path, columnsPath string) Provider {
+	lower := strings.ToLower(path)
+	switch ext := filepath.Ext(lower); {
+	case path == "":
+		return URL(UpstreamURL)
+	case lower == "embedded":
+		return Embedded{}
+	case strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://"):
+		return URL(path)
+	case strings.HasPrefix(lower, "sqlite:"):
+		return SQLite(strings.TrimPrefix(path[len("sqlite:"):], "//"))
+	case ext == ".db" || ext == ".sqlite" || ext == ".sqlite3":
+		return SQLite(path)
+	}
+	return File{path, columnsPath}
+}
+
+//go:embed elements.json
+var elementsJSON []byte
+
+// Embedded is a snapshot of the upstream dataset built into the
+// binary, for running offline. It has each element's number, symbol,
+// name, mass, category, position and phase, but none of the other fields.
+type Embedded struct{}
+
+func (Embedded) Elements(context.Context) ([]Element, Provenance, error) {
+	es, err := Parse(elementsJSON)
+	return es, dataProvenance("embedded", elementsJSON), err
+}
+
+// File is a JSON file in the upstream format, or a CSV file with one
+// element per row under a row of headers, named after the upstream fields
+// or mapped to them by the ColumnsPath file.
+type File struct{ Path, ColumnsPath string }
+
+func (f File) Elements(context.Context) ([]Element, Provenance, error) {
+	bs, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, Provenance{}, err
+	}
+	prov := dataProvenance(f.Path, bs)
+	if !strings.EqualFold(filepath.Ext(f.Path), ".csv") {
+		es, err := Parse(bs)
+		return es, prov, err
+	}
+	columns, err := loadColumns(f.ColumnsPath)
+	if err != nil {
+		return nil, prov, fmt.Errorf("reading columns: %w", err)
+	}
+	js, err := csvElements(bytes.NewReader(bs), columns)
+	if err != nil {
+		return nil, prov, fmt.Errorf("%s: %w", f.Path, err)
+	}
+	es, err := Parse(js)
+	return es, prov, err
+}
+
+// URL is a URL serving JSON in the upstream format. A token in
+// PERIODIC_DATA_TOKEN is sent as a bearer token, for data kept behind a
+// login.
+type URL string
+
+func (u URL) Elements(ctx context.Context) ([]Element, Provenance, error) {
+	body, err := httpGet(ctx, string(u), os.Getenv("PERIODIC_DATA_TOKEN"))
+	if err != nil {
+		return nil, Provenance{}, err
+	}
+	es, err := Parse(body)
+	return es, dataProvenance(string(u), body), err
+}
+
+// dataProvenance records the source of element data and a hash of it, with
+// the version the data gives itself in a top-level "version" key, if any.
+func dataProvenance(src string, bs []byte) Provenance {
+	sum := sha256.Sum256(bs)
+	prov := Provenance{Source: src, SHA256: hex.EncodeToString(sum[:])}
+	var root struct {
+		Version json.RawMessage `json:"version"`
+	}
+	if json.Unmarshal(bs, &root) == nil && root.Version != nil {
+		// A number or a string.
+		var v any
+		if json.Unmarshal(root.Version, &v) == nil && v != nil {
+			prov.Version = fmt.Sprint(v)
+		}
+	}
+	return prov
+}
+
+// httpGet downloads a URL, sending token as a bearer token if it isn't "".
+func httpGet(ctx context.Context, url, token string) ([]byte, error) {
+	client := http.Client{Timeout: 20 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// SQLite is an SQLite database with an elements table. Its columns
+// are found by name: those of the upstream format, or as export names
+// them, with the category given by name or by id in a categories table.
+type SQLite string
+
+// sqliteDataColumns maps the columns export writes to the upstream fields
+// they hold, where the names differ.
+var sqliteDataColumns = map[string]string{
+	"melting_point":          "melt",
+	"boiling_point":          "boil",
+	"electron_configuration": "electron_configuration_semantic",
+	"electronegativity":      "electronegativity_pauling",
+}
+
+func (d SQLite) Elements(context.Context) ([]Element, Provenance, error) {
+	bs, err := os.ReadFile(string(d))
+	if err != nil {
+		return nil, Provenance{}, err
+	}
+	prov := dataProvenance(string(d), bs)
+	db, err := openSQLite(bs)
+	if err != nil {
+		return nil, prov, fmt.Errorf("%s: %w", d, err)
+	}
+	if v := db.userVersion(); v != 0 {
+		prov.Version = fmt.Sprint(v)
+	}
+	cols, rows, err := db.table("elements")
+	if err != nil {
+		return nil, prov, fmt.Errorf("%s: %w", d, err)
+	}
+	categories := map[int64]string{}
+	if slices.Contains(cols, "category_id") {
+		ccols, crows, err := db.table("categories")
+		if err != nil {
+			return nil, prov, fmt.Errorf("%s: %w", d, err)
+		}
+		id, name := slices.Index(ccols, "id"), slices.Index(ccols, "name")
+		if id < 0 || name < 0 {
+			return nil, prov, fmt.Errorf("%s: categories has no id and name columns", d)
+		}
+		for _, row := range crows {
+			if max(id, name) < len(row) {
+				n, _ := row[id].(int64)
+				categories[n], _ = row[name].(string)
+			}
+		}
+	}
+
+	// Build the same JSON the other sources read, so the data goes through
+	// Parse like theirs.
+	var elements []map[string]any
+	for _, row := range rows {
+		e := map[string]any{}
+		for i, col := range cols {
+			if i >= len(row) || row[i] == nil {
+				continue
+			}
+			v := row[i]
+			switch col {
+			case "category_id":
+				n, _ := v.(int64)
+				col, v = "category", categories[n]
+			case "shells":
+				// Written in a cell as in a CSV, 2,8,14,2.
+				s, _ := v.(string)
+				var shells []int
+				for _, f := range strings.Split(s, ",") {
+					var n int
+					if _, err := fmt.Sscan(strings.TrimSpace(f), &n); err == nil {
+						shells = append(shells, n)
+					}
+				}
+				v = shells
+			}
+			if field, ok := sqliteDataColumns[col]; ok {
+				col = field
+			}
+			e[col] = v
+		}
+		elements = append(elements, e)
+	}
+	js, err := json.Marshal(map[string]any{"elements": elements})
+	if err != nil {
+		return nil, prov, err
+	}
+	es, err := Parse(js)
+	if err != nil {
+		return nil, prov, fmt.Errorf("%s: %w", d, err)
+	}
+	return es, prov, nil
+}
@@ -0,0 +1,322 @@
+package elements
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// sqliteFile reads the tables of a database file, such as one export
+// writes, or one kept elsewhere in the same format. It reads the pages
+// directly as well, so it sees only what has been written to the main file:
+// a database in WAL mode must be checkpointed first.
+type sqliteFile struct {
+	data     []byte
+	pageSize int
+	usable   int // page size less the reserved space at the end of each
+}
+
+func openSQLite(data []byte) (*sqliteFile, error) {
+	if len(data) < 100 || !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		return nil, fmt.Errorf("not an SQLite database")
+	}
+	size := int(binary.BigEndian.Uint16(data[16:]))
+	if size == 1 {
+		size = 65536
+	}
+	if size < 512 || size&(size-1) != 0 || len(data)%size != 0 {
+		return nil, fmt.Errorf("bad page size %d", size)
+	}
+	if enc := binary.BigEndian.Uint32(data[56:]); enc > 1 {
+		return nil, fmt.Errorf("text encoding %d isn't UTF-8", enc)
+	}
+	// SQLite itself won't open a file with less than 480 bytes of each page
+	// usable, which leaves room for the header and a cell of any page.
+	usable := size - int(data[20])
+	if usable < 480 {
+		return nil, fmt.Errorf("bad reserved space %d", data[20])
+	}
+	return &sqliteFile{data: data, pageSize: size, usable: usable}, nil
+}
+
+// userVersion returns the number set with PRAGMA user_version, which a
+// database's authors may use to version its contents.
+func (f *sqliteFile) userVersion() uint32 { return binary.BigEndian.Uint32(f.data[60:]) }
+
+func (f *sqliteFile) page(n int) ([]byte, error) {
+	if n < 1 || n > len(f.data)/f.pageSize {
+		return nil, fmt.Errorf("page %d is past the end of the file", n)
+	}
+	return f.data[(n-1)*f.pageSize : n*f.pageSize][:f.usable], nil
+}
+
+// table returns the column names of a table and its rows, in rowid
+// order. Their values are nil, int64, float64, string or []byte, with the
+// rowid filled in for an INTEGER PRIMARY KEY column, which is stored as
+// NULL.
+func (f *sqliteFile) table(name string) ([]string, [][]any, error) {
+	schema, err := f.rows(1, map[int]bool{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("schema: %w", err)
+	}
+	for _, row := range schema {
+		if len(row.values) < 5 || row.values[0] != "table" || !strings.EqualFold(fmt.Sprint(row.values[1]), name) {
+			continue
+		}
+		root, ok := row.values[3].(int64)
+		sql, _ := row.values[4].(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s: bad root page", name)
+		}
+		recs, err := f.rows(int(root), map[int]bool{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		cols, key := sqliteColumns(sql)
+		rows := make([][]any, len(recs))
+		for i, rec := range recs {
+			rows[i] = rec.values
+			if key >= 0 && key < len(rec.values) && rec.values[key] == nil {
+				rec.values[key] = rec.rowid
+			}
+		}
+		return cols, rows, nil
+	}
+	return nil, nil, fmt.Errorf("no table %q", name)
+}
+
+// sqliteRow is a row of a table B-tree as it is stored.
+type sqliteRow struct {
+	rowid  int64
+	values []any
+}
+
+// rows walks the table B-tree rooted at page n. seen holds the pages
+// walked already, as a page reached twice means the tree loops.
+func (f *sqliteFile) rows(n int, seen map[int]bool) ([]sqliteRow, error) {
+	if seen[n] {
+		return nil, fmt.Errorf("page %d is in the B-tree twice", n)
+	}
+	seen[n] = true
+	p, err := f.page(n)
+	if err != nil {
+		return nil, err
+	}
+	off := 0
+	if n == 1 {
+		off = 100
+	}
+	kind := p[off]
+	count := int(binary.BigEndian.Uint16(p[off+3:]))
+	hdr := 8
+	if kind == 0x05 {
+		hdr = 12
+	} else if kind != 0x0d {
+		return nil, fmt.Errorf("page %d isn't a table B-tree page", n)
+	}
+	var rows []sqliteRow
+	for i := range count {
+		if off+hdr+2*i+2 > len(p) {
+			return nil, fmt.Errorf("page %d: bad cell count", n)
+		}
+		at := int(binary.BigEndian.Uint16(p[off+hdr+2*i:]))
+		if at+4 > len(p) {
+			return nil, fmt.Errorf("page %d: bad cell pointer", n)
+		}
+		if kind == 0x05 {
+			child, err := f.rows(int(binary.BigEndian.Uint32(p[at:])), seen)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, child...)
+			continue
+		}
+		size, k := readVarint(p[at:])
+		rowid, m := readVarint(p[at+k:])
+		if k == 0 || m == 0 {
+			return nil, fmt.Errorf("page %d: cell runs off the page", n)
+		}
+		// No record is bigger than the file holding it.
+		if size > uint64(len(f.data)) {
+			return nil, fmt.Errorf("page %d: bad record size %d", n, size)
+		}
+		payload, err := f.payload(p, at+k+m, int(size))
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", n, err)
+		}
+		row, err := readSQLiteRecord(payload)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", n, err)
+		}
+		rows = append(rows, sqliteRow{int64(rowid), row})
+	}
+	if kind == 0x05 {
+		child, err := f.rows(int(binary.BigEndian.Uint32(p[off+8:])), seen)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, child...)
+	}
+	return rows, nil
+}
+
+// payload reads a leaf cell's record of size bytes starting at off on page
+// p, following its chain of overflow pages if it has one, as leafCell
+// lays it out.
+func (f *sqliteFile) payload(p []byte, off, size int) ([]byte, error) {
+	u := f.usable
+	local := size
+	if maxLocal := u - 35; size > maxLocal {
+		minLocal := (u-12)*32/255 - 23
+		local = minLocal + (size-minLocal)%(u-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	if off+local > len(p) || local == size && off+size > len(p) {
+		return nil, fmt.Errorf("cell runs off the page")
+	}
+	out := append([]byte(nil), p[off:off+local]...)
+	if local == size {
+		return out, nil
+	}
+	if off+local+4 > len(p) {
+		return nil, fmt.Errorf("cell runs off the page")
+	}
+	next := int(binary.BigEndian.Uint32(p[off+local:]))
+	for len(out) < size {
+		op, err := f.page(next)
+		if err != nil {
+			return nil, fmt.Errorf("overflow: %w", err)
+		}
+		n := min(size-len(out), len(op)-4)
+		out = append(out, op[4:4+n]...)
+		next = int(binary.BigEndian.Uint32(op))
+	}
+	return out, nil
+}
+
+// readSQLiteRecord decodes a row in the record format.
+func readSQLiteRecord(rec []byte) ([]any, error) {
+	hdrLen, k := readVarint(rec)
+	if k == 0 || hdrLen < uint64(k) || hdrLen > uint64(len(rec)) {
+		return nil, fmt.Errorf("bad record header")
+	}
+	var values []any
+	body := rec[hdrLen:]
+	for at := k; at < int(hdrLen); {
+		t, n := readVarint(rec[at:hdrLen])
+		if n == 0 {
+			return nil, fmt.Errorf("bad record header")
+		}
+		at += n
+		var size uint64
+		switch {
+		case t <= 4:
+			size = t
+		case t == 5:
+			size = 6
+		case t == 6 || t == 7:
+			size = 8
+		case t >= 12:
+			size = (t - 12) / 2
+		}
+		if size > uint64(len(body)) {
+			return nil, fmt.Errorf("record runs short")
+		}
+		v := body[:size]
+		body = body[size:]
+		switch {
+		case t == 0:
+			values = append(values, nil)
+		case t <= 6:
+			// A big-endian two's complement integer of size bytes.
+			var x int64
+			if v[0]&0x80 != 0 {
+				x = -1
+			}
+			for _, b := range v {
+				x = x<<8 | int64(b)
+			}
+			values = append(values, x)
+		case t == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(v)))
+		case t == 8 || t == 9:
+			values = append(values, int64(t-8))
+		case t >= 12 && t%2 == 0:
+			values = append(values, append([]byte(nil), v...))
+		case t >= 13:
+			values = append(values, string(v))
+		default:
+			return nil, fmt.Errorf("unknown serial type %d", t)
+		}
+	}
+	return values, nil
+}
+
+// readVarint reads an SQLite varint, returning it and
+// its length, or a length of 0 if b ends first.
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := range min(len(b), 9) {
+		if i == 8 {
+			return v<<8 | uint64(b[i]), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// sqliteColumns returns the column names of a CREATE TABLE statement, in
+// order, and which is the INTEGER PRIMARY KEY standing for the rowid, or
+// -1.
+func sqliteColumns(sql string) ([]string, int) {
+	var clean strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		line, _, _ = strings.Cut(line, "--")
+		clean.WriteString(line + "\n")
+	}
+	s := clean.String()
+	open, end := strings.Index(s, "("), strings.LastIndex(s, ")")
+	if open < 0 || end < open {
+		return nil, -1
+	}
+	var defs []string
+	depth, start := 0, open+1
+	for i := open + 1; i < end; i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				defs = append(defs, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	defs = append(defs, s[start:end])
+	var cols []string
+	key := -1
+	for _, d := range defs {
+		fields := strings.Fields(strings.ToUpper(d))
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+			continue
+		}
+		if len(fields) >= 4 && fields[1] == "INTEGER" && fields[2] == "PRIMARY" && fields[3] == "KEY" {
+			key = len(cols)
+		}
+		cols = append(cols, strings.ToLower(strings.Trim(fields[0], "\"`[]")))
+	}
+	return cols, key
+}
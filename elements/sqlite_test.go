@@ -0,0 +1,171 @@
+package elements
+
+import (
+	"encoding/binary"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testdata/things.db was made by sqlite3 itself with 512 byte pages, so it
+// has an interior page (2) over the leaves of things (3 to 6) and a name
+// long enough to overflow (7 to 10).
+func readThings(t *testing.T) []byte {
+	t.Helper()
+	bs, err := os.ReadFile("testdata/things.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bs
+}
+
+// readTable opens a database and reads a table from it.
+func readTable(data []byte, name string) ([]string, [][]any, error) {
+	db, err := openSQLite(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.table(name)
+}
+
+func TestSQLiteTable(t *testing.T) {
+	data := readThings(t)
+	db, err := openSQLite(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := db.userVersion(); v != 7 {
+		t.Errorf("userVersion = %d, want 7", v)
+	}
+	cols, rows, err := db.table("THINGS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"id", "name", "n", "x", "raw"}; !reflect.DeepEqual(cols, want) {
+		t.Errorf("columns = %q, want %q", cols, want)
+	}
+	if len(rows) != 60 {
+		t.Fatalf("got %d rows, want 60", len(rows))
+	}
+	for i, row := range rows {
+		if row[0] != int64(i+1) {
+			t.Errorf("row %d has id %v", i, row[0])
+		}
+	}
+	for _, tt := range []struct {
+		id   int
+		want []any
+	}{
+		{1, []any{int64(1), "thing 1", int64(1000), 0.25, nil}},
+		{2, []any{int64(2), "thing 2", nil, 0.5, []byte{0x00, 0xff, 0x10}}},
+		{3, []any{int64(3), strings.Repeat("a", 2000), int64(-129), 0.75, nil}},
+		// SQLite stores a REAL without a fraction as an integer.
+		{4, []any{int64(4), "thing 4", int64(0), int64(1), nil}},
+		{5, []any{int64(5), "thing 5", int64(1), 1.25, nil}},
+		{6, []any{int64(6), "thing 6", int64(-1000000000000), 1.5, nil}},
+		{60, []any{int64(60), "thing 60", int64(216000000), int64(15), nil}},
+	} {
+		if got := rows[tt.id-1]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("row %d = %#v, want %#v", tt.id, got, tt.want)
+		}
+	}
+	if _, _, err := db.table("nothing"); err == nil {
+		t.Error("read a table that isn't there")
+	}
+}
+
+func TestSQLiteCorrupt(t *testing.T) {
+	const pageSize = 512
+	// cell returns the offset in the file of the first cell on page n.
+	cell := func(data []byte, n int) int {
+		start := (n - 1) * pageSize
+		return start + int(binary.BigEndian.Uint16(data[start+8:]))
+	}
+	for _, tt := range []struct {
+		name, want string
+		corrupt    func(data []byte) []byte
+	}{
+		{"not a database", "not an SQLite database", func(data []byte) []byte {
+			return data[:50]
+		}},
+		{"part of a page", "bad page size", func(data []byte) []byte {
+			return data[:len(data)-100]
+		}},
+		{"missing pages", "past the end", func(data []byte) []byte {
+			return data[:4*pageSize]
+		}},
+		{"reserved space", "bad reserved space", func(data []byte) []byte {
+			data[20] = 200
+			return data
+		}},
+		{"record size", "bad record size", func(data []byte) []byte {
+			at := cell(data, 3)
+			copy(data[at:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+			return data
+		}},
+		{"record header", "bad record header", func(data []byte) []byte {
+			// After a byte each for the record's size and rowid.
+			data[cell(data, 3)+2] = 0x7f
+			return data
+		}},
+		{"serial type", "record runs short", func(data []byte) []byte {
+			data[cell(data, 3)+3] = 0x7f
+			return data
+		}},
+		{"reserved serial type", "unknown serial type 10", func(data []byte) []byte {
+			data[cell(data, 3)+3] = 10
+			return data
+		}},
+		{"cell pointer", "bad cell pointer", func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[2*pageSize+8:], pageSize-2)
+			return data
+		}},
+		{"cell count", "page 3", func(data []byte) []byte {
+			binary.BigEndian.PutUint16(data[2*pageSize+3:], 0xffff)
+			return data
+		}},
+		{"loop", "in the B-tree twice", func(data []byte) []byte {
+			binary.BigEndian.PutUint32(data[pageSize+8:], 2)
+			return data
+		}},
+		{"child page", "page 4294967295 is past the end", func(data []byte) []byte {
+			binary.BigEndian.PutUint32(data[pageSize+8:], 0xffffffff)
+			return data
+		}},
+		{"overflow page", "overflow", func(data []byte) []byte {
+			for n := 7; n <= 10; n++ {
+				binary.BigEndian.PutUint32(data[(n-1)*pageSize:], 0x7fffffff)
+			}
+			return data
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := readTable(tt.corrupt(readThings(t)), "things")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestSQLiteAnyByte reads the file with each of its bytes changed in turn,
+// which may or may not be noticed but mustn't panic.
+func TestSQLiteAnyByte(t *testing.T) {
+	data := readThings(t)
+	bad := make([]byte, len(data))
+	for i := range data {
+		for _, b := range []byte{0x00, 0x7f, 0x80, 0xff} {
+			copy(bad, data)
+			bad[i] = b
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Fatalf("byte %d set to %#x: %v", i, b, r)
+					}
+				}()
+				readTable(bad, "things")
+			}()
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package elements
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AtomicWeight is a standard atomic weight as IUPAC writes it: a value
+// with the uncertainty in its last digits in brackets, as in "55.845(2)",
+// the interval it lies in for elements whose weight varies in nature, as
+// in "[1.00784, 1.00811]", or the mass number of the longest-lived
+// isotope in square brackets, as in "[209]", for elements without a
+// stable one.
+type AtomicWeight struct {
+	Value       float64 // the middle of an interval
+	Text        string  // the value as written, keeping its digits; "" if not given
+	Uncertainty string  // in the last digits of Text, "" if not given
+	MassNumber  bool    // Text is a mass number
+	Interval    bool    // Text is the two ends of an interval, "1.00784, 1.00811"
+}
+
+// ParseAtomicWeight reads the IUPAC notation. Empty means not given.
+func ParseAtomicWeight(s string) (AtomicWeight, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return AtomicWeight{}, nil
+	}
+	w := AtomicWeight{Text: s}
+	if inner, ok := strings.CutPrefix(s, "["); ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+		if !ok {
+			return w, fmt.Errorf("atomic weight %q: want a value such as 55.845(2), an interval such as [1.00784, 1.00811] or a mass number such as [209]", s)
+		}
+		if lo, hi, ok := strings.Cut(inner, ","); ok {
+			lo, hi = strings.TrimSpace(lo), strings.TrimSpace(hi)
+			low, err1 := strconv.ParseFloat(lo, 64)
+			high, err2 := strconv.ParseFloat(hi, 64)
+			if err1 != nil || err2 != nil || low > high {
+				return w, fmt.Errorf("atomic weight %q: bad interval", s)
+			}
+			w.Text, w.Interval, w.Value = lo+", "+hi, true, (low+high)/2
+			return w, nil
+		}
+		w.Text, w.MassNumber = inner, true
+	} else if v, u, ok := strings.Cut(s, "("); ok {
+		u, ok = strings.CutSuffix(u, ")")
+		if _, err := strconv.Atoi(u); !ok || err != nil {
+			return w, fmt.Errorf("atomic weight %q: bad uncertainty", s)
+		}
+		w.Text, w.Uncertainty = v, u
+	}
+	v, err := strconv.ParseFloat(w.Text, 64)
+	if err != nil {
+		return w, fmt.Errorf("atomic weight %q: %w", s, err.(*strconv.NumError).Err)
+	}
+	w.Value = v
+	return w, nil
+}
+
+// String writes the weight back in IUPAC notation.
+func (w AtomicWeight) String() string {
+	switch {
+	case w.MassNumber || w.Interval:
+		return "[" + w.Text + "]"
+	case w.Uncertainty != "":
+		return w.Text + "(" + w.Uncertainty + ")"
+	}
+	return w.Text
+}
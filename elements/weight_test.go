@@ -0,0 +1,66 @@
+package elements
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAtomicWeight(t *testing.T) {
+	for _, tt := range []struct {
+		src  string
+		want AtomicWeight
+		str  string // as String writes it back
+	}{
+		{"", AtomicWeight{}, ""},
+		{"55.845", AtomicWeight{Value: 55.845, Text: "55.845"}, "55.845"},
+		{" 55.845(2) ", AtomicWeight{Value: 55.845, Text: "55.845", Uncertainty: "2"}, "55.845(2)"},
+		{"4.002602(2)", AtomicWeight{Value: 4.002602, Text: "4.002602", Uncertainty: "2"}, "4.002602(2)"},
+		{"207.2(11)", AtomicWeight{Value: 207.2, Text: "207.2", Uncertainty: "11"}, "207.2(11)"},
+		// The digits are kept as written, trailing zeros and all.
+		{"1.0080(2)", AtomicWeight{Value: 1.008, Text: "1.0080", Uncertainty: "2"}, "1.0080(2)"},
+		{"[1.00784, 1.00811]", AtomicWeight{Value: (1.00784 + 1.00811) / 2, Text: "1.00784, 1.00811", Interval: true}, "[1.00784, 1.00811]"},
+		{"[6.938,6.997]", AtomicWeight{Value: (6.938 + 6.997) / 2, Text: "6.938, 6.997", Interval: true}, "[6.938, 6.997]"},
+		{"[209]", AtomicWeight{Value: 209, Text: "209", MassNumber: true}, "[209]"},
+	} {
+		t.Run(tt.src, func(t *testing.T) {
+			got, err := ParseAtomicWeight(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The middle of an interval needn't be the nearest float64.
+			if d := got.Value - tt.want.Value; d > 1e-12 || d < -1e-12 {
+				t.Errorf("value = %v, want %v", got.Value, tt.want.Value)
+			}
+			got.Value = tt.want.Value
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if s := got.String(); s != tt.str {
+				t.Errorf("String() = %q, want %q", s, tt.str)
+			}
+		})
+	}
+}
+
+func TestParseAtomicWeightErrors(t *testing.T) {
+	for _, tt := range []struct {
+		src, want string
+	}{
+		{"heavy", `atomic weight "heavy": invalid syntax`},
+		{"55.845(x)", `atomic weight "55.845(x)": bad uncertainty`},
+		{"55.845(2", `atomic weight "55.845(2": bad uncertainty`},
+		{"55.845()", `atomic weight "55.845()": bad uncertainty`},
+		{"[1.00811, 1.00784]", "bad interval"},
+		{"[1.00784, x]", "bad interval"},
+		{"[209", "want a value such as 55.845(2)"},
+		{"[x]", `atomic weight "[x]": invalid syntax`},
+		{"1e400", "value out of range"},
+	} {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := ParseAtomicWeight(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"periodic-table-tiles/render"
+)
+
+// Formats accepted by export -format.
+const exportSQLite = "sqlite"
+
+// The tables export writes. Categories hold the card colours, and each
+// element and isotope points to the rows it belongs to.
+const (
+	sqlCategories = `CREATE TABLE categories (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	colour TEXT
+)`
+	sqlElements = `CREATE TABLE elements (
+	number INTEGER PRIMARY KEY,
+	symbol TEXT NOT NULL,
+	name TEXT NOT NULL,
+	category_id INTEGER NOT NULL REFERENCES categories (id),
+	atomic_mass REAL,
+	atomic_weight TEXT,
+	xpos INTEGER,
+	ypos INTEGER,
+	melting_point REAL,
+	boiling_point REAL,
+	density REAL,
+	phase TEXT,
+	electron_configuration TEXT,
+	electronegativity REAL,
+	valence_electrons INTEGER,
+	discovered INTEGER, -- year, 0 for antiquity
+	summary TEXT
+)`
+	sqlIsotopes = `CREATE TABLE isotopes (
+	id INTEGER PRIMARY KEY,
+	element_number INTEGER NOT NULL REFERENCES elements (number),
+	mass_number INTEGER NOT NULL,
+	stable INTEGER NOT NULL
+)`
+)
+
+// runExport writes the element data, with the isotopes and category
+// colours, to a file other programs can query.
+func runExport(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	o := addFlags(fs)
+	format := fs.String("format", exportSQLite, "file format: sqlite")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: export [flags] periodic.db")
+	}
+	if *format != exportSQLite {
+		return fmt.Errorf("unknown -format %q (want %s)", *format, exportSQLite)
+	}
+
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	colours, err := render.LoadColours(o.ColoursPath)
+	if os.IsNotExist(err) {
+		logger.Warn("No colours file, using a generated palette", "path", o.ColoursPath)
+		colours, err = render.GeneratePalette(render.DefaultPalette), nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading colours: %w", err)
+	}
+
+	var b bytes.Buffer
+	if err := writeSQLite(&b, exportTables(elements, colours)); err != nil {
+		return err
+	}
+	if err := os.WriteFile(fs.Arg(0), b.Bytes(), 0o644); err != nil {
+		return err
+	}
+	logger.Info("Written", "path", fs.Arg(0))
+	return nil
+}
+
+// exportTables puts the data into rows, leaving unknown values NULL.
+func exportTables(elements []Element, colours render.Colours) []sqliteTable {
+	orNull := func(v any, ok bool) any {
+		if !ok {
+			return nil
+		}
+		return v
+	}
+
+	// Every category with a colour or an element, numbered in name order.
+	byName := map[string]string{}
+	for name, c := range colours {
+		byName[normaliseCategory(name)] = c
+	}
+	for _, e := range elements {
+		if _, ok := byName[e.Type]; !ok {
+			byName[e.Type] = ""
+		}
+	}
+	categoryIDs := map[string]int{}
+	categories := sqliteTable{name: "categories", sql: sqlCategories}
+	for i, name := range slices.Sorted(maps.Keys(byName)) {
+		categoryIDs[name] = i + 1
+		categories.rows = append(categories.rows, []any{i + 1, name, orNull(byName[name], byName[name] != "")})
+	}
+
+	els := sqliteTable{name: "elements", sql: sqlElements}
+	isos := sqliteTable{name: "isotopes", sql: sqlIsotopes}
+	for _, e := range elements {
+		year, known := render.DiscoveryYears[e.Symbol]
+		els.rows = append(els.rows, []any{
+			e.Number, e.Symbol, e.Name, categoryIDs[e.Type],
+			orNull(e.Mass, e.Mass > 0), orNull(e.Weight.String(), e.Weight.Text != ""),
+			e.XPos, e.YPos,
+			orNull(e.Melt, e.Melt > 0), orNull(e.Boil, e.Boil > 0),
+			orNull(e.Density, e.Density > 0), orNull(e.Phase, e.Phase != ""),
+			orNull(e.Configuration, e.Configuration != ""), orNull(e.Electronegativity, e.Electronegativity > 0),
+			e.Valence, orNull(year, known), orNull(e.Summary, e.Summary != ""),
+		})
+		iso := render.IsotopesBySymbol[e.Symbol]
+		add := func(a, stable int) {
+			isos.rows = append(isos.rows, []any{len(isos.rows) + 1, e.Number, a, stable})
+		}
+		for _, a := range iso.Stable {
+			add(a, 1)
+		}
+		if iso.LongestLived > 0 {
+			add(iso.LongestLived, 0)
+		}
+	}
+	return []sqliteTable{categories, els, isos}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+
+	"periodic-table-tiles/render"
+)
+
+// runFamilies makes a poster for each category of the elements being
+// drawn: its name in a band of its colour over a grid of its cards.
+func runFamilies(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("families", flag.ExitOnError)
+	o := addFlags(fs)
+	cols := fs.Int("columns", 0, "cards in each row of a poster (default: as square a grid as it can)")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	if err := r.CheckStrict(elements); err != nil {
+		return err
+	}
+
+	// Categories in the order of their first element.
+	var order []string
+	members := map[string][]Element{}
+	for _, e := range elements {
+		c := r.Category(e.Type)
+		if members[c] == nil {
+			order = append(order, c)
+		}
+		members[c] = append(members[c], e)
+	}
+
+	header, err := r.CardFont(float64(r.TileH) / 4)
+	if err != nil {
+		return err
+	}
+	return saveFiles(o, func(st store) ([]string, error) {
+		var files []string
+		for _, c := range order {
+			if ctx.Err() != nil {
+				return files, fmt.Errorf("interrupted")
+			}
+			fname := "family_" + cssName(c) + ".png"
+			if err := savePNG(st, fname, familyPoster(r, header, c, members[c], *cols), o); err != nil {
+				return files, err
+			}
+			files = append(files, fname)
+		}
+		return files, nil
+	})
+}
+
+// familyPoster draws a category's poster with cols cards a row.
+func familyPoster(r *render.Renderer, header font.Face, category string, elements []Element, cols int) *image.RGBA {
+	if cols <= 0 {
+		cols = int(math.Ceil(math.Sqrt(float64(len(elements)))))
+	}
+	cols = min(cols, len(elements))
+	rows := (len(elements) + cols - 1) / cols
+	pad := r.TileH / 20
+	bandH := r.TileH / 2
+	img := image.NewRGBA(image.Rect(0, 0, cols*(r.TileW+pad)+pad, bandH+rows*(r.TileH+pad)+pad))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	band := image.Rect(0, 0, img.Rect.Dx(), bandH)
+	draw.Draw(img, band, image.NewUniform(r.CategoryColour(category)), image.Point{}, draw.Src)
+	title := render.FitText(header, strings.ToUpper(category[:1])+category[1:], band.Dx()-2*pad)
+	y := (bandH + header.Metrics().Ascent.Round()) / 2
+	render.DrawText(img, header, (band.Dx()-render.MeasureText(header, title).Round())/2, y, title, color.Black)
+
+	for i, e := range elements {
+		tile := r.Tile(e)
+		at := image.Pt(pad+i%cols*(r.TileW+pad), bandH+pad+i/cols*(r.TileH+pad))
+		draw.Draw(img, tile.Bounds().Add(at), tile, image.Point{}, draw.Over)
+	}
+	return img
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"periodic-table-tiles/render"
+)
+
+// runFlameChart renders a single reference chart listing every element that
+// has a flame test colour, in atomic number order.
+func runFlameChart(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("flame-test", flag.ExitOnError)
+	o := addFlags(fs)
+	cols := fs.Int("columns", 5, "swatches per row")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if *cols < 1 {
+		return errors.New("-columns must be at least 1")
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	var shown []Element
+	for _, e := range elements {
+		if _, ok := render.FlameColours[e.Symbol]; ok {
+			shown = append(shown, e)
+		}
+	}
+
+	cellW, cellH := r.TileW, r.TileH
+	pad := cellH / 20
+	rows := (len(shown) + *cols - 1) / *cols
+	img := image.NewRGBA(image.Rect(0, 0, *cols*cellW, rows*cellH))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	for i, e := range shown {
+		fc := render.FlameColours[e.Symbol]
+		x0, y0 := (i%*cols)*cellW, (i/(*cols))*cellH
+		swatch := image.Rect(x0+pad, y0+pad, x0+cellW-pad, y0+cellH/2)
+		render.DrawSwatch(img, swatch, render.HexToRGBA(fc.Colour))
+
+		symW := render.MeasureText(r.NameFont, e.Symbol).Round()
+		render.DrawText(img, r.NameFont, x0+(cellW-symW)/2, swatch.Max.Y+pad+r.NameFont.Metrics().Height.Round(), e.Symbol, color.Black)
+		descW := render.MeasureText(r.MassFont, fc.Description).Round()
+		render.DrawText(img, r.MassFont, x0+(cellW-descW)/2, y0+cellH-pad, fc.Description, color.Black)
+	}
+
+	return saveAsset(o, "flame_test.png", img)
+}
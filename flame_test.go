@@ -0,0 +1,14 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFlameChartColumns(t *testing.T) {
+	for _, cols := range []string{"0", "-2"} {
+		if err := runFlameChart(context.Background(), []string{"-columns", cols}); err == nil {
+			t.Errorf("-columns %s: no error", cols)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"text/template"
+	"time"
+
+	"periodic-table-tiles/render"
+)
+
+// toolName is what the footer calls this program.
+const toolName = "periodic-table-tiles"
+
+// footerData is what a -footer template is given:
+//
+//	Data: {{base .Data}} {{.DataVersion}} / generated by {{.Tool}} {{.Version}}
+type footerData struct {
+	Data        string    // -data, or the URL of the upstream dataset
+	DataVersion string    // as the data gives it, if it does
+	DataSHA256  string    // of the data as read
+	Weights     string    // -atomic-weights, if given
+	Tool        string    // periodic-table-tiles
+	Version     string    // of the build, or its commit
+	Date        time.Time // when the poster was drawn
+}
+
+var footerFuncs = template.FuncMap{
+	"base":  filepath.Base,
+	"short": func(s string) string { return s[:min(len(s), 12)] },
+}
+
+// parseFooter parses a -footer template.
+func parseFooter(text string) (*template.Template, error) {
+	t, err := template.New("footer").Funcs(footerFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("-footer: %w", err)
+	}
+	return t, nil
+}
+
+// footerText fills in a -footer template from where loadElements got the
+// data, collapsing the space left by any blank fields.
+func footerText(t *template.Template, o *options) (string, error) {
+	data, weights := o.provenance["data"], o.provenance["atomic_weights"]
+	var sb strings.Builder
+	err := t.Execute(&sb, footerData{
+		Data:        data.Source,
+		DataVersion: data.Version,
+		DataSHA256:  data.SHA256,
+		Weights:     weights.Source,
+		Tool:        toolName,
+		Version:     toolVersion(),
+		Date:        time.Now(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("-footer: %w", err)
+	}
+	return strings.Join(strings.Fields(sb.String()), " "), nil
+}
+
+// toolVersion returns the module version this was built as, or else the
+// commit it was built from, or "dev". The pseudo-versions Go gives builds
+// of a checkout, v0.0.0-<date>-<commit>, are too long for a footer.
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" && !strings.HasPrefix(v, "v0.0.0-") {
+		return v
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			return s.Value[:min(len(s.Value), 7)]
+		}
+	}
+	return "dev"
+}
+
+// footerHeight returns the height of the strip a footer is drawn in below
+// a table, and the size of its text.
+func footerHeight(r *render.Renderer) (int, float64) {
+	h := max(12, r.TileH/4)
+	return h, float64(h) / 2
+}
+
+// footerColour is the grey of the footer's text.
+var footerColour = color.RGBA{0x55, 0x55, 0x55, 0xff}
+
+// addFooter returns the table with a white strip below it and txt written
+// small in its left corner, cut short to fit.
+func addFooter(img *image.RGBA, r *render.Renderer, txt string) (*image.RGBA, error) {
+	h, size := footerHeight(r)
+	face, err := r.CardFont(size)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(b.Min.X, b.Min.Y, b.Max.X, b.Max.Y+h))
+	draw.Draw(out, out.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(out, b, img, b.Min, draw.Src)
+	margin := max(1, r.TileH/40) * 2 // the table's own margin
+	txt = render.FitText(face, txt, b.Dx()-2*margin)
+	render.DrawText(out, face, b.Min.X+margin, b.Max.Y+h*2/3, txt, footerColour)
+	return out, nil
+}
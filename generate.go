@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"periodic-table-tiles/render"
+)
+
+// summary records what a tile generation run got through.
+type summary struct {
+	Total     int           `json:"total"`
+	Written   int           `json:"written"`
+	Skipped   int           `json:"skipped"` // already done by the run being resumed
+	Changed   int           `json:"changed"` // written cards that differ from the previous run's
+	Failed    int           `json:"failed"`
+	Cancelled bool          `json:"cancelled"`
+	Elapsed   time.Duration `json:"-"`
+}
+
+func (s summary) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("total", s.Total),
+		slog.Int("written", s.Written),
+		slog.Int("skipped", s.Skipped),
+		slog.Int("changed", s.Changed),
+		slog.Int("failed", s.Failed),
+		slog.Bool("cancelled", s.Cancelled),
+		slog.Duration("elapsed", s.Elapsed),
+	)
+}
+
+func (s summary) String() string {
+	status := "done"
+	if s.Cancelled {
+		status = "cancelled"
+	}
+	skipped := ""
+	if s.Skipped > 0 {
+		skipped = fmt.Sprintf(" (%d already done)", s.Skipped)
+	}
+	return fmt.Sprintf("%s: %d of %d cards written%s, %d changed, %d failed, in %s",
+		status, s.Written, s.Total, skipped, s.Changed, s.Failed, s.Elapsed.Round(time.Millisecond))
+}
+
+func tileFilename(e Element) string {
+	return fmt.Sprintf("%03d_%s.png", e.Number, e.Symbol)
+}
+
+// generateTiles renders and saves a card for each element, recording each in
+// the manifest as it goes. With -resume, cards the manifest already has are
+// skipped. It stops between cards once ctx is cancelled, so the card being
+// encoded at the time is still finished, and returns the first error it hits.
+func generateTiles(ctx context.Context, r *render.Renderer, st store, elements []Element, o *options, p *progressLog) (summary, error) {
+	start := time.Now()
+	s := summary{Total: len(elements)}
+
+	var m *manifest
+	var err error
+	if o.resume {
+		m, err = resumeManifest(st, o)
+	} else {
+		m, err = openManifest(st)
+	}
+	if err != nil {
+		return s, err
+	}
+	m.Options = o.settings
+	m.Provenance = o.provenance
+
+	var todo []Element
+	for _, e := range elements {
+		if o.resume && m.done(st, e.Number) {
+			s.Skipped++
+			p.element(e, tileFilename(e), "skipped", 0, nil)
+			continue
+		}
+		todo = append(todo, e)
+	}
+
+	// Stop rendering ahead if saving fails.
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tiles, err := r.RenderAll(rctx, todo)
+	if err != nil {
+		return s, err
+	}
+	for t := range tiles {
+		if ctx.Err() != nil {
+			break
+		}
+		e := t.Element
+		fname := tileFilename(e)
+
+		// Save PNG
+		err := t.Err
+		if err == nil {
+			err = savePNG(st, fname, t.Tile, o)
+		}
+		changed := false
+		if err == nil {
+			changed, err = m.record(st, manifestEntry{Number: e.Number, Symbol: e.Symbol, Path: fname})
+		}
+		if err == nil {
+			err = m.save(st)
+		}
+		if err != nil {
+			s.Failed++
+			s.Elapsed = time.Since(start)
+			p.element(e, fname, "failed", time.Since(t.Began), err)
+			return s, fmt.Errorf("%s: %w", fname, err)
+		}
+		s.Written++
+		if changed {
+			s.Changed++
+		}
+		p.element(e, fname, "written", time.Since(t.Began), nil)
+		logger.Info("Written", "path", fname)
+	}
+	s.Cancelled = s.Written < len(todo)
+	if !s.Cancelled {
+		if err := saveElementsInfo(st, m, elements); err != nil {
+			s.Elapsed = time.Since(start)
+			return s, fmt.Errorf("%s: %w", elementsInfoName, err)
+		}
+	}
+	s.Elapsed = time.Since(start)
+	return s, nil
+}
@@ -2,6 +2,7 @@ module periodic-table-tiles
 
 go 1.25.0
 
-require golang.org/x/image v0.30.0
-
-require golang.org/x/text v0.28.0 // indirect
+require (
+	golang.org/x/image v0.30.0
+	golang.org/x/text v0.28.0
+)
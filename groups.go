@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"periodic-table-tiles/render"
+)
+
+// loadElements fetches the elements, takes their atomic weights from any
+// -atomic-weights table, names the -out-versioned folder, moves those
+// listed in -groups into
+// their groups and keeps only the ones on the -preset's table and in
+// -categories. With a -colour-by other than category, each is then put in
+// the category for what the colours show, such as its valence electrons.
+func loadElements(ctx context.Context, o *options) ([]Element, error) {
+	elements, prov, err := elementsCache.fetch(ctx, o.dataPath, o.columnsPath)
+	if err != nil {
+		return nil, fmt.Errorf("fetching elements: %w", err)
+	}
+	o.provenance = map[string]provenance{"data": prov}
+	if o.atomicWeights != "" {
+		prov, err := applyAtomicWeights(ctx, elements, o.atomicWeights)
+		if err != nil {
+			return nil, fmt.Errorf("reading atomic weights: %w", err)
+		}
+		o.provenance["atomic_weights"] = prov
+	}
+	if o.outVersioned {
+		versionOutdir(o)
+	}
+	groups, err := render.LoadGroups(o.GroupsPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading groups: %w", err)
+	}
+
+	assigned := map[int]string{}
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		for _, member := range groups[name] {
+			i := indexElement(elements, member)
+			if i < 0 {
+				return nil, fmt.Errorf("%s: unknown element %q in %q", o.GroupsPath, member, name)
+			}
+			e := &elements[i]
+			if other, ok := assigned[e.Number]; ok && other != name {
+				return nil, fmt.Errorf("%s: %s is in both %q and %q", o.GroupsPath, e.Symbol, other, name)
+			}
+			assigned[e.Number] = name
+			e.Type = name
+		}
+	}
+
+	preset, err := render.LookupPreset(o.Preset)
+	if err != nil {
+		return nil, err
+	}
+	if preset != nil {
+		if elements, err = preset.Filter(elements); err != nil {
+			return nil, err
+		}
+	}
+
+	if o.categories != "" {
+		var only []string
+		for _, c := range strings.Split(o.categories, ",") {
+			only = append(only, normaliseCategory(c))
+		}
+		elements = slices.DeleteFunc(elements, func(e Element) bool { return !slices.Contains(only, e.Type) })
+		if len(elements) == 0 {
+			return nil, fmt.Errorf("no elements in -categories %s", o.categories)
+		}
+	}
+	for i := range elements {
+		switch o.ColourBy {
+		case render.ColourByValence:
+			elements[i].Type = render.ValenceCategory(elements[i].Valence)
+		case render.ColourByMagnetism:
+			elements[i].Type = magnetismCategory(elements[i].Symbol)
+		case render.ColourByOccurrence:
+			elements[i].Type = render.Occurrence(elements[i].Number)
+		case render.ColourByStableIsotopes:
+			elements[i].Type = stableIsotopesCategory(elements[i].Symbol)
+		}
+	}
+	return elements, nil
+}
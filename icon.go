@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"slices"
+
+	xdraw "golang.org/x/image/draw"
+
+	"periodic-table-tiles/render"
+)
+
+// Icon file formats accepted by icon -format.
+const (
+	iconICO  = "ico"
+	iconICNS = "icns"
+)
+
+// icoSizes are the sizes in a Windows icon or favicon, up to the largest
+// the format allows.
+var icoSizes = []int{16, 24, 32, 48, 64, 128, 256}
+
+// icnsTypes are the macOS icon types holding PNGs, by size.
+var icnsTypes = []struct {
+	size int
+	kind string
+}{
+	{16, "icp4"}, {32, "icp5"}, {64, "icp6"}, {128, "ic07"}, {256, "ic08"}, {512, "ic09"}, {1024, "ic10"},
+}
+
+// runIcon makes an icon file of one element's card. The card is drawn once
+// at the largest size and scaled down with -resample for the others.
+func runIcon(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("icon", flag.ExitOnError)
+	o := addFlags(fs)
+	name := fs.String("element", "", "the element to make the icon of, by symbol or atomic number")
+	format := fs.String("format", iconICO, "ico for Windows icons and favicons, or icns for macOS")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if *format != iconICO && *format != iconICNS {
+		return fmt.Errorf("unknown -format %q (want %s or %s)", *format, iconICO, iconICNS)
+	}
+	if *name == "" {
+		return fmt.Errorf("-element is required")
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	i := indexElement(elements, *name)
+	if i < 0 {
+		return fmt.Errorf("unknown element %q", *name)
+	}
+	e := elements[i]
+
+	var sizes []int
+	if *format == iconICO {
+		sizes = icoSizes
+	} else {
+		for _, t := range icnsTypes {
+			sizes = append(sizes, t.size)
+		}
+	}
+	largest := slices.Max(sizes)
+	big, err := iconImage(o, e, largest)
+	if err != nil {
+		return err
+	}
+	// Icons are sized in pixels, not printed.
+	po := *o
+	po.dpi = 0
+	pngs := map[int][]byte{}
+	for _, size := range sizes {
+		img := big
+		if size != largest {
+			img = image.NewRGBA(image.Rect(0, 0, size, size))
+			render.Resamplers[o.Resample].Scale(img, img.Bounds(), big, big.Bounds(), xdraw.Src, nil)
+		}
+		var b bytes.Buffer
+		if err := encodePNG(&b, img, &po); err != nil {
+			return err
+		}
+		pngs[size] = b.Bytes()
+	}
+
+	fname := fmt.Sprintf("%s.%s", e.Symbol, *format)
+	return saveFile(o, fname, func(st store) error {
+		return st.put(fname, func(w io.Writer) error {
+			if *format == iconICNS {
+				return writeICNS(w, pngs)
+			}
+			return writeICO(w, sizes, pngs)
+		})
+	})
+}
+
+// iconImage renders a card as large as fits in a size×size square, centred
+// on a transparent background.
+func iconImage(o *options, e Element, size int) (*image.RGBA, error) {
+	so := *o
+	so.Width, so.dpi = 0, 0
+	so.Height = size
+	if w, _ := render.TileSize(o.Shape, size); w > size {
+		so.Height = size * size / w
+	}
+	r, err := newRenderer(&so)
+	if err != nil {
+		return nil, err
+	}
+	tile := r.Tile(e)
+	canvas := image.NewRGBA(image.Rect(0, 0, size, size))
+	at := image.Pt((size-r.TileW)/2, (size-r.TileH)/2)
+	draw.Draw(canvas, tile.Bounds().Add(at), tile, image.Point{}, draw.Src)
+	return canvas, nil
+}
+
+// writeICO writes an icon file of PNG images, which every version of
+// Windows since Vista and every browser reads.
+func writeICO(w io.Writer, sizes []int, pngs map[int][]byte) error {
+	var b bytes.Buffer
+	le := binary.LittleEndian
+	b.Write(le.AppendUint16(le.AppendUint16(le.AppendUint16(nil, 0), 1), uint16(len(sizes))))
+	offset := 6 + 16*len(sizes)
+	for _, size := range sizes {
+		// Sizes are a byte each, with 0 meaning 256.
+		dim := byte(size % 256)
+		b.Write([]byte{dim, dim, 0, 0})
+		b.Write(le.AppendUint16(le.AppendUint16(nil, 1), 32))
+		b.Write(le.AppendUint32(le.AppendUint32(nil, uint32(len(pngs[size]))), uint32(offset)))
+		offset += len(pngs[size])
+	}
+	for _, size := range sizes {
+		b.Write(pngs[size])
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeICNS writes a macOS icon file of PNG images.
+func writeICNS(w io.Writer, pngs map[int][]byte) error {
+	var body bytes.Buffer
+	for _, t := range icnsTypes {
+		body.WriteString(t.kind)
+		body.Write(binary.BigEndian.AppendUint32(nil, uint32(8+len(pngs[t.size]))))
+		body.Write(pngs[t.size])
+	}
+	var b bytes.Buffer
+	b.WriteString("icns")
+	b.Write(binary.BigEndian.AppendUint32(nil, uint32(8+body.Len())))
+	b.Write(body.Bytes())
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// icoEntry is an image in an ICO file's directory.
+type icoEntry struct {
+	width, height byte
+	planes, bpp   uint16
+	data          []byte
+}
+
+// readICO parses an ICO file of PNG images.
+func readICO(t *testing.T, bs []byte) []icoEntry {
+	t.Helper()
+	le := binary.LittleEndian
+	if len(bs) < 6 || le.Uint16(bs) != 0 || le.Uint16(bs[2:]) != 1 {
+		t.Fatalf("not an icon file: % x", bs[:min(len(bs), 6)])
+	}
+	var entries []icoEntry
+	for i := range int(le.Uint16(bs[4:])) {
+		dir := bs[6+16*i:]
+		size, offset := le.Uint32(dir[8:]), le.Uint32(dir[12:])
+		if int(offset)+int(size) > len(bs) {
+			t.Fatalf("image %d runs past the end of the file", i)
+		}
+		entries = append(entries, icoEntry{dir[0], dir[1], le.Uint16(dir[4:]), le.Uint16(dir[6:]), bs[offset : offset+size]})
+	}
+	return entries
+}
+
+// readICNS parses an ICNS file into its chunks by type, in order.
+func readICNS(t *testing.T, bs []byte) (kinds []string, data map[string][]byte) {
+	t.Helper()
+	be := binary.BigEndian
+	if len(bs) < 8 || string(bs[:4]) != "icns" || int(be.Uint32(bs[4:])) != len(bs) {
+		t.Fatalf("not an icns file of %d bytes: % x", len(bs), bs[:min(len(bs), 8)])
+	}
+	data = map[string][]byte{}
+	for at := 8; at < len(bs); {
+		n := int(be.Uint32(bs[at+4:]))
+		if n < 8 || at+n > len(bs) {
+			t.Fatalf("bad chunk length %d at %d", n, at)
+		}
+		kind := string(bs[at : at+4])
+		kinds = append(kinds, kind)
+		data[kind] = bs[at+8 : at+n]
+		at += n
+	}
+	return kinds, data
+}
+
+func TestWriteICO(t *testing.T) {
+	sizes := []int{16, 48, 256}
+	pngs := map[int][]byte{16: []byte("sixteen"), 48: []byte("forty-eight"), 256: []byte("two hundred and fifty-six")}
+	var b bytes.Buffer
+	if err := writeICO(&b, sizes, pngs); err != nil {
+		t.Fatal(err)
+	}
+	entries := readICO(t, b.Bytes())
+	if len(entries) != len(sizes) {
+		t.Fatalf("%d images, want %d", len(entries), len(sizes))
+	}
+	for i, size := range sizes {
+		e := entries[i]
+		// 256 is written as 0, as it doesn't fit in a byte.
+		if want := byte(size % 256); e.width != want || e.height != want {
+			t.Errorf("image %d is %dx%d, want %d", i, e.width, e.height, want)
+		}
+		if e.planes != 1 || e.bpp != 32 {
+			t.Errorf("image %d has %d planes of %d bits", i, e.planes, e.bpp)
+		}
+		if !bytes.Equal(e.data, pngs[size]) {
+			t.Errorf("image %d is %q, want %q", i, e.data, pngs[size])
+		}
+	}
+}
+
+func TestWriteICNS(t *testing.T) {
+	pngs := map[int][]byte{}
+	for _, it := range icnsTypes {
+		pngs[it.size] = bytes.Repeat([]byte{byte(it.size)}, it.size/16)
+	}
+	var b bytes.Buffer
+	if err := writeICNS(&b, pngs); err != nil {
+		t.Fatal(err)
+	}
+	kinds, data := readICNS(t, b.Bytes())
+	if len(kinds) != len(icnsTypes) {
+		t.Fatalf("chunks %q, want one for each of %d types", kinds, len(icnsTypes))
+	}
+	for i, it := range icnsTypes {
+		if kinds[i] != it.kind || !bytes.Equal(data[it.kind], pngs[it.size]) {
+			t.Errorf("chunk %d is %s of %d bytes, want %s of %d", i, kinds[i], len(data[kinds[i]]), it.kind, len(pngs[it.size]))
+		}
+	}
+}
+
+// TestRunIcon makes both kinds of icon from the embedded data and checks
+// each image decodes at its size.
+func TestRunIcon(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		sizes  []int
+	}{
+		{iconICO, icoSizes},
+		{iconICNS, []int{16, 32, 64, 128, 256, 512, 1024}},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			dir := t.TempDir()
+			args := []string{"-data", "embedded", "-element", "26", "-format", tt.format, "-outdir", dir}
+			if err := runIcon(context.Background(), args); err != nil {
+				t.Fatal(err)
+			}
+			bs, err := os.ReadFile(filepath.Join(dir, "Fe."+tt.format))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var images [][]byte
+			if tt.format == iconICO {
+				for _, e := range readICO(t, bs) {
+					images = append(images, e.data)
+				}
+			} else {
+				kinds, data := readICNS(t, bs)
+				for _, kind := range kinds {
+					images = append(images, data[kind])
+				}
+			}
+			if len(images) != len(tt.sizes) {
+				t.Fatalf("%d images, want %d", len(images), len(tt.sizes))
+			}
+			for i, size := range tt.sizes {
+				img, err := png.Decode(bytes.NewReader(images[i]))
+				if err != nil {
+					t.Fatalf("image %d: %v", i, err)
+				}
+				b := img.Bounds()
+				if b.Dx() != size || b.Dy() != size {
+					t.Errorf("image %d is %v, want %dx%d", i, b, size, size)
+				}
+				// A card is wider than it is high, so the square has
+				// transparent space above and below it.
+				if _, _, _, a := img.At(size/2, 0).RGBA(); a != 0 {
+					t.Errorf("image %d isn't transparent at the top", i)
+				}
+				if _, _, _, a := img.At(size/2, size/2).RGBA(); a == 0 {
+					t.Errorf("image %d is transparent in the middle", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRunIconErrors(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-element", "Fe", "-format", "bmp"}, `unknown -format "bmp" (want ico or icns)`},
+		{nil, "-element is required"},
+		{[]string{"-element", "Xx"}, `unknown element "Xx"`},
+	} {
+		err := runIcon(context.Background(), append([]string{"-data", "embedded", "-outdir", t.TempDir()}, tt.args...))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image/color"
+	"math"
+
+	"periodic-table-tiles/render"
+)
+
+// Measured binding energies per nucleon (MeV) for light nuclei, where the
+// liquid drop model below is a poor fit.
+var lightBinding = map[[2]int]float64{
+	{1, 1}: 0, {1, 2}: 1.112, {2, 3}: 2.573, {2, 4}: 7.074,
+	{3, 6}: 5.332, {3, 7}: 5.606, {4, 9}: 6.463, {5, 10}: 6.475,
+	{5, 11}: 6.928, {6, 12}: 7.680, {6, 13}: 7.470, {7, 14}: 7.476,
+	{7, 15}: 7.699, {8, 16}: 7.976, {8, 17}: 7.751, {8, 18}: 7.767,
+	{9, 19}: 7.779,
+}
+
+// bindingPerNucleon returns the binding energy per nucleon in MeV of the
+// nucleus with z protons and mass number a, using the semi-empirical mass
+// formula for anything heavier than fluorine.
+func bindingPerNucleon(z, a int) float64 {
+	if b, ok := lightBinding[[2]int{z, a}]; ok {
+		return b
+	}
+	const aV, aS, aC, aA, aP = 15.75, 17.8, 0.711, 23.7, 11.18
+	A, Z := float64(a), float64(z)
+	b := aV*A - aS*math.Pow(A, 2.0/3) - aC*Z*(Z-1)/math.Cbrt(A) - aA*(A-2*Z)*(A-2*Z)/A
+	switch n := a - z; {
+	case z%2 == 0 && n%2 == 0:
+		b += aP / math.Sqrt(A)
+	case z%2 == 1 && n%2 == 1:
+		b -= aP / math.Sqrt(A)
+	}
+	return b / A
+}
+
+// runBindingEnergy plots binding energy per nucleon against mass number for
+// every isotope in the isotope dataset.
+func runBindingEnergy(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("binding-energy", flag.ExitOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	stable := chartSeries{Label: "stable", Colour: color.RGBA{0x2b, 0x6c, 0xb0, 255}}
+	unstable := chartSeries{Label: "longest-lived (no stable isotope)", Colour: color.RGBA{0xd0, 0x4a, 0x3a, 255}}
+	var notes []chartNote
+	for _, e := range elements {
+		iso, ok := render.IsotopesBySymbol[e.Symbol]
+		if !ok {
+			continue
+		}
+		for _, a := range iso.Stable {
+			p := render.Point{X: float64(a), Y: bindingPerNucleon(e.Number, a)}
+			stable.Points = append(stable.Points, p)
+			switch e.Symbol + fmt.Sprint(a) {
+			case "He4", "Fe56":
+				notes = append(notes, chartNote{p, fmt.Sprintf("%s-%d", e.Symbol, a)})
+			}
+		}
+		if iso.LongestLived > 0 {
+			a := iso.LongestLived
+			p := render.Point{X: float64(a), Y: bindingPerNucleon(e.Number, a)}
+			unstable.Points = append(unstable.Points, p)
+			if e.Symbol == "U" {
+				notes = append(notes, chartNote{p, fmt.Sprintf("%s-%d", e.Symbol, a)})
+			}
+		}
+	}
+
+	c := chart{
+		Title:  "Binding energy per nucleon",
+		XLabel: "Mass number A",
+		YLabel: "B/A (MeV)",
+		Series: []chartSeries{stable, unstable},
+		Notes:  notes,
+		XMin:   0,
+		XMax:   300,
+		FixedX: true,
+		YMin:   0,
+		YMax:   9,
+		FixedY: true,
+	}
+	img, err := c.render(o.FontPath, o.Height*16/10, o.Height)
+	if err != nil {
+		return err
+	}
+
+	return saveAsset(o, "binding_energy.png", img)
+}
+
+// stableIsotopesCategory is the category a card is drawn as with
+// -colour-by stable-isotopes.
+func stableIsotopesCategory(symbol string) string {
+	n := render.StableIsotopes(symbol)
+	if n < 0 {
+		return "stable isotopes unknown"
+	}
+	return fmt.Sprintf("stable isotopes %d", n)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"periodic-table-tiles/render"
+)
+
+// logger carries everything the tool prints. By default it prints plain
+// lines such as "Written: 001_H.png"; -log-format json switches it to one
+// JSON object per line for build systems to parse.
+var logger = slog.New(&plainHandler{w: os.Stdout})
+
+func configureLogging(format string) error {
+	switch format {
+	case "text":
+		logger = slog.New(&plainHandler{w: os.Stdout})
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	default:
+		return fmt.Errorf("unknown -log-format %q (want text or json)", format)
+	}
+	render.SetLogger(logger)
+	return nil
+}
+
+func init() { render.SetLogger(logger) }
+
+// plainHandler prints the message followed by the attribute values, so
+// logger.Info("Written", "path", p) comes out as "Written: <p>".
+type plainHandler struct {
+	mu    sync.Mutex
+	w     io.Writer
+	attrs []slog.Attr
+}
+
+func (h *plainHandler) Enabled(_ context.Context, l slog.Level) bool { return l >= slog.LevelInfo }
+
+func (h *plainHandler) Handle(_ context.Context, r slog.Record) error {
+	var vals []string
+	add := func(a slog.Attr) bool {
+		// Prefer a value's own String over its structured LogValue.
+		if s, ok := a.Value.Any().(fmt.Stringer); ok {
+			vals = append(vals, s.String())
+		} else {
+			vals = append(vals, a.Value.Resolve().String())
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+
+	line := r.Message
+	if len(vals) > 0 {
+		line += ": " + strings.Join(vals, " ")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := fmt.Fprintln(h.w, line)
+	return err
+}
+
+func (h *plainHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &plainHandler{w: h.w, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+func (h *plainHandler) WithGroup(string) slog.Handler { return h }
+
+// progressEvent is one line of the -progress stream.
+type progressEvent struct {
+	Event   string   `json:"event"` // "element" or "summary"
+	Number  int      `json:"number,omitempty"`
+	Symbol  string   `json:"symbol,omitempty"`
+	Path    string   `json:"path,omitempty"`
+	Outcome string   `json:"outcome,omitempty"` // written, skipped or failed
+	Error   string   `json:"error,omitempty"`
+	Millis  float64  `json:"ms"`
+	Summary *summary `json:"summary,omitempty"`
+}
+
+// progressLog writes newline-delimited JSON progress events. A nil
+// progressLog discards them.
+type progressLog struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	c   io.Closer
+}
+
+// openProgress opens the -progress destination: a file path, or "-" for
+// standard error so it doesn't mix with the log on standard output.
+func openProgress(path string) (*progressLog, error) {
+	switch path {
+	case "":
+		return nil, nil
+	case "-":
+		return &progressLog{enc: json.NewEncoder(os.Stderr)}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &progressLog{enc: json.NewEncoder(f), c: f}, nil
+}
+
+func (p *progressLog) element(e Element, path, outcome string, took time.Duration, err error) {
+	if p == nil {
+		return
+	}
+	ev := progressEvent{Event: "element", Number: e.Number, Symbol: e.Symbol, Path: path, Outcome: outcome, Millis: millis(took)}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	p.write(ev)
+}
+
+func (p *progressLog) summary(s summary) {
+	if p == nil {
+		return
+	}
+	p.write(progressEvent{Event: "summary", Millis: millis(s.Elapsed), Summary: &s})
+}
+
+func (p *progressLog) write(ev progressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.enc.Encode(ev)
+}
+
+func (p *progressLog) Close() error {
+	if p == nil || p.c == nil {
+		return nil
+	}
+	return p.c.Close()
+}
+
+func millis(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
@@ -0,0 +1,12 @@
+package main
+
+import "periodic-table-tiles/render"
+
+// magnetismCategory is the category a card is drawn as with -colour-by
+// magnetism.
+func magnetismCategory(symbol string) string {
+	if m, ok := render.MagnetismBySymbol[symbol]; ok {
+		return m.Ordering
+	}
+	return render.MagnetismUnknown
+}
@@ -1,241 +1,285 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"image"
-	"image/color"
-	"image/draw"
-	"image/png"
 	"io"
-	"net/http"
 	"os"
-	"path/filepath"
-	"sort"
-	"strings"
-	"time"
-	"log"
-
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/opentype"
-	"golang.org/x/image/math/fixed"
+	"os/signal"
+	"syscall"
+
+	"periodic-table-tiles/render"
 )
 
-// Settings
-const numSize = 8.8
-const symSize = 2.5
-const nameSize = 6.5
-const massSize = 8.8
-
-type SourceRoot struct {
-	Elements []struct {
-		Number     int     `json:"number"`
-		Symbol     string  `json:"symbol"`
-		Name       string  `json:"name"`
-		AtomicMass float64 `json:"atomic_mass"`
-		Category   string  `json:"category"`
-		Xpos       int     `json:"xpos"`
-		Ypos       int     `json:"ypos"`
-	} `json:"elements"`
-}
+type options struct {
+	render.Options
 
-type Element struct {
-	Number int
-	Symbol string
-	Name   string
-	Mass   float64
-	Type   string
-}
+	categories     string
+	textToPath     bool
+	dataPath       string
+	columnsPath    string
+	atomicWeights  string
+	outdir         string
+	outVersioned   bool
+	configPath     string
+	heightLen      length
+	widthLen       length
+	dpi            float64
+	pngMode        string
+	pngCompression string
+	optimize       bool
+	optimizer      string
 
-type Colours map[string]string
+	logFormat    string
+	progressPath string
+	notifyURL    string
 
-func hexToRGBA(h string) color.RGBA {
-	h = strings.TrimPrefix(strings.TrimSpace(h), "#")
-	if len(h) == 3 {
-		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
-	}
-	if len(h) != 6 {
-		return color.RGBA{0, 0, 0, 255}
-	}
-	var c color.RGBA
-	fmt.Sscanf(h, "%02x%02x%02x", &c.R, &c.G, &c.B)
-	c.A = 255
-	return c
+	resume   bool              // the cards only
+	settings map[string]string // flags that affect the output, see renderSettings
+
+	provenance map[string]provenance // where loadElements got its data from, for the manifest
+
+	// Only the cards are profiled.
+	cpuProfile string
+	memProfile string
+	tracePath  string
 }
 
-func normaliseCategory(c string) string {
-	c = strings.ToLower(c)
-	c = strings.ReplaceAll(c, "-", " ")
-	c = strings.ReplaceAll(c, "_", " ")
-	c = strings.Join(strings.Fields(c), " ")
-	switch c {
-	case "diatomic nonmetal", "polyatomic nonmetal":
-		return "nonmetal"
-	case "noble gas", "noble gases":
-		return "noble gas"
-	case "alkali metal", "alkali metals":
-		return "alkali metal"
-	case "alkaline earth metal", "alkaline earth metals":
-		return "alkaline earth metal"
-	case "transition metal", "transition metals":
-		return "transition metal"
-	case "post transition metal", "post transition metals":
-		return "post-transition metal"
-	case "lanthanide", "lanthanoid", "lanthanoids", "lanthanides":
-		return "lanthanide"
-	case "actinide", "actinoid", "actinoids", "actinides":
-		return "actinide"
-	default:
-		return c
-	}
+// addFlags registers the rendering flags shared by every mode.
+func addFlags(fs *flag.FlagSet) *options {
+	d := render.DefaultOptions()
+	o := &options{}
+	fs.StringVar(&o.configPath, "config", "", "YAML, TOML or JSON file of flag values, with a section for each mode's own; flags given here override it")
+	fs.StringVar(&o.FontPath, "font", d.FontPath, "path to .ttf or .otf font file (default: Go Bold, built in)")
+	fs.StringVar(&o.ColoursPath, "colours", d.ColoursPath, "path to colours.json")
+	fs.StringVar(&o.ColoursPath, "colors", d.ColoursPath, "same as -colours")
+	fs.StringVar(&o.AliasesPath, "aliases", d.AliasesPath, "JSON file mapping extra category names to the ones they stand for")
+	fs.StringVar(&o.GroupsPath, "groups", d.GroupsPath, "JSON file of your own categories and the elements in them, e.g. {\"coinage metals\": [\"Cu\", \"Ag\", \"Au\"]}")
+	fs.StringVar(&o.NotesPath, "notes", d.NotesPath, "JSON file of notes to print along the bottom of the cards, by element symbol")
+	fs.BoolVar(&o.Strict, "strict", d.Strict, "fail instead of drawing cards with a missing colour or glyph, or text too wide for the card")
+	fs.BoolVar(&o.DebugLayout, "debug-layout", d.DebugLayout, "draw guides over the cards: the border's inside edge, the safe area, each line of text's box and baseline, and the areas kept for the options in use")
+	fs.BoolVar(&o.RandomStyle, "random-style", d.RandomStyle, "give every card its own shade of its colour and a background pattern, the same each run with the same -seed")
+	fs.Int64Var(&o.Seed, "seed", d.Seed, "seed for -random-style; each one gives a different set of cards")
+	fs.StringVar(&o.ColourBy, "colour-by", d.ColourBy, "what the card colours show: category, valence for the number of valence electrons, magnetism for the magnetic ordering, occurrence for primordial, from decay or synthetic, or stable-isotopes for a heatmap of the number of stable isotopes")
+	fs.BoolVar(&o.Valence, "valence", d.Valence, "draw the number of valence electrons on the cards")
+	fs.BoolVar(&o.Lewis, "lewis", d.Lewis, "draw the Lewis dot structure around the symbol of s- and p-block elements")
+	fs.BoolVar(&o.Compounds, "compounds", d.Compounds, "list a few common compounds of each element along the bottom of the cards")
+	fs.BoolVar(&o.Shells, "shells", d.Shells, "write the number of electrons in each shell, such as 2,8,14,2, along the bottom of the cards")
+	fs.BoolVar(&o.Magnetism, "magnetism", d.Magnetism, "write the magnetic ordering at room temperature, and the superconducting critical temperature of superconductors, along the bottom of the cards")
+	fs.BoolVar(&o.Occurrence, "occurrence", d.Occurrence, "hatch the cards of elements only found from the decay of others, and cross-hatch synthetic ones")
+	fs.StringVar(&o.Patterns, "patterns", d.Patterns, "fill the cards with a pattern of lines or dots by category, so they can be told apart in greyscale: auto, or a JSON file of pattern names by category, e.g. {\"noble gas\": \"dots\"}")
+	fs.StringVar(&o.RulesPath, "rules", d.RulesPath, "JSON file of changes to the cards of the elements matching a condition, such as a dashed border where \"category == unknown\"; see rulesSpec in render/rules.go")
+	fs.BoolVar(&o.Greyscale, "grayscale", d.Greyscale, "print the card colours as greys spread evenly from dark to light in the order of the colours' lightness, for black-and-white printing; -patterns tells them apart further")
+	fs.BoolVar(&o.StableIsotopes, "stable-isotopes", d.StableIsotopes, "write how many stable isotopes each element has along the bottom of the cards")
+	fs.StringVar(&o.Label, "label", d.Label, "write a label worked out from each element's data along the bottom of the cards, such as 'number + \" / \" + block'; see cardExpr in render/expr.go")
+	fs.BoolVar(&o.PhaseBar, "phase-bar", d.PhaseBar, "draw a bar of the solid, liquid and gas ranges of each element on a scale shared by every card")
+	fs.StringVar(&o.dataPath, "data", "", "where to read the element data from instead of downloading it: a JSON file in the upstream format, a CSV file, an http(s) URL serving the upstream format, an SQLite database (.db, .sqlite or sqlite:path), or embedded for the copy built in")
+	fs.StringVar(&o.columnsPath, "data-columns", "", "JSON file naming the -data CSV column for each upstream field, e.g. {\"number\": \"Atomic No\", \"symbol\": \"Sym\"}")
+	fs.StringVar(&o.atomicWeights, "atomic-weights", "", "file or URL of a CIAAW table of standard atomic weights, such as https://www.ciaaw.org/atomic-weights.htm, to take the atomic weights and masses from")
+	fs.StringVar(&o.MassFormat, "mass-format", d.MassFormat, "how the atomic mass is written: fixed to four decimal places, or iupac for the standard atomic weight with its uncertainty, such as 55.845(2)")
+	fs.StringVar(&o.MassUnit, "mass-unit", d.MassUnit, "unit written after the atomic mass: none, u, g/mol, or both for \"u (g/mol)\"")
+	fs.StringVar(&o.VAlign, "valign", d.VAlign, "vertical alignment of the card text: baseline (default), cap to centre the capitals or middle to centre the line, for every field or as field=alignment pairs such as \"symbol=cap\"")
+	fs.BoolVar(&o.WrapNames, "wrap-names", d.WrapNames, "break names too wide for the card across two lines, at a space or hyphen if they have one")
+	fs.Float64Var(&o.NameSpacing, "name-spacing", d.NameSpacing, "distance between the lines of a wrapped name, in multiples of the name's line height")
+	fs.StringVar(&o.categories, "categories", "", "only include elements in these comma-separated categories or -groups")
+	fs.StringVar(&o.Preset, "preset", d.Preset, "draw the table an exam board prints: gcse, ap-chem or ib for its elements, the fields on its cards and its rounding of the masses")
+	fs.StringVar(&o.outdir, "outdir", "elements", "output directory, or s3://bucket/prefix, gs://bucket/prefix or az://account/container/prefix to upload there")
+	fs.StringVar(&o.outdir, "out", "elements", "same as -outdir")
+	fs.BoolVar(&o.outVersioned, "out-versioned", false, "write into -outdir with the data's version, the colours file, the height and a hash of the other settings added to its name, such as elements-v2021-dark-512-3f9c0a1e, so variants don't overwrite each other")
+	o.heightLen = length{float64(d.Height), "px"}
+	fs.Var(&o.heightLen, "height", "tile image height in px, or in in, cm, mm or pt with -dpi (width scales to aspect ratio)")
+	fs.Var(&o.widthLen, "width", "rectangular tile width, in the same units as -height (default: from the height, in the classic proportions)")
+	fs.Float64Var(&o.dpi, "dpi", 0, "resolution to print at: turns a -height in in, cm, mm or pt into px and is recorded in the PNGs")
+	fs.StringVar(&o.ColourSpace, "colour-space", d.ColourSpace, "colour space of the images, recorded in them as an ICC profile: srgb, display-p3 for wide-gamut screens, or cmyk for print, with table -format tiff")
+	fs.StringVar(&o.Shape, "shape", d.Shape, "tile shape: rect, hex for hexagons or circle for round badges")
+	fs.StringVar(&o.Style, "style", d.Style, "card style: classic, kids for rounded cards with bigger type, no atomic mass and a picture from -illustrations, or outline for unfilled cards with outlined text in the category colour")
+	fs.StringVar(&o.Illustrations, "illustrations", d.Illustrations, "with -style kids, folder of pictures to put on the cards, named after their element such as He.png")
+	fs.BoolVar(&o.Bevel, "bevel", d.Bevel, "shade the border like a raised tile lit from the top left")
+	fs.BoolVar(&o.Flame, "flame", d.Flame, "draw a flame test colour swatch on cards that have one")
+	fs.BoolVar(&o.Spectrum, "spectrum", d.Spectrum, "draw the visible emission spectrum along the bottom of cards that have one")
+	fs.StringVar(&o.SpectraPath, "spectra", d.SpectraPath, "spectra file written by \"spectra import/fetch\" (default: bundled data)")
+	fs.StringVar(&o.pngMode, "png-mode", pngModeRGBA, "PNG colour type: rgba, or paletted for 8-bit indexed images that are much smaller")
+	fs.BoolVar(&o.textToPath, "text-to-path", false, "in SVG output, turn the text into the outlines of the -font's glyphs, so it looks the same where the font isn't installed")
+	fs.StringVar(&o.Hinting, "hinting", d.Hinting, "how far the card text's metrics are rounded to whole pixels: full, vertical, or none for even spacing at large sizes")
+	fs.Float64Var(&o.Gamma, "gamma", d.Gamma, "gamma correction of the card text's edges: above 1 draws it heavier, below 1 lighter")
+	fs.StringVar(&o.Subpixel, "subpixel", d.Subpixel, "draw the card text a third of a pixel at a time for LCD screens whose pixels are rgb or bgr stripes, or none")
+	fs.IntVar(&o.Supersample, "supersample", d.Supersample, "draw each card 2 or 4 times as big and scale it down, for smoother edges on large type and shapes at the cost of time")
+	fs.StringVar(&o.Resample, "resample", d.Resample, "filter for scaling cards down, for -supersample and the smaller sizes of an icon: catmull-rom, lanczos, bilinear or nearest")
+	fs.StringVar(&o.pngCompression, "png-compression", "default", "PNG compression level: default, none, fast or best")
+	fs.BoolVar(&o.optimize, "optimize", false, "try several lossless PNG encodings and keep the smallest")
+	fs.StringVar(&o.optimizer, "optimizer", "", "external command run on each written PNG, e.g. \"oxipng -o 4 {}\"")
+	fs.StringVar(&o.logFormat, "log-format", "text", "log output format: text, or json for one JSON object per line")
+	fs.StringVar(&o.progressPath, "progress", "", "write NDJSON progress events, one per element, to this file (\"-\" for stderr)")
+	return o
 }
 
-func fetchElements() ([]Element, error) {
-	client := http.Client{Timeout: 20 * time.Second}
-	const url = "https://raw.githubusercontent.com/Bowserinator/Periodic-Table-JSON/master/PeriodicTableJSON.json"
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
+// batchFlags is set while running a batch, so bad flags fail the job they
+// are in instead of exiting.
+var batchFlags bool
+
+// parseFlags parses a mode's flags and applies the ones that configure the
+// tool itself rather than the images.
+func parseFlags(fs *flag.FlagSet, o *options, args []string) error {
+	if batchFlags {
+		fs.Init(fs.Name(), flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if o.configPath != "" {
+		if err := applyConfig(fs, o.configPath); err != nil {
+			return err
+		}
+	}
+	h, err := o.heightLen.pixels(o.dpi)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("-height: %w", err)
 	}
-	var root SourceRoot
-	if err := json.Unmarshal(body, &root); err != nil {
-		return nil, err
+	o.Height = h
+	if o.widthLen.value > 0 {
+		if o.Width, err = o.widthLen.pixels(o.dpi); err != nil {
+			return fmt.Errorf("-width: %w", err)
+		}
 	}
-	var es []Element
-	for _, e := range root.Elements {
-		es = append(es, Element{
-			Number: e.Number,
-			Symbol: e.Symbol,
-			Name:   e.Name,
-			Mass:   e.AtomicMass,
-			Type:   normaliseCategory(e.Category),
-		})
-	}
-	sort.Slice(es, func(i, j int) bool { return es[i].Number < es[j].Number })
-	if len(es) > 118 {
-		es = es[:118]
-	}
-	return es, nil
+	o.settings = renderSettings(fs)
+	return configureLogging(o.logFormat)
 }
 
-func loadFont(path string, size float64) (font.Face, error) {
-	fBytes, err := os.ReadFile(path)
-	if err != nil {
+// newRenderer makes the card renderer for the flags, checking the
+// -png-mode and -png-compression the cards will be saved with too.
+func newRenderer(o *options) (*render.Renderer, error) {
+	if err := checkPNGMode(o.pngMode); err != nil {
 		return nil, err
 	}
-	ft, err := opentype.Parse(fBytes)
-	if err != nil {
+	if err := checkPNGCompression(o.pngCompression); err != nil {
 		return nil, err
 	}
-	return opentype.NewFace(ft, &opentype.FaceOptions{
-		Size:    size,
-		DPI:     72,
-		Hinting: font.HintingFull,
-	})
+	return render.New(&o.Options)
 }
 
-func drawText(img *image.RGBA, face font.Face, x, y int, txt string, col color.Color) {
-	d := &font.Drawer{
-		Dst:  img,
-		Src:  image.NewUniform(col),
-		Face: face,
-		Dot:  fixed.P(x, y),
-	}
-	d.DrawString(txt)
+// commands are the optional modes selected by the first argument. Without
+// one the tool generates the element cards.
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"flame-test":     runFlameChart,
+	"spectra":        runSpectra,
+	"binding-energy": runBindingEnergy,
+	"serve":          runServe,
+	"table":          runTable,
+	"discovery":      runDiscovery,
+	"temperature":    runTemperature,
+	"palette":        runPalette,
+	"themes":         runThemes,
+	"data":           runData,
+	"export":         runExport,
+	"atlas":          runAtlas,
+	"icon":           runIcon,
+	"sprite":         runSprite,
+	"css":            runCSS,
+	"families":       runFamilies,
+	"strip":          runStrip,
+	"contact-sheet":  runContactSheet,
+	"verify":         runVerify,
+	"diff":           runDiff,
+	"mnemonics":      runMnemonics,
+	"backs":          runBacks,
+	"clean":          runClean,
+	"poster":         runPoster,
 }
 
 func main() {
+	// The first Ctrl-C cancels ctx so work can stop cleanly; once that has
+	// happened a second one kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	fontPath := flag.String("font", "Stuff.ttf", "path to .ttf font file")
-	coloursPath := flag.String("colours", "colours.json", "path to colours.json")
-	outdir := flag.String("outdir", "elements", "output directory")
-	height := flag.Int("height", 600, "tile image height in px (width scales to aspect ratio)")
-	flag.Parse()
-
-	// Load sample image to know aspect ratio
-	file, err := os.Open("Untitled.png")
+	cmd, args := runTiles, os.Args[1:]
+	if len(os.Args) > 1 {
+		if c, ok := commands[os.Args[1]]; ok {
+			cmd, args = c, os.Args[2:]
+		}
+	}
+	err := cmd(ctx, args)
+	stop()
 	if err != nil {
-    	log.Fatal(err)
+		logger.Error("Error", "error", err)
+		os.Exit(1)
 	}
-	defer file.Close()
+}
 
-	ratio := float64(2456) / float64(1882)
-	tileH := *height
-	tileW := int(ratio * float64(tileH))
+func runTiles(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("tiles", flag.ExitOnError)
+	o := addFlags(fs)
+	fs.BoolVar(&o.resume, "resume", false, "skip cards already recorded in the output directory's manifest by an interrupted run")
+	fs.StringVar(&o.notifyURL, "notify-url", "", "POST a JSON report to this URL when generation finishes")
+	fs.StringVar(&o.cpuProfile, "cpuprofile", "", "write a CPU profile of the generation loop to this file")
+	fs.StringVar(&o.memProfile, "memprofile", "", "write a heap profile to this file once generation finishes")
+	fs.StringVar(&o.tracePath, "trace", "", "write a runtime execution trace of the generation loop to this file")
+	sample := fs.Int("sample-report", 0, "render only this many cards, chosen at random, into a review folder with a contact sheet and an estimate of the whole run's time and size")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
 
-	// Read colours.json
-	var colours Colours
-	bs, err := os.ReadFile(*coloursPath)
+	r, err := newRenderer(o)
 	if err != nil {
-		fmt.Println("Error reading colours.json:", err)
-		return
-	}
-	if err := json.Unmarshal(bs, &colours); err != nil {
-		fmt.Println("Error parsing colours.json:", err)
-		return
+		return err
 	}
 
-	// Load font faces of different sizes
-	numFont, _ := loadFont(*fontPath, float64(tileH)/numSize)   // ~large enough
-	symFont, _ := loadFont(*fontPath, float64(tileH)/symSize)    // biggest
-	nameFont, _ := loadFont(*fontPath, float64(tileH)/nameSize)  // medium
-	massFont, _ := loadFont(*fontPath, float64(tileH)/massSize)  // smallest
-
 	// Fetch element data
-	elements, err := fetchElements()
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	if err := r.CheckStrict(elements); err != nil {
+		return err
+	}
+	if *sample > 0 {
+		return sampleReport(ctx, r, elements, o, *sample)
+	}
+
+	st, err := openStore(o)
 	if err != nil {
-		fmt.Println("Error fetching elements:", err)
-		return
+		return err
 	}
 
-	os.MkdirAll(*outdir, 0755)
+	stopProfiling, err := startProfiling(o)
+	if err != nil {
+		return err
+	}
+	defer stopProfiling()
 
-	for _, e := range elements {
-		img := image.NewRGBA(image.Rect(0, 0, tileW, tileH))
-		draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	p, err := openProgress(o.progressPath)
+	if err != nil {
+		return err
+	}
+	defer p.Close()
 
-		border := hexToRGBA(colours[e.Type])
-		if _, ok := colours[e.Type]; !ok {
-			border = color.RGBA{0, 0, 0, 255}
+	s, err := generateTiles(ctx, r, st, elements, o, p)
+	if cerr := st.Close(); err == nil {
+		err = cerr
+	}
+	p.summary(s)
+	logger.Info("Summary", "summary", s)
+	if o.notifyURL != "" {
+		rerr := err
+		if rerr == nil && s.Cancelled {
+			rerr = errors.New("interrupted")
+		}
+		if nerr := notify(o.notifyURL, newReport("tiles", o, s, rerr)); nerr != nil {
+			logger.Warn("Notify failed", "error", nerr)
 		}
-		bt := tileH / 15 // border thickness proportional to height
-		// Draw borders
-		draw.Draw(img, image.Rect(0, 0, tileW, bt), &image.Uniform{border}, image.Point{}, draw.Src)
-		draw.Draw(img, image.Rect(0, tileH-bt, tileW, tileH), &image.Uniform{border}, image.Point{}, draw.Src)
-		draw.Draw(img, image.Rect(0, 0, bt, tileH), &image.Uniform{border}, image.Point{}, draw.Src)
-		draw.Draw(img, image.Rect(tileW-bt, 0, tileW, tileH), &image.Uniform{border}, image.Point{}, draw.Src)
-
-		// Padding
-		pad := tileH / 20
-
-		// Atomic Number (top-left)
-		numTxt := fmt.Sprintf("%d", e.Number)
-		drawText(img, numFont, bt+pad, bt+pad+int(numFont.Metrics().Height.Round()), numTxt, color.Black)
-
-		// Atomic Mass (top-right)
-		massTxt := fmt.Sprintf("%.4f", e.Mass)
-		mw := font.MeasureString(massFont, massTxt).Round()
-		drawText(img, massFont, tileW-bt-pad-mw, bt+pad+int(massFont.Metrics().Height.Round()), massTxt, color.Black)
-
-		// Symbol (center)
-		symW := font.MeasureString(symFont, e.Symbol).Round()
-		drawText(img, symFont, (tileW-symW)/2, tileH/2+int(symFont.Metrics().Height.Round())/4, e.Symbol, color.Black)
-
-		// Name (below symbol)
-		nameW := font.MeasureString(nameFont, e.Name).Round()
-		drawText(img, nameFont, (tileW-nameW)/2, tileH/2+int(symFont.Metrics().Height.Round())/4+int(nameFont.Metrics().Height.Round())+pad, e.Name, color.Black)
-
-		// Save PNG
-		fname := fmt.Sprintf("%03d_%s.png", e.Number, e.Symbol)
-		f, _ := os.Create(filepath.Join(*outdir, fname))
-		png.Encode(f, img)
-		f.Close()
-		fmt.Println("Written:", fname)
 	}
+	if err != nil {
+		return err
+	}
+	if s.Cancelled {
+		stopProfiling()
+		return errors.New("interrupted")
+	}
+	return stopProfiling()
 }
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"sort"
+)
+
+const (
+	manifestName  = "manifest.json"
+	checksumsName = "SHA256SUMS"
+)
+
+// manifestEntry records one generated file. Cards also record the element
+// they show.
+type manifestEntry struct {
+	Number int    `json:"number,omitempty"`
+	Symbol string `json:"symbol,omitempty"`
+	Path   string `json:"path"` // relative to the output directory
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// manifest lists everything generated into an output directory. It is kept
+// up to date as files are written, so an interrupted run can be resumed,
+// and a SHA256SUMS file in sha256sum's format is written beside it. Options
+// holds the flags the cards were rendered with; a run with different ones
+// cannot resume from it.
+type manifest struct {
+	Options    map[string]string     `json:"options,omitempty"`
+	Provenance map[string]provenance `json:"provenance,omitempty"` // of the data: "data", and "atomic_weights" with -atomic-weights
+	Entries    []manifestEntry       `json:"entries"`
+}
+
+// Flags that have no effect on the generated images.
+var nonRenderFlags = map[string]bool{
+	"outdir":        true,
+	"resume":        true,
+	"cpuprofile":    true,
+	"memprofile":    true,
+	"trace":         true,
+	"log-format":    true,
+	"progress":      true,
+	"notify-url":    true,
+	"sample-report": true,
+	"out-versioned": true,
+	"config":        true, // the flags it sets are recorded
+
+	// Other names for flags recorded under their own.
+	"colors": true,
+	"out":    true,
+}
+
+// renderSettings returns the value of every flag that affects the output.
+func renderSettings(fs *flag.FlagSet) map[string]string {
+	m := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if !nonRenderFlags[f.Name] {
+			m[f.Name] = f.Value.String()
+		}
+	})
+	return m
+}
+
+func loadManifest(st store) (*manifest, error) {
+	bs, err := fs.ReadFile(st, manifestName)
+	if err != nil {
+		return nil, err
+	}
+	var m manifest
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", manifestName, err)
+	}
+	return &m, nil
+}
+
+// save writes the manifest and checksum file.
+func (m *manifest) save(st store) error {
+	sort.Slice(m.Entries, func(i, j int) bool { return m.Entries[i].Path < m.Entries[j].Path })
+	bs, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = st.put(manifestName, func(w io.Writer) error {
+		_, err := w.Write(append(bs, '\n'))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return st.put(checksumsName, func(w io.Writer) error {
+		for _, e := range m.Entries {
+			if _, err := fmt.Fprintf(w, "%s  %s\n", e.SHA256, e.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sum returns the recorded hash of path, if any.
+func (m *manifest) sum(path string) string {
+	for _, e := range m.Entries {
+		if e.Path == path {
+			return e.SHA256
+		}
+	}
+	return ""
+}
+
+// record hashes a file that has just been written to st and adds or
+// replaces its entry. It reports whether the file differs from the one
+// recorded before.
+func (m *manifest) record(st store, e manifestEntry) (changed bool, err error) {
+	if e.SHA256, e.Bytes, err = st.sha256(e.Path); err != nil {
+		return false, err
+	}
+	for i := range m.Entries {
+		if m.Entries[i].Path == e.Path {
+			changed = m.Entries[i].SHA256 != e.SHA256
+			m.Entries[i] = e
+			return changed, nil
+		}
+	}
+	m.Entries = append(m.Entries, e)
+	return true, nil
+}
+
+// done reports whether a card is recorded and still stored unchanged.
+func (m *manifest) done(st store, number int) bool {
+	for _, e := range m.Entries {
+		if e.Number == number {
+			sum, _, err := st.sha256(e.Path)
+			return err == nil && sum == e.SHA256
+		}
+	}
+	return false
+}
+
+// openManifest loads the manifest already in st, or starts an empty one.
+func openManifest(st store) (*manifest, error) {
+	m, err := loadManifest(st)
+	if errors.Is(err, os.ErrNotExist) {
+		return &manifest{}, nil
+	}
+	return m, err
+}
+
+// resumeManifest loads the manifest left by an earlier run, which must have
+// used the same settings and data.
+func resumeManifest(st store, o *options) (*manifest, error) {
+	m, err := openManifest(st)
+	if err != nil {
+		return nil, err
+	}
+	if m.Options != nil && !maps.Equal(m.Options, o.settings) {
+		return nil, fmt.Errorf("cannot resume: %s in %s was written with different options", manifestName, st)
+	}
+	if m.Options != nil && !maps.Equal(m.Provenance, o.provenance) {
+		return nil, fmt.Errorf("cannot resume: %s in %s was written from different data", manifestName, st)
+	}
+	m.Options = o.settings
+	return m, nil
+}
+
+// saveAsset saves a single image generated by one of the chart modes and
+// records it in the output directory's manifest.
+func saveAsset(o *options, fname string, img image.Image) error {
+	return saveFile(o, fname, func(st store) error { return savePNG(st, fname, img, o) })
+}
+
+// saveFile saves a single file with save and records it in the output
+// directory's manifest.
+func saveFile(o *options, fname string, save func(st store) error) error {
+	return saveFiles(o, func(st store) ([]string, error) {
+		return []string{fname}, save(st)
+	})
+}
+
+// saveFiles is saveFile for a set of files whose names are only known once
+// save has written them.
+func saveFiles(o *options, save func(st store) ([]string, error)) error {
+	st, err := openStore(o)
+	if err != nil {
+		return err
+	}
+	fnames, err := save(st)
+	if err != nil {
+		return err
+	}
+	m, err := openManifest(st)
+	if err != nil {
+		return err
+	}
+	if o.provenance != nil {
+		if m.Provenance == nil {
+			m.Provenance = map[string]provenance{}
+		}
+		maps.Copy(m.Provenance, o.provenance)
+	}
+	for _, fname := range fnames {
+		if _, err := m.record(st, manifestEntry{Path: fname}); err != nil {
+			return err
+		}
+	}
+	if err := m.save(st); err != nil {
+		return err
+	}
+	if err := st.Close(); err != nil {
+		return err
+	}
+	for _, fname := range fnames {
+		logger.Info("Written", "path", fname)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"golang.org/x/image/font"
+
+	"periodic-table-tiles/render"
+)
+
+//go:embed data/mnemonics.json
+var mnemonicsJSON []byte
+
+// mnemonicKey is what a memory phrase is for: "group" or "period" and its
+// number, as written in a mnemonics file like "group 17".
+type mnemonicKey struct {
+	kind string
+	n    int
+}
+
+func (k mnemonicKey) String() string { return fmt.Sprintf("%s %d", k.kind, k.n) }
+
+// parseMnemonics reads a mnemonics file, a JSON object of phrases by group
+// or period, e.g. {"group 1": "Hi Little Naughty Kids, Rub Cats' Fur"}.
+func parseMnemonics(bs []byte) (map[mnemonicKey]string, error) {
+	var raw map[string]string
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return nil, err
+	}
+	phrases := map[mnemonicKey]string{}
+	for name, phrase := range raw {
+		var k mnemonicKey
+		fmt.Sscanf(strings.ToLower(strings.TrimSpace(name)), "%s %d", &k.kind, &k.n)
+		if !(k.kind == "group" && k.n >= 1 && k.n <= 18 || k.kind == "period" && k.n >= 1 && k.n <= 7) {
+			return nil, fmt.Errorf("%q isn't a group from 1 to 18 or a period from 1 to 7, such as \"group 17\"", name)
+		}
+		phrases[k] = phrase
+	}
+	return phrases, nil
+}
+
+// runMnemonics makes a poster for each memory phrase: the cards of its
+// group or period in a row, with the phrase wrapped underneath. The
+// bundled phrases are used unless -mnemonics adds to or replaces them.
+func runMnemonics(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("mnemonics", flag.ExitOnError)
+	o := addFlags(fs)
+	path := fs.String("mnemonics", "", "JSON file of your own phrases by group or period, e.g. {\"group 1\": \"Hi Little Naughty Kids, Rub Cats' Fur\"}, used before the bundled ones")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	phrases, err := parseMnemonics(mnemonicsJSON)
+	if err != nil {
+		panic("data/mnemonics.json: " + err.Error())
+	}
+	if *path != "" {
+		bs, err := os.ReadFile(*path)
+		if err != nil {
+			return err
+		}
+		own, err := parseMnemonics(bs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *path, err)
+		}
+		maps.Copy(phrases, own)
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	if err := r.CheckStrict(elements); err != nil {
+		return err
+	}
+	members := map[mnemonicKey][]Element{}
+	for _, e := range elements {
+		info := describeElement(e, "")
+		members[mnemonicKey{"group", info.Group}] = append(members[mnemonicKey{"group", info.Group}], e)
+		members[mnemonicKey{"period", info.Period}] = append(members[mnemonicKey{"period", info.Period}], e)
+	}
+
+	// Groups before periods, each in order.
+	keys := slices.SortedFunc(maps.Keys(phrases), func(a, b mnemonicKey) int {
+		return cmp.Or(cmp.Compare(a.kind, b.kind), cmp.Compare(a.n, b.n))
+	})
+	face, err := r.CardFont(float64(r.TileH) / 6)
+	if err != nil {
+		return err
+	}
+	return saveFiles(o, func(st store) ([]string, error) {
+		var files []string
+		for _, k := range keys {
+			if ctx.Err() != nil {
+				return files, fmt.Errorf("interrupted")
+			}
+			if len(members[k]) == 0 {
+				logger.Warn("No elements for mnemonic", "for", k.String())
+				continue
+			}
+			fname := fmt.Sprintf("mnemonic_%s_%d.png", k.kind, k.n)
+			title := strings.ToUpper(k.kind[:1]) + k.String()[1:]
+			if err := savePNG(st, fname, mnemonicPoster(r, face, title, phrases[k], members[k]), o); err != nil {
+				return files, err
+			}
+			files = append(files, fname)
+		}
+		return files, nil
+	})
+}
+
+// mnemonicPoster draws a title over the cards in a row and the phrase
+// below them, wrapped to the width of the row.
+func mnemonicPoster(r *render.Renderer, face font.Face, title, phrase string, elements []Element) *image.RGBA {
+	pad := r.TileH / 20
+	width := len(elements)*(r.TileW+pad) + pad
+	lines := render.WrapText(face, phrase, width-2*pad)
+	lineH := face.Metrics().Height.Round()
+	titleH := lineH + pad
+	img := image.NewRGBA(image.Rect(0, 0, width, titleH+r.TileH+pad+len(lines)*lineH+2*pad))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	render.DrawText(img, face, pad, pad+face.Metrics().Ascent.Round(), render.FitText(face, title, width-2*pad), color.Black)
+	for i, e := range elements {
+		tile := r.Tile(e)
+		at := image.Pt(pad+i*(r.TileW+pad), titleH)
+		draw.Draw(img, tile.Bounds().Add(at), tile, image.Point{}, draw.Over)
+	}
+	top := titleH + r.TileH + pad
+	render.NewTextBox(img, face, image.Rect(pad, top, width-pad, img.Rect.Max.Y-pad)).Paragraph(phrase)
+	return img
+}
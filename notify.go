@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// report is the JSON body posted to -notify-url when a run finishes.
+type report struct {
+	Mode     string            `json:"mode"`
+	Outdir   string            `json:"outdir"`
+	Options  map[string]string `json:"options"`
+	Summary  summary           `json:"summary"`
+	Seconds  float64           `json:"seconds"`
+	Error    string            `json:"error,omitempty"`
+	Job      string            `json:"job,omitempty"`    // serve mode's job ID
+	Result   string            `json:"result,omitempty"` // and where to download it
+	Jobs     int               `json:"jobs,omitempty"`   // batch mode's number of jobs
+	Failed   []string          `json:"failed,omitempty"` // and the names of those that failed
+	Finished time.Time         `json:"finished"`
+}
+
+func newReport(mode string, o *options, s summary, err error) report {
+	r := report{
+		Mode:     mode,
+		Outdir:   o.outdir,
+		Options:  o.settings,
+		Summary:  s,
+		Seconds:  s.Elapsed.Seconds(),
+		Finished: time.Now().UTC(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// notifyClient doesn't follow redirects, which could take a job's report
+// on to a host -notify-hosts doesn't allow.
+var notifyClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// notify posts rep as JSON to url. It has its own timeout rather than a
+// caller's context so that interrupted runs still get reported.
+func notify(url string, rep report) error {
+	body, err := json.Marshal(rep)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := notifyClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPIJSON describes the server's routes as an OpenAPI 3 document, for
+// generating clients; the client package is written against it, and its
+// tests fail if it misses an operation or schema field. Keep the two in
+// step with the handlers in server.go.
+//
+//go:embed data/openapi.json
+var openAPIJSON []byte
+
+// openAPIPath is where the server serves openAPIJSON.
+const openAPIPath = "/openapi.json"
+
+func handleOpenAPI(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIJSON)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"periodic-table-tiles/render"
+)
+
+// PNG modes accepted by -png-mode.
+const (
+	pngModeRGBA     = "rgba"
+	pngModePaletted = "paletted"
+)
+
+func checkPNGMode(mode string) error {
+	switch mode {
+	case pngModeRGBA, pngModePaletted:
+		return nil
+	}
+	return fmt.Errorf("unknown -png-mode %q (want %s or %s)", mode, pngModeRGBA, pngModePaletted)
+}
+
+// pngCompression maps -png-compression values to encoder levels.
+var pngCompression = map[string]png.CompressionLevel{
+	"default": png.DefaultCompression,
+	"none":    png.NoCompression,
+	"fast":    png.BestSpeed,
+	"best":    png.BestCompression,
+}
+
+func checkPNGCompression(level string) error {
+	if _, ok := pngCompression[level]; !ok {
+		return fmt.Errorf("unknown -png-compression %q (want default, none, fast or best)", level)
+	}
+	return nil
+}
+
+// encoderPool lets every image share the encoder's compression state rather
+// than allocating it afresh for each of the 118 cards.
+type encoderPool struct{ p sync.Pool }
+
+func (ep *encoderPool) Get() *png.EncoderBuffer {
+	b, _ := ep.p.Get().(*png.EncoderBuffer)
+	return b
+}
+
+func (ep *encoderPool) Put(b *png.EncoderBuffer) { ep.p.Put(b) }
+
+var pngBuffers = &encoderPool{}
+
+func encodePNG(w io.Writer, img image.Image, o *options) error {
+	switch o.ColourSpace {
+	case render.ColourSpaceCMYK:
+		return fmt.Errorf("PNGs can't be -colour-space %s; use table -format tiff for print", render.ColourSpaceCMYK)
+	case render.ColourSpaceDisplayP3:
+		img = toDisplayP3(img)
+	}
+	if o.pngMode == pngModePaletted {
+		img, _ = quantise(img, 256)
+	}
+	enc := png.Encoder{
+		CompressionLevel: pngCompression[o.pngCompression],
+		BufferPool:       pngBuffers,
+	}
+	var best []byte
+	if o.optimize {
+		var err error
+		if best, err = optimisePNG(img, enc); err != nil {
+			return err
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, img); err != nil {
+			return err
+		}
+		best = buf.Bytes()
+	}
+	if o.dpi > 0 {
+		best = withDPI(best, o.dpi)
+	}
+	_, err := w.Write(withICC(best, o.ColourSpace))
+	return err
+}
+
+// optimisePNG encodes img several lossless ways and returns the smallest:
+// as configured, with the best deflate level, and as a paletted image when
+// it has few enough colours for that to lose nothing.
+func optimisePNG(img image.Image, enc png.Encoder) ([]byte, error) {
+	type candidate struct {
+		enc png.Encoder
+		img image.Image
+	}
+	best := enc
+	best.CompressionLevel = png.BestCompression
+	candidates := []candidate{{enc, img}, {best, img}}
+	if _, ok := img.(*image.Paletted); !ok {
+		if pal, exact := quantise(img, 256); exact {
+			candidates = append(candidates, candidate{best, pal})
+		}
+	}
+
+	var smallest []byte
+	for _, c := range candidates {
+		var buf bytes.Buffer
+		if err := c.enc.Encode(&buf, c.img); err != nil {
+			return nil, err
+		}
+		if smallest == nil || buf.Len() < len(smallest) {
+			smallest = buf.Bytes()
+		}
+	}
+	return smallest, nil
+}
+
+// runOptimizer runs the -optimizer command on a written file. A "{}"
+// argument is replaced with the path; otherwise the path is appended.
+func runOptimizer(command, path string) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	replaced := false
+	for i, a := range args {
+		if a == "{}" {
+			args[i], replaced = path, true
+		}
+	}
+	if !replaced {
+		args = append(args, path)
+	}
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("optimizer %s: %w\n%s", args[0], err, out)
+	}
+	return nil
+}
+
+// atomicWrite writes a file through a temporary file beside path that is
+// renamed into place, so an interrupted or failed write never leaves a
+// truncated file behind.
+func atomicWrite(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// CreateTemp makes the file private; give it the usual permissions.
+	os.Chmod(tmp, 0644)
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func savePNG(st store, name string, img image.Image, o *options) error {
+	err := st.put(name, func(w io.Writer) error { return encodePNG(w, img, o) })
+	if err != nil || o.optimizer == "" {
+		return err
+	}
+	// openStore only allows -optimizer with a local directory.
+	return runOptimizer(o.optimizer, st.(dirStore).path(name))
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"periodic-table-tiles/render"
+)
+
+// TestOptimisePNGLossless checks that -optimize writes the same pixels as
+// the PNG written without it. Neither is quite the card, as PNGs aren't
+// premultiplied, so the edges of cards with transparent corners round.
+func TestOptimisePNGLossless(t *testing.T) {
+	decode := func(bs []byte) image.Image {
+		img, err := png.Decode(bytes.NewReader(bs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+	for _, shape := range []string{render.ShapeRect, render.ShapeCircle, render.ShapeHex} {
+		t.Run(shape, func(t *testing.T) {
+			card := testRenderer(t, "-shape", shape, "-height", "30").Tile(testHelium)
+			var plain bytes.Buffer
+			if err := png.Encode(&plain, card); err != nil {
+				t.Fatal(err)
+			}
+			bs, err := optimisePNG(card, png.Encoder{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			samePixels(t, decode(plain.Bytes()), decode(bs))
+		})
+	}
+}
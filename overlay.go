@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font"
+
+	"periodic-table-tiles/render"
+)
+
+// overlaySpec is a -overlay file of callouts drawn over the full table:
+//
+//	{"items": [
+//	  {"type": "box", "from": {"group": 3, "period": 4}, "to": {"group": 12, "period": 7}, "label": "Transition metals"},
+//	  {"type": "arrow", "from": {"x": 40, "y": 30}, "to": {"element": "Fe"}, "colour": "#d0021b"},
+//	  {"type": "circle", "at": {"element": "Au"}},
+//	  {"type": "label", "at": {"group": 8, "period": 1}, "text": "Metals start here"},
+//	  {"type": "region", "series": "halogens", "label": "Halogens", "style": "dashed"},
+//	  {"type": "image", "src": "crest.png", "from": {"group": 4, "period": 1}, "to": {"group": 11, "period": 3}, "opacity": 0.3, "blend": "multiply"}
+//	]}
+//
+// Places are a card, by element or by group and period (the lanthanides
+// and actinides are periods 9 and 10), or a pixel position. A region
+// outlines the cards of a series: alkali metals, alkaline earth metals,
+// transition metals, halogens, noble gases, lanthanides or actinides, or
+// any category or -groups group. An image, such as a watermark or photo,
+// is fitted inside the box between its from and to places. Any item can be
+// faded with opacity and combined with the table under it by a blend mode:
+// normal (the default), multiply, screen or overlay.
+type overlaySpec struct {
+	Items []overlayItem `json:"items"`
+}
+
+type overlayItem struct {
+	Type   string      `json:"type"` // box, arrow, circle, label, region or image
+	At     *overlayPos `json:"at"`   // circle and label
+	From   *overlayPos `json:"from"` // box, arrow and image
+	To     *overlayPos `json:"to"`
+	Series string      `json:"series"` // of a region
+	Label  string      `json:"label"`  // under a box, circle or region
+	Text   string      `json:"text"`   // of a label
+	Colour string      `json:"colour"` // stroke and text, #rrggbb or #rrggbbaa; black by default
+	Fill   string      `json:"fill"`   // inside a box, circle or region; none by default
+	Style  string      `json:"style"`  // of the outline: solid (the default), dashed or dotted
+	Width  float64     `json:"width"`  // stroke width in px
+	Size   float64     `json:"size"`   // text height in px
+	Radius float64     `json:"radius"` // of a circle in px
+	Src    string      `json:"src"`    // PNG or JPEG file of an image
+
+	Opacity *float64 `json:"opacity"` // from 0 to 1; 1 by default
+	Blend   string   `json:"blend"`
+}
+
+type overlayPos struct {
+	Element string   `json:"element"`
+	Group   int      `json:"group"`
+	Period  int      `json:"period"`
+	X       *float64 `json:"x"`
+	Y       *float64 `json:"y"`
+}
+
+func loadOverlay(path string) (*overlaySpec, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec overlaySpec
+	if err := json.Unmarshal(bs, &spec); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// parseOverlayColour reads #rrggbb or #rrggbbaa.
+func parseOverlayColour(s string, def color.NRGBA) (color.NRGBA, error) {
+	if s == "" {
+		return def, nil
+	}
+	h := strings.TrimPrefix(s, "#")
+	var c color.NRGBA
+	c.A = 255
+	var err error
+	switch len(h) {
+	case 6:
+		_, err = fmt.Sscanf(h, "%02x%02x%02x", &c.R, &c.G, &c.B)
+	case 8:
+		_, err = fmt.Sscanf(h, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A)
+	default:
+		err = fmt.Errorf("want #rrggbb or #rrggbbaa")
+	}
+	if err != nil {
+		return c, fmt.Errorf("colour %q: %v", s, err)
+	}
+	return c, nil
+}
+
+// overlayShape is an item resolved to pixels on the table.
+type overlayShape struct {
+	kind         string
+	rect         image.Rectangle  // box, and the bounds of a circle or region
+	polys        [][]render.Point // region outlines
+	x0, y0       float64          // arrow start, and the centre of a label or circle
+	x1, y1       float64          // arrow end
+	radius       float64
+	text         string // label text, or the caption of a box or circle
+	captionY     int    // baseline of the caption
+	stroke, fill color.NRGBA
+	hasFill      bool
+	width, size  float64
+	style        string
+	img          *image.RGBA // an image, scaled to fit rect
+	opacity      float64
+	blend        string
+}
+
+// resolveOverlay places every item of spec on the table.
+func resolveOverlay(spec *overlaySpec, r *render.Renderer, t tableLayout, elements []Element) ([]overlayShape, error) {
+	cell := func(p *overlayPos) (image.Rectangle, bool, error) {
+		if p == nil {
+			return image.Rectangle{}, false, fmt.Errorf("missing position")
+		}
+		if p.X != nil || p.Y != nil {
+			if p.X == nil || p.Y == nil {
+				return image.Rectangle{}, false, fmt.Errorf("a pixel position needs both x and y")
+			}
+			pt := image.Pt(int(*p.X), int(*p.Y))
+			return image.Rectangle{pt, pt}, false, nil
+		}
+		e := Element{XPos: p.Group, YPos: p.Period}
+		if p.Element != "" {
+			i := -1
+			for j, el := range elements {
+				if strings.EqualFold(el.Symbol, p.Element) {
+					i = j
+				}
+			}
+			if i < 0 {
+				return image.Rectangle{}, false, fmt.Errorf("unknown element %q", p.Element)
+			}
+			e = elements[i]
+		}
+		if e.XPos < 1 || e.YPos < 1 {
+			return image.Rectangle{}, false, fmt.Errorf("want an element, group and period, or x and y")
+		}
+		at := t.pos(e)
+		return image.Rectangle{at, at.Add(image.Pt(r.TileW, r.TileH))}, true, nil
+	}
+	centre := func(rc image.Rectangle) (float64, float64) {
+		return float64(rc.Min.X+rc.Max.X) / 2, float64(rc.Min.Y+rc.Max.Y) / 2
+	}
+
+	var shapes []overlayShape
+	for i, it := range spec.Items {
+		s := overlayShape{kind: it.Type, width: it.Width, size: it.Size, radius: it.Radius, style: it.Style, opacity: 1, blend: cmp.Or(it.Blend, blendNormal)}
+		if it.Opacity != nil {
+			s.opacity = *it.Opacity
+		}
+		if s.width <= 0 {
+			s.width = math.Max(1, float64(r.TileH)/20)
+		}
+		if s.size <= 0 {
+			s.size = float64(r.TileH) / 4
+		}
+		err := render.CheckStrokeStyle(it.Style)
+		if err == nil {
+			err = checkBlend(s.blend)
+		}
+		if err == nil && (s.opacity < 0 || s.opacity > 1) {
+			err = fmt.Errorf("opacity must be from 0 to 1")
+		}
+		if err == nil {
+			s.stroke, err = parseOverlayColour(it.Colour, color.NRGBA{0, 0, 0, 255})
+		}
+		if err == nil && it.Fill != "" {
+			s.fill, err = parseOverlayColour(it.Fill, color.NRGBA{})
+			s.hasFill = true
+		}
+
+		switch it.Type {
+		case "box":
+			var a, b image.Rectangle
+			if err == nil {
+				a, _, err = cell(it.From)
+			}
+			if err == nil {
+				b, _, err = cell(it.To)
+			}
+			s.rect = a.Union(b)
+			if s.rect.Empty() {
+				s.rect = image.Rectangle{a.Min, b.Max}.Canon()
+			}
+			s.rect = s.rect.Inset(-t.gap - int(s.width)/2)
+			s.text = it.Label
+		case "arrow":
+			var a, b image.Rectangle
+			var aCard, bCard bool
+			if err == nil {
+				a, aCard, err = cell(it.From)
+			}
+			if err == nil {
+				b, bCard, err = cell(it.To)
+			}
+			s.x0, s.y0 = centre(a)
+			s.x1, s.y1 = centre(b)
+			// Arrows touch the edge of a card rather than covering it.
+			if bCard {
+				s.x1, s.y1 = edgePoint(b, s.x0, s.y0)
+			}
+			if aCard {
+				s.x0, s.y0 = edgePoint(a, s.x1, s.y1)
+			}
+		case "circle":
+			var a image.Rectangle
+			if err == nil {
+				a, _, err = cell(it.At)
+			}
+			s.x0, s.y0 = centre(a)
+			if s.radius <= 0 {
+				s.radius = math.Hypot(float64(r.TileW), float64(r.TileH))/2 + s.width
+			}
+			ri := int(s.radius)
+			s.rect = image.Rect(int(s.x0)-ri, int(s.y0)-ri, int(s.x0)+ri, int(s.y0)+ri)
+			s.text = it.Label
+		case "label":
+			var a image.Rectangle
+			if err == nil {
+				a, _, err = cell(it.At)
+			}
+			s.x0, s.y0 = centre(a)
+			s.text = it.Text
+		case "region":
+			if t.layout != layoutGrid {
+				err = fmt.Errorf("regions need -layout %s", layoutGrid)
+			}
+			var cells map[image.Point]bool
+			if err == nil {
+				cells, err = seriesCells(it.Series, elements)
+			}
+			s.polys = regionOutlines(cells, t, float64(t.gap)/2+s.width/2)
+			for _, poly := range s.polys {
+				for _, p := range poly {
+					pt := image.Pt(int(p.X), int(p.Y))
+					s.rect = s.rect.Union(image.Rectangle{pt, pt.Add(image.Pt(1, 1))})
+				}
+			}
+			s.text = it.Label
+		case "image":
+			var a, b image.Rectangle
+			if err == nil {
+				a, _, err = cell(it.From)
+			}
+			if err == nil {
+				b, _, err = cell(it.To)
+			}
+			if err == nil {
+				box := a.Union(b)
+				if box.Empty() {
+					box = image.Rectangle{a.Min, b.Max}.Canon()
+				}
+				s.img, s.rect, err = render.LoadImageFitted(it.Src, box)
+			}
+		default:
+			err = fmt.Errorf("unknown type %q (want box, arrow, circle, label, region or image)", it.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("overlay item %d: %w", i+1, err)
+		}
+		// Captions go under their shape, or over it at the bottom of the
+		// table.
+		s.captionY = s.rect.Max.Y + int(s.width+s.size)
+		if s.captionY > t.H {
+			s.captionY = s.rect.Min.Y - int(s.width+s.size/3)
+		}
+		shapes = append(shapes, s)
+	}
+	return shapes, nil
+}
+
+// edgePoint returns where the line from (x, y) to the centre of rc crosses
+// its edge.
+func edgePoint(rc image.Rectangle, x, y float64) (float64, float64) {
+	cx, cy := float64(rc.Min.X+rc.Max.X)/2, float64(rc.Min.Y+rc.Max.Y)/2
+	dx, dy := x-cx, y-cy
+	if dx == 0 && dy == 0 {
+		return cx, cy
+	}
+	hw, hh := float64(rc.Dx())/2, float64(rc.Dy())/2
+	s := math.Min(hw/math.Abs(dx), hh/math.Abs(dy))
+	return cx + dx*s, cy + dy*s
+}
+
+// arrowHead returns the triangle at the end of an arrow.
+func (s overlayShape) arrowHead() []render.Point {
+	l := math.Hypot(s.x1-s.x0, s.y1-s.y0)
+	if l == 0 {
+		return nil
+	}
+	ux, uy := (s.x1-s.x0)/l, (s.y1-s.y0)/l
+	hl, hw := s.width*4, s.width*2.5
+	bx, by := s.x1-ux*hl, s.y1-uy*hl
+	return []render.Point{{X: s.x1, Y: s.y1}, {X: bx - uy*hw, Y: by + ux*hw}, {X: bx + uy*hw, Y: by - ux*hw}}
+}
+
+// drawOverlay paints the shapes onto a rendered table.
+func drawOverlay(img *image.RGBA, fontPath string, shapes []overlayShape) error {
+	faces := map[float64]font.Face{}
+	text := func(img *image.RGBA, s overlayShape, cx float64, baseline int, txt string) error {
+		face, ok := faces[s.size]
+		if !ok {
+			var err error
+			if face, err = loadFont(fontPath, s.size); err != nil {
+				return err
+			}
+			faces[s.size] = face
+		}
+		// Kept inside the table, for captions of shapes at its edges.
+		w := render.MeasureText(face, txt).Round()
+		x := min(max(0, int(cx)-w/2), img.Bounds().Dx()-w)
+		render.DrawText(img, face, x, baseline, txt, s.stroke)
+		return nil
+	}
+	for _, s := range shapes {
+		// Faded or blended shapes are drawn on a layer of their own first.
+		dst := img
+		if s.opacity < 1 || s.blend != blendNormal {
+			dst = image.NewRGBA(img.Bounds())
+		}
+		if err := drawOverlayShape(dst, s, text); err != nil {
+			return err
+		}
+		if dst != img {
+			blendOnto(img, dst, image.Point{}, s.opacity, s.blend)
+		}
+	}
+	return nil
+}
+
+// drawOverlayShape paints one shape, writing its text with text.
+func drawOverlayShape(img *image.RGBA, s overlayShape, text func(img *image.RGBA, s overlayShape, cx float64, baseline int, txt string) error) error {
+	switch s.kind {
+	case "box":
+		rc := s.rect
+		pts := []render.Point{
+			{X: float64(rc.Min.X), Y: float64(rc.Min.Y)}, {X: float64(rc.Max.X), Y: float64(rc.Min.Y)},
+			{X: float64(rc.Max.X), Y: float64(rc.Max.Y)}, {X: float64(rc.Min.X), Y: float64(rc.Max.Y)},
+		}
+		if s.hasFill {
+			render.FillPolygon(img, pts, s.fill)
+		}
+		render.StrokeStyled(img, pts, s.width, s.stroke, s.style)
+	case "circle":
+		pts := render.Circle(s.rect.Dx(), s.rect.Dy(), 0)
+		for i := range pts {
+			pts[i].X += float64(s.rect.Min.X)
+			pts[i].Y += float64(s.rect.Min.Y)
+		}
+		if s.hasFill {
+			render.FillPolygon(img, pts, s.fill)
+		}
+		render.StrokeStyled(img, pts, s.width, s.stroke, s.style)
+	case "region":
+		for _, pts := range s.polys {
+			if s.hasFill {
+				render.FillPolygon(img, pts, s.fill)
+			}
+			render.StrokeStyled(img, pts, s.width, s.stroke, s.style)
+		}
+	case "arrow":
+		head := s.arrowHead()
+		if head == nil {
+			return nil
+		}
+		// The shaft stops inside the head so its end doesn't poke out.
+		mx, my := (head[1].X+head[2].X)/2, (head[1].Y+head[2].Y)/2
+		render.DrawLine(img, s.x0, s.y0, mx, my, s.width, s.stroke)
+		render.FillPolygon(img, head, s.stroke)
+	case "image":
+		draw.Draw(img, s.rect, s.img, s.img.Bounds().Min, draw.Over)
+	case "label":
+		if err := text(img, s, s.x0, int(s.y0+s.size/3), s.text); err != nil {
+			return err
+		}
+	}
+	if s.text != "" && s.kind != "label" {
+		if err := text(img, s, float64(s.rect.Min.X+s.rect.Max.X)/2, s.captionY, s.text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeOverlaySVG writes the shapes as SVG elements.
+func writeOverlaySVG(b *bytes.Buffer, shapes []overlayShape) {
+	rgba := func(c color.NRGBA) string {
+		return fmt.Sprintf("rgba(%d,%d,%d,%.3f)", c.R, c.G, c.B, float64(c.A)/255)
+	}
+	for _, s := range shapes {
+		fill := "none"
+		if s.hasFill {
+			fill = rgba(s.fill)
+		}
+		stroke := fmt.Sprintf(`stroke="%s" stroke-width="%.1f" stroke-linejoin="round"`, rgba(s.stroke), s.width)
+		if s.kind != "arrow" {
+			stroke += render.SVGDash(s.style, s.width)
+		}
+		faded := s.opacity < 1 || s.blend != blendNormal
+		if faded {
+			fmt.Fprintf(b, `<g opacity="%.3f" style="mix-blend-mode:%s">`, s.opacity, s.blend)
+		}
+		switch s.kind {
+		case "box":
+			fmt.Fprintf(b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" %s/>`+"\n", s.rect.Min.X, s.rect.Min.Y, s.rect.Dx(), s.rect.Dy(), fill, stroke)
+		case "circle":
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s" %s/>`+"\n", s.x0, s.y0, s.radius, fill, stroke)
+		case "region":
+			for _, pts := range s.polys {
+				b.WriteString(`<polygon points="`)
+				for _, p := range pts {
+					fmt.Fprintf(b, "%.1f,%.1f ", p.X, p.Y)
+				}
+				fmt.Fprintf(b, `" fill="%s" %s/>`+"\n", fill, stroke)
+			}
+		case "arrow":
+			head := s.arrowHead()
+			if head == nil {
+				break
+			}
+			mx, my := (head[1].X+head[2].X)/2, (head[1].Y+head[2].Y)/2
+			fmt.Fprintf(b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" %s/>`, s.x0, s.y0, mx, my, stroke)
+			fmt.Fprintf(b, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s"/>`+"\n", head[0].X, head[0].Y, head[1].X, head[1].Y, head[2].X, head[2].Y, rgba(s.stroke))
+		case "image":
+			// Embedded, so the SVG still shows it when moved. Encoding to
+			// memory can't fail.
+			var data bytes.Buffer
+			png.Encode(&data, s.img)
+			fmt.Fprintf(b, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`+"\n",
+				s.rect.Min.X, s.rect.Min.Y, s.rect.Dx(), s.rect.Dy(), base64.StdEncoding.EncodeToString(data.Bytes()))
+		case "label":
+			fmt.Fprintf(b, `<text x="%.1f" y="%.1f" font-size="%.1f" text-anchor="middle" fill="%s">%s</text>`+"\n", s.x0, s.y0+s.size/3, s.size, rgba(s.stroke), html.EscapeString(s.text))
+		}
+		if s.text != "" && s.kind != "label" {
+			fmt.Fprintf(b, `<text x="%d" y="%d" font-size="%.1f" text-anchor="middle" fill="%s">%s</text>`+"\n",
+				(s.rect.Min.X+s.rect.Max.X)/2, s.captionY, s.size, rgba(s.stroke), html.EscapeString(s.text))
+		}
+		if faded {
+			b.WriteString("</g>\n")
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"periodic-table-tiles/render"
+)
+
+// parseHueRange parses a -hues range such as "180-300". The end may be
+// past 360 to wrap through red.
+func parseHueRange(s string) (from, to float64, err error) {
+	a, b, ok := strings.Cut(s, "-")
+	if ok {
+		from, err = strconv.ParseFloat(strings.TrimSpace(a), 64)
+		if err == nil {
+			to, err = strconv.ParseFloat(strings.TrimSpace(b), 64)
+		}
+	}
+	if !ok || err != nil || to <= from || to-from > 360 {
+		return 0, 0, fmt.Errorf("bad -hues %q (want from-to in degrees, e.g. 180-300)", s)
+	}
+	return from, to, nil
+}
+
+// writeColours writes colours as a colours.json file, or to stdout for "-".
+func writeColours(path string, colours render.Colours) error {
+	bs, err := json.MarshalIndent(colours, "", "  ")
+	if err != nil {
+		return err
+	}
+	bs = append(bs, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(bs)
+		return err
+	}
+	if err := os.WriteFile(path, bs, 0644); err != nil {
+		return err
+	}
+	logger.Info("Written", "path", path)
+	return nil
+}
+
+func runPalette(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: palette generate|duotone|from-image [flags] ...")
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("palette "+sub, flag.ExitOnError)
+	out := fs.String("out", "colours.json", "colours file to write (\"-\" for stdout)")
+	switch sub {
+	case "generate":
+		hues := fs.String("hues", "0-360", "range of hues in degrees to spread the categories over")
+		sat := fs.Float64("saturation", render.DefaultPalette.Saturation, "saturation from 0 to 1")
+		light := fs.Float64("lightness", render.DefaultPalette.Lightness, "lightness from 0 to 1")
+		seed := fs.Uint64("seed", render.DefaultPalette.Seed, "seed for the order of the hues; try others for other palettes")
+		fs.Parse(args[1:])
+		from, to, err := parseHueRange(*hues)
+		if err != nil {
+			return err
+		}
+		if *sat < 0 || *sat > 1 || *light < 0 || *light > 1 {
+			return fmt.Errorf("-saturation and -lightness must be between 0 and 1")
+		}
+		return writeColours(*out, render.GeneratePalette(render.PaletteConfig{HueFrom: from, HueTo: to, Saturation: *sat, Lightness: *light, Seed: *seed}))
+	case "duotone":
+		from := fs.String("from", "", "brand colour of the first categories, as #rrggbb")
+		to := fs.String("to", "", "brand colour of the last categories, as #rrggbb")
+		fs.Parse(args[1:])
+		a, err := parseBrandColour("-from", *from)
+		if err != nil {
+			return err
+		}
+		b, err := parseBrandColour("-to", *to)
+		if err != nil {
+			return err
+		}
+		return writeColours(*out, duotonePalette(a, b))
+	case "from-image":
+		ref := fs.String("colours", "colours.json", "colours to match: each category gets the extracted colour nearest its colour here")
+		fs.Parse(args[1:])
+		if fs.NArg() != 1 {
+			return fmt.Errorf("usage: palette from-image [flags] poster.png")
+		}
+		reference, err := render.LoadColours(*ref)
+		if os.IsNotExist(err) {
+			reference, err = render.GeneratePalette(render.DefaultPalette), nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading colours: %w", err)
+		}
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		img, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", fs.Arg(0), err)
+		}
+		return writeColours(*out, matchPalette(reference, dominantColours(img, len(categories)-1)))
+	}
+	return fmt.Errorf("unknown palette command %q", sub)
+}
+
+// dominantColours returns up to n of the most common colours in img, most
+// common first. Near-greys, which are usually the background and text, are
+// left out, as are colours too close to one already chosen.
+func dominantColours(img image.Image, n int) []color.RGBA {
+	// A few hundred thousand pixels is plenty to find the main colours.
+	b := img.Bounds()
+	step := max(1, int(math.Sqrt(float64(b.Dx()*b.Dy())/250000)))
+	var pix []uint8
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			if c.A == 255 && !greyish(c) {
+				pix = append(pix, c.R, c.G, c.B, c.A)
+			}
+		}
+	}
+	if len(pix) == 0 {
+		return nil
+	}
+	sample := &image.RGBA{Pix: pix, Stride: len(pix), Rect: image.Rect(0, 0, len(pix)/4, 1)}
+
+	q, _ := quantise(sample, 48)
+	counts := make([]int, len(q.Palette))
+	for _, i := range q.Pix {
+		counts[i]++
+	}
+	idx := make([]int, len(q.Palette))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return counts[idx[a]] > counts[idx[b]] })
+
+	var out []color.RGBA
+	for _, i := range idx {
+		c := color.RGBAModel.Convert(q.Palette[i]).(color.RGBA)
+		if counts[i] == 0 || slices.ContainsFunc(out, func(o color.RGBA) bool { return colourDistance(o, c) < 48 }) {
+			continue
+		}
+		out = append(out, c)
+		if len(out) == n {
+			break
+		}
+	}
+	return out
+}
+
+func greyish(c color.RGBA) bool { return int(max(c.R, c.G, c.B))-int(min(c.R, c.G, c.B)) < 24 }
+
+func colourDistance(a, b color.RGBA) float64 {
+	dr, dg, db := float64(a.R)-float64(b.R), float64(a.G)-float64(b.G), float64(a.B)-float64(b.B)
+	return math.Sqrt(dr*dr + dg*dg + db*db)
+}
+
+// matchPalette gives each category the extracted colour closest to its
+// colour in reference, closest pairs first, so a poster's blue goes to the
+// category that was already blue. Categories left over when there are too
+// few colours keep their reference colour.
+func matchPalette(reference render.Colours, extracted []color.RGBA) render.Colours {
+	type pair struct {
+		category string
+		colour   int
+		d        float64
+	}
+	var pairs []pair
+	for _, category := range categories {
+		ref, ok := reference[category]
+		if !ok || category == "unknown" {
+			continue
+		}
+		for i, c := range extracted {
+			pairs = append(pairs, pair{category, i, colourDistance(render.HexToRGBA(ref), c)})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].d < pairs[j].d })
+
+	colours := maps.Clone(reference)
+	used := map[int]bool{}
+	done := map[string]bool{}
+	for _, p := range pairs {
+		if used[p.colour] || done[p.category] {
+			continue
+		}
+		used[p.colour], done[p.category] = true, true
+		colours[p.category] = render.RGBHex(extracted[p.colour])
+	}
+	if len(done) < len(categories)-1 {
+		logger.Warn("Not enough colours in the image, some categories keep theirs", "found", len(extracted))
+	}
+	return colours
+}
+
+// duotonePalette gives the categories shades blended from one brand colour
+// to another, in the usual order of categories from the alkali metals to
+// the actinides. The blend is in linear light, so the shades in between
+// don't go muddy, and neighbouring categories alternate a little lighter
+// to keep them apart. "unknown" is a grey as light as the blend's middle.
+func duotonePalette(from, to color.RGBA) render.Colours {
+	named := categories[:len(categories)-1] // all but "unknown"
+	mix := func(a, b uint8, t float64) uint8 { return render.Unlinear(render.Linear(a)*(1-t) + render.Linear(b)*t) }
+	colours := render.Colours{}
+	for i, category := range named {
+		t := float64(i) / float64(len(named)-1)
+		c := color.RGBA{mix(from.R, to.R, t), mix(from.G, to.G, t), mix(from.B, to.B, t), 255}
+		if i%2 == 1 {
+			h, s, l := render.RGBToHSL(c)
+			c = render.HSLToRGB(h, s, min(1, l+0.08))
+		}
+		colours[category] = render.RGBHex(c)
+	}
+	colours["unknown"] = render.RGBHex(render.Grey((render.Lightness(from) + render.Lightness(to)) / 2))
+	return colours
+}
+
+// parseBrandColour reads a palette duotone colour, #rrggbb.
+func parseBrandColour(flag, s string) (color.RGBA, error) {
+	c := color.RGBA{A: 255}
+	h, _ := strings.CutPrefix(s, "#")
+	if len(h) != 6 {
+		return c, fmt.Errorf("palette duotone needs %s as #rrggbb, not %q", flag, s)
+	}
+	if _, err := fmt.Sscanf(h, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("palette duotone %s %q: %v", flag, s, err)
+	}
+	return c, nil
+}
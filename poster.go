@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+
+	"periodic-table-tiles/render"
+)
+
+// posterHeight is the height of a poster when -height isn't given: A4 at
+// 300 dpi. Posters are in the proportions of the A sizes, 1 to √2.
+const posterHeight = 3508
+
+// The greys the poster's infographics are drawn in, so they stay quieter
+// than the symbol.
+var (
+	posterInk   = color.RGBA{0x33, 0x33, 0x33, 0xff} // values
+	posterMuted = color.RGBA{0x88, 0x88, 0x88, 0xff} // headings and labels
+	posterRule  = color.RGBA{0xcc, 0xcc, 0xcc, 0xff} // rules and shells
+)
+
+// runPoster draws one element as an art print: its symbol as large as the
+// page allows, with its name and mass under it and small diagrams of its
+// shells, states of matter and properties, and its emission spectrum,
+// along the bottom.
+func runPoster(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("poster", flag.ExitOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: poster [flags] Fe")
+	}
+	given := false
+	fs.Visit(func(f *flag.Flag) { given = given || f.Name == "height" })
+	if !given {
+		o.Height = posterHeight
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	i := indexElement(elements, fs.Arg(0))
+	if i < 0 {
+		return fmt.Errorf("unknown element %q", fs.Arg(0))
+	}
+	w := o.Width
+	if w == 0 {
+		w = int(math.Round(float64(o.Height) / math.Sqrt2))
+	}
+	img, err := drawPoster(r, elements[i], w, o.Height)
+	if err != nil {
+		return err
+	}
+	return saveAsset(o, fmt.Sprintf("poster_%s.png", elements[i].Symbol), img)
+}
+
+// drawPoster draws an element's poster w×h px.
+func drawPoster(r *render.Renderer, e Element, w, h int) (*image.RGBA, error) {
+	accent := r.CategoryColour(e.Type)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	// A wash of the category's colour, faint enough to print on.
+	wash := color.RGBA{mixByte(accent.R, 255, 0.93), mixByte(accent.G, 255, 0.93), mixByte(accent.B, 255, 0.93), 255}
+	draw.Draw(img, img.Bounds(), image.NewUniform(wash), image.Point{}, draw.Src)
+
+	m := w / 12
+	inner := image.Rect(m, m, w-m, h-m)
+	faces := map[float64]font.Face{}
+	face := func(size float64) (font.Face, error) {
+		if f, ok := faces[size]; ok {
+			return f, nil
+		}
+		f, err := r.CardFont(size)
+		faces[size] = f
+		return f, err
+	}
+	small, err := face(float64(h) / 70)
+	if err != nil {
+		return nil, fmt.Errorf("loading font: %w", err)
+	}
+
+	// The number and category across the top, over a rule.
+	head, err := face(float64(h) / 28)
+	if err != nil {
+		return nil, err
+	}
+	y := inner.Min.Y + head.Metrics().Ascent.Round()
+	render.DrawText(img, head, inner.Min.X, y, fmt.Sprint(e.Number), accent)
+	cat := strings.ToUpper(r.Category(e.Type))
+	render.DrawText(img, small, inner.Max.X-render.MeasureText(small, cat).Round(), y, cat, posterMuted)
+	rule := max(1, h/600)
+	y += head.Metrics().Descent.Round() + m/4
+	draw.Draw(img, image.Rect(inner.Min.X, y, inner.Max.X, y+rule), image.NewUniform(accent), image.Point{}, draw.Src)
+	symTop := y + rule
+
+	// The name and mass under the symbol, which fills the space between.
+	name, err := face(float64(h) / 18)
+	if err != nil {
+		return nil, err
+	}
+	nameY := h * 55 / 100
+	drawPosterCentred(img, name, w, nameY, render.FitText(name, e.Name, inner.Dx()), color.Black)
+	mass := "Atomic mass " + r.FormatMass(e)
+	if unit := r.MassUnit(); unit != "" {
+		mass += " " + unit
+	}
+	if cfg := describeElement(e, "").Configuration; cfg != "" {
+		mass += "   ·   " + cfg
+	}
+	massY := nameY + name.Metrics().Descent.Round() + small.Metrics().Height.Round()*3/2
+	drawPosterCentred(img, small, w, massY, render.FitText(small, mass, inner.Dx()), posterMuted)
+	if err := drawPosterSymbol(img, e.Symbol, accent, image.Rect(inner.Min.X, symTop, inner.Max.X, nameY-name.Metrics().Ascent.Round()), face); err != nil {
+		return nil, err
+	}
+
+	// Three panels of infographics, then the spectrum.
+	gap := m / 2
+	panelTop := massY + m
+	stripH := h / 28
+	stripTop := inner.Max.Y - stripH - small.Metrics().Height.Round()*2
+	panelH := stripTop - small.Metrics().Height.Round()*2 - panelTop
+	panelW := (inner.Dx() - 2*gap) / 3
+	panel := func(i int) image.Rectangle {
+		x := inner.Min.X + i*(panelW+gap)
+		return image.Rect(x, panelTop, x+panelW, panelTop+panelH)
+	}
+	drawPosterShells(img, small, panel(0), render.ShellOccupancy(e), accent)
+	drawPosterPhases(img, small, panel(1), e)
+	drawPosterFacts(img, small, panel(2), posterFacts(e))
+
+	if lines, ok := r.Spectra[e.Symbol]; ok {
+		drawPosterSpectrum(img, small, image.Rect(inner.Min.X, stripTop, inner.Max.X, stripTop+stripH), lines)
+	} else {
+		logger.Warn("No spectrum for element, see spectra fetch", "element", e.Symbol, "path", r.Options.SpectraPath)
+	}
+	return img, nil
+}
+
+// drawPosterSymbol draws the symbol as large as fits in rect, leaving a
+// little room either side, centred on its ink rather than its advance so it
+// sits in the middle of the page.
+func drawPosterSymbol(img *image.RGBA, sym string, c color.Color, rect image.Rectangle, face func(float64) (font.Face, error)) error {
+	size := float64(rect.Dy()) * 1.3
+	f, err := face(size)
+	if err != nil {
+		return err
+	}
+	b, _ := render.BoundText(f, sym)
+	iw, ih := (b.Max.X - b.Min.X).Ceil(), (b.Max.Y - b.Min.Y).Ceil()
+	if scale := min(float64(rect.Dx())*0.9/float64(iw), float64(rect.Dy())/float64(ih)); scale < 1 {
+		if f, err = face(math.Floor(size * scale)); err != nil {
+			return err
+		}
+		b, _ = render.BoundText(f, sym)
+		iw, ih = (b.Max.X - b.Min.X).Ceil(), (b.Max.Y - b.Min.Y).Ceil()
+	}
+	x := rect.Min.X + (rect.Dx()-iw)/2 - b.Min.X.Floor()
+	y := rect.Min.Y + (rect.Dy()-ih)/2 - b.Min.Y.Floor()
+	render.DrawText(img, f, x, y, sym, c)
+	return nil
+}
+
+// drawPosterCentred draws text centred across the first width px of img.
+func drawPosterCentred(img *image.RGBA, face font.Face, width, y int, txt string, c color.Color) {
+	render.DrawText(img, face, (width-render.MeasureText(face, txt).Round())/2, y, txt, c)
+}
+
+// posterPanel writes a panel's heading and returns the space below it.
+func posterPanel(img *image.RGBA, face font.Face, rect image.Rectangle, heading string) image.Rectangle {
+	lh := face.Metrics().Height.Round()
+	render.DrawText(img, face, rect.Min.X, rect.Min.Y+face.Metrics().Ascent.Round(), strings.ToUpper(heading), posterMuted)
+	return image.Rect(rect.Min.X, rect.Min.Y+lh*2, rect.Max.X, rect.Max.Y)
+}
+
+// drawPosterShells draws a Bohr diagram of the shells, a ring for each
+// with its electrons spaced round it, and their occupancy under it. The
+// diagram is drawn on its own, as fillPolygon rasterises the whole image
+// it is given for every dot.
+func drawPosterShells(img *image.RGBA, face font.Face, rect image.Rectangle, shells []int, c color.Color) {
+	rect = posterPanel(img, face, rect, "Electron shells")
+	lh := face.Metrics().Height.Round()
+	size := min(rect.Dx(), rect.Dy()-lh*2)
+	if size <= 0 || len(shells) == 0 {
+		return
+	}
+	diagram := image.NewRGBA(image.Rect(0, 0, size, size))
+	cx, cy := float64(size)/2, float64(size)/2
+	outer := float64(size) / 2
+	nucleus := outer / 7
+	step := (outer - nucleus) / float64(len(shells))
+	dot := min(step/5, outer/30)
+	for i, n := range shells {
+		radius := nucleus + step*float64(i+1) - dot
+		dot = min(dot, math.Pi*radius/float64(max(n, 1))*0.4)
+	}
+	line := max(1, outer/120)
+	render.FillCircle(diagram, cx, cy, nucleus, c)
+	for i, n := range shells {
+		radius := nucleus + step*float64(i+1) - dot
+		render.StrokePolygon(diagram, circlePoints(cx, cy, radius, 96), line, posterRule)
+		for j := range n {
+			a := 2*math.Pi*float64(j)/float64(n) - math.Pi/2
+			render.FillCircle(diagram, cx+radius*math.Cos(a), cy+radius*math.Sin(a), dot, c)
+		}
+	}
+	at := image.Pt(rect.Min.X+(rect.Dx()-size)/2, rect.Min.Y)
+	draw.Draw(img, diagram.Bounds().Add(at), diagram, image.Point{}, draw.Over)
+	txt := render.FitText(face, render.FormatShells(shells), rect.Dx())
+	render.DrawText(img, face, rect.Min.X+(rect.Dx()-render.MeasureText(face, txt).Round())/2, rect.Min.Y+size+lh*3/2, txt, posterInk)
+}
+
+// circlePoints returns n points round a circle, for strokePolygon.
+func circlePoints(cx, cy, radius float64, n int) []render.Point {
+	pts := make([]render.Point, n)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / float64(n)
+		pts[i] = render.Point{X: cx + radius*math.Cos(a), Y: cy + radius*math.Sin(a)}
+	}
+	return pts
+}
+
+// drawPosterPhases draws the element's phase bar, with where it melts and
+// boils and its state at room temperature, which the bar's tick marks.
+func drawPosterPhases(img *image.RGBA, face font.Face, rect image.Rectangle, e Element) {
+	rect = posterPanel(img, face, rect, "States of matter")
+	lh := face.Metrics().Height.Round()
+	render.DrawPhaseBar(img, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+lh*2), e)
+	rows := [][2]string{}
+	if e.Melt > 0 {
+		rows = append(rows, [2]string{"Melts", fmt.Sprintf("%g K", e.Melt)})
+	}
+	if e.Boil > 0 {
+		rows = append(rows, [2]string{"Boils", fmt.Sprintf("%g K", e.Boil)})
+	}
+	state := "Unknown"
+	if phase := phaseAt(e, render.RoomTemperature); phase != render.PhaseUnknown {
+		state = strings.ToUpper(phase[:1]) + phase[1:]
+	}
+	rows = append(rows, [2]string{"At 20 °C", state})
+	drawPosterFacts(img, face, image.Rect(rect.Min.X, rect.Min.Y+lh*3, rect.Max.X, rect.Max.Y), rows)
+}
+
+// posterFacts are the properties listed on a poster, leaving out what the
+// element data doesn't have.
+func posterFacts(e Element) [][2]string {
+	info := describeElement(e, "")
+	var rows [][2]string
+	add := func(label, value string, ok bool) {
+		if ok {
+			rows = append(rows, [2]string{label, value})
+		}
+	}
+	add("Period", fmt.Sprint(info.Period), info.Period > 0)
+	add("Group", fmt.Sprint(info.Group), info.Group > 0)
+	add("Block", info.Block, info.Block != "")
+	density := "g/cm³"
+	if e.Phase == "Gas" {
+		density = "g/L"
+	}
+	add("Density", fmt.Sprintf("%g %s", e.Density, density), e.Density > 0)
+	add("Electronegativity", fmt.Sprintf("%g", e.Electronegativity), e.Electronegativity > 0)
+	year, ok := render.DiscoveryYears[e.Symbol]
+	add("Discovered", render.DiscoveryLabel(year), ok)
+	return rows
+}
+
+// drawPosterFacts lists labels in grey with their values right-aligned,
+// as many as fit in rect.
+func drawPosterFacts(img *image.RGBA, face font.Face, rect image.Rectangle, rows [][2]string) {
+	m := face.Metrics()
+	y := rect.Min.Y + m.Ascent.Round()
+	for _, row := range rows {
+		if y+m.Descent.Round() > rect.Max.Y {
+			return
+		}
+		render.DrawText(img, face, rect.Min.X, y, row[0], posterMuted)
+		v := render.FitText(face, row[1], rect.Dx()-render.MeasureText(face, row[0]+"  ").Round())
+		render.DrawText(img, face, rect.Max.X-render.MeasureText(face, v).Round(), y, v, posterInk)
+		y += m.Height.Round()
+	}
+}
+
+// drawPosterSpectrum draws the emission spectrum across rect, with its
+// heading above and the wavelength every 100 nm below.
+func drawPosterSpectrum(img *image.RGBA, face font.Face, rect image.Rectangle, lines []render.SpectralLine) {
+	lh := face.Metrics().Height.Round()
+	render.DrawText(img, face, rect.Min.X, rect.Min.Y-lh/2, "EMISSION SPECTRUM", posterMuted)
+	render.DrawSpectrum(img, rect, lines)
+	for wl := 400.0; wl <= 700; wl += 100 {
+		x := rect.Min.X + int((wl-render.MinVisible)/(render.MaxVisible-render.MinVisible)*float64(rect.Dx()))
+		draw.Draw(img, image.Rect(x, rect.Max.Y, x+max(1, lh/12), rect.Max.Y+lh/3), image.NewUniform(posterMuted), image.Point{}, draw.Src)
+		label := fmt.Sprintf("%g nm", wl)
+		render.DrawText(img, face, x-render.MeasureText(face, label).Round()/2, rect.Max.Y+lh*4/3, label, posterMuted)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"sort"
+)
+
+type colourCount struct {
+	c [4]uint8
+	n int
+}
+
+// quantise converts img to a paletted image of at most n colours. Cards that
+// already use n colours or fewer are converted losslessly, reported by
+// exact; otherwise the palette is chosen by median cut.
+func quantise(img image.Image, n int) (out *image.Paletted, exact bool) {
+	b := img.Bounds()
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				rgba.Set(x, y, img.At(x, y))
+			}
+		}
+	}
+
+	hist := map[[4]uint8]int{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := rgba.Pix[rgba.PixOffset(b.Min.X, y):rgba.PixOffset(b.Max.X, y)]
+		for i := 0; i < len(row); i += 4 {
+			hist[[4]uint8{row[i], row[i+1], row[i+2], row[i+3]}]++
+		}
+	}
+	counts := make([]colourCount, 0, len(hist))
+	for c, k := range hist {
+		counts = append(counts, colourCount{c, k})
+	}
+	// Map iteration order is random; sort so output is reproducible.
+	sort.Slice(counts, func(i, j int) bool {
+		a, b := counts[i].c, counts[j].c
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return false
+	})
+
+	var pal color.Palette
+	exact = len(counts) <= n
+	if exact {
+		for _, cc := range counts {
+			pal = append(pal, color.RGBA{cc.c[0], cc.c[1], cc.c[2], cc.c[3]}) // premultiplied, as in img.Pix
+		}
+	} else {
+		pal = medianCut(counts, n)
+	}
+
+	out = image.NewPaletted(b, pal)
+	index := map[[4]uint8]uint8{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := rgba.Pix[rgba.PixOffset(b.Min.X, y):rgba.PixOffset(b.Max.X, y)]
+		dst := out.Pix[out.PixOffset(b.Min.X, y):]
+		for i := 0; i < len(row); i += 4 {
+			c := [4]uint8{row[i], row[i+1], row[i+2], row[i+3]}
+			idx, ok := index[c]
+			if !ok {
+				idx = uint8(pal.Index(color.RGBA{c[0], c[1], c[2], c[3]}))
+				index[c] = idx
+			}
+			dst[i/4] = idx
+		}
+	}
+	return out, exact
+}
+
+// medianCut repeatedly splits the box of colours with the widest channel
+// range at its weighted median until there are n boxes, then averages each.
+func medianCut(counts []colourCount, n int) color.Palette {
+	boxes := [][]colourCount{counts}
+	for len(boxes) < n {
+		best, bestCh, bestRange := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for ch := 0; ch < 4; ch++ {
+				lo, hi := 255, 0
+				for _, cc := range box {
+					lo, hi = min(lo, int(cc.c[ch])), max(hi, int(cc.c[ch]))
+				}
+				if hi-lo > bestRange {
+					best, bestCh, bestRange = i, ch, hi-lo
+				}
+			}
+		}
+		if best < 0 {
+			break
+		}
+		box := boxes[best]
+		sort.SliceStable(box, func(i, j int) bool { return box[i].c[bestCh] < box[j].c[bestCh] })
+		total := 0
+		for _, cc := range box {
+			total += cc.n
+		}
+		split, acc := 1, 0
+		for i, cc := range box[:len(box)-1] {
+			acc += cc.n
+			if acc*2 >= total {
+				split = i + 1
+				break
+			}
+		}
+		boxes[best] = box[:split]
+		boxes = append(boxes, box[split:])
+	}
+
+	pal := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var sum [4]int
+		total := 0
+		for _, cc := range box {
+			for ch := range sum {
+				sum[ch] += int(cc.c[ch]) * cc.n
+			}
+			total += cc.n
+		}
+		// Pixels are premultiplied, so the average is too.
+		pal = append(pal, color.RGBA{
+			uint8(sum[0] / total), uint8(sum[1] / total), uint8(sum[2] / total), uint8(sum[3] / total),
+		})
+	}
+	return pal
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"testing"
+
+	"periodic-table-tiles/render"
+)
+
+// testRenderer makes a renderer for the shared flags in args, drawing in
+// the built-in font.
+func testRenderer(t *testing.T, args ...string) *render.Renderer {
+	t.Helper()
+	fs := flag.NewFlagSet("cards", flag.ContinueOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, args); err != nil {
+		t.Fatal(err)
+	}
+	r, err := newRenderer(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+var testHelium = Element{Number: 2, Symbol: "He", Name: "Helium", Mass: 4.0026, Type: "noble gas", XPos: 18, YPos: 1}
+
+// samePixels fails the test if any pixel of b differs from a.
+func samePixels(t *testing.T, a, b image.Image) {
+	t.Helper()
+	if a.Bounds() != b.Bounds() {
+		t.Fatalf("bounds %v, want %v", b.Bounds(), a.Bounds())
+	}
+	diff := 0
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if color.RGBAModel.Convert(a.At(x, y)) != color.RGBAModel.Convert(b.At(x, y)) {
+				diff++
+			}
+		}
+	}
+	if diff > 0 {
+		t.Errorf("%d pixels differ", diff)
+	}
+}
+
+func TestQuantiseExact(t *testing.T) {
+	for _, shape := range []string{render.ShapeCircle, render.ShapeHex} {
+		t.Run(shape, func(t *testing.T) {
+			card := testRenderer(t, "-shape", shape, "-height", "30").Tile(testHelium)
+			if card.RGBAAt(0, 0).A != 0 {
+				t.Fatal("want transparent corners")
+			}
+			out, exact := quantise(card, 256)
+			if !exact {
+				t.Fatal("not exact")
+			}
+			samePixels(t, card, out)
+		})
+	}
+}
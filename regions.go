@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strings"
+
+	"periodic-table-tiles/render"
+)
+
+// standardSeries picks out the usual series of the table by position, so
+// they outline the same cards whatever -groups has moved them into.
+var standardSeries = map[string]func(e Element) bool{
+	"alkali metal":         func(e Element) bool { return e.XPos == 1 && e.YPos >= 2 && e.YPos <= 7 },
+	"alkaline earth metal": func(e Element) bool { return e.XPos == 2 && e.YPos <= 7 },
+	"transition metal":     func(e Element) bool { return e.XPos >= 3 && e.XPos <= 12 && e.YPos >= 4 && e.YPos <= 7 },
+	"halogen":              func(e Element) bool { return e.XPos == 17 && e.YPos <= 7 },
+	"noble gas":            func(e Element) bool { return e.XPos == 18 && e.YPos <= 7 },
+	"lanthanide":           func(e Element) bool { return e.YPos == 9 },
+	"actinide":             func(e Element) bool { return e.YPos == 10 },
+}
+
+// seriesCells returns the places in the table of the elements in a series,
+// either a standard one or a category or -groups group.
+func seriesCells(name string, elements []Element) (map[image.Point]bool, error) {
+	c := normaliseCategory(name)
+	in, ok := standardSeries[c]
+	if !ok {
+		in = func(e Element) bool { return e.Type == c }
+	}
+	cells := map[image.Point]bool{}
+	for _, e := range elements {
+		if in(e) {
+			cells[image.Pt(e.XPos, e.YPos)] = true
+		}
+	}
+	if len(cells) == 0 {
+		return nil, fmt.Errorf("no elements in series %q", name)
+	}
+	return cells, nil
+}
+
+// traceCells returns the outlines, clockwise, around the cells of the
+// table in a set, in grid corners: corner (x, y) is the top left of the
+// cell in column x+1 and row y+1. A set in several pieces has several
+// outlines.
+func traceCells(cells map[image.Point]bool) [][]image.Point {
+	next := map[image.Point][]image.Point{}
+	add := func(a, b image.Point) { next[a] = append(next[a], b) }
+	for c := range cells {
+		x, y := c.X-1, c.Y-1
+		if !cells[c.Add(image.Pt(0, -1))] {
+			add(image.Pt(x, y), image.Pt(x+1, y))
+		}
+		if !cells[c.Add(image.Pt(1, 0))] {
+			add(image.Pt(x+1, y), image.Pt(x+1, y+1))
+		}
+		if !cells[c.Add(image.Pt(0, 1))] {
+			add(image.Pt(x+1, y+1), image.Pt(x, y+1))
+		}
+		if !cells[c.Add(image.Pt(-1, 0))] {
+			add(image.Pt(x, y+1), image.Pt(x, y))
+		}
+	}
+
+	var outlines [][]image.Point
+	for {
+		// Start from the top-left-most corner left, so the result doesn't
+		// depend on map order.
+		var start image.Point
+		found := false
+		for p, to := range next {
+			if len(to) > 0 && (!found || p.Y < start.Y || p.Y == start.Y && p.X < start.X) {
+				start, found = p, true
+			}
+		}
+		if !found {
+			return outlines
+		}
+		var pts []image.Point
+		for p := start; ; {
+			pts = append(pts, p)
+			to := next[p]
+			q := to[0]
+			next[p] = to[1:]
+			p = q
+			if p == start {
+				break
+			}
+		}
+		outlines = append(outlines, dropStraight(pts))
+	}
+}
+
+// dropStraight removes the corners of a closed outline that lie on a
+// straight line.
+func dropStraight(pts []image.Point) []image.Point {
+	var out []image.Point
+	for i, p := range pts {
+		prev, next := pts[(i+len(pts)-1)%len(pts)], pts[(i+1)%len(pts)]
+		a, b := p.Sub(prev), next.Sub(p)
+		if a.X*b.Y-a.Y*b.X != 0 {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// regionOutlines places the outlines of a series on the table, pad px
+// outside the cards.
+func regionOutlines(cells map[image.Point]bool, t tableLayout, pad float64) [][]render.Point {
+	var polys [][]render.Point
+	for _, corners := range traceCells(cells) {
+		poly := make([]render.Point, len(corners))
+		for i, p := range corners {
+			prev, next := corners[(i+len(corners)-1)%len(corners)], corners[(i+1)%len(corners)]
+			// Clockwise on screen, so the outside of an edge is to its
+			// left: the edges either side of a corner push it out along
+			// their normals.
+			in, out := sign(p.Sub(prev)), sign(next.Sub(p))
+			nx, ny := float64(in.Y+out.Y), float64(-in.X-out.X)
+			// Corners sit in the middle of the gap between cards.
+			x := float64(p.X*t.stepX) + float64(t.gap)*1.5
+			y := float64(p.Y*t.stepY) + float64(t.gap)*1.5
+			poly[i] = render.Point{X: x + nx*pad, Y: y + ny*pad}
+		}
+		polys = append(polys, poly)
+	}
+	return polys
+}
+
+func sign(p image.Point) image.Point {
+	s := func(v int) int {
+		switch {
+		case v > 0:
+			return 1
+		case v < 0:
+			return -1
+		}
+		return 0
+	}
+	return image.Pt(s(p.X), s(p.Y))
+}
+
+// regionItems turns -regions into overlay items.
+func regionItems(list, style string) []overlayItem {
+	var items []overlayItem
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		items = append(items, overlayItem{Type: "region", Series: name, Label: strings.ToUpper(name[:1]) + name[1:], Style: style})
+	}
+	return items
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+
+	"periodic-table-tiles/render"
+)
+
+func loadFont(path string, size float64) (font.Face, error) {
+	fBytes, err := render.ReadFont(path)
+	if err != nil {
+		return nil, err
+	}
+	ft, err := opentype.Parse(fBytes)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.NewFace(ft, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+}
@@ -0,0 +1,66 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Mass formats accepted by -mass-format.
+const (
+	MassFixed = "fixed"
+	MassIUPAC = "iupac"
+)
+
+// Units accepted by -mass-unit.
+const (
+	UnitNone = "none"
+	UnitU    = "u"
+	UnitGMol = "g/mol"
+	UnitBoth = "both"
+)
+
+func checkMassFormat(f string) error {
+	switch f {
+	case MassFixed, MassIUPAC:
+		return nil
+	}
+	return fmt.Errorf("unknown -mass-format %q (want %s or %s)", f, MassFixed, MassIUPAC)
+}
+
+func checkMassUnit(u string) error {
+	switch u {
+	case UnitNone, UnitU, UnitGMol, UnitBoth:
+		return nil
+	}
+	return fmt.Errorf("unknown -mass-unit %q (want %s, %s, %s or %s)", u, UnitNone, UnitU, UnitGMol, UnitBoth)
+}
+
+// FormatMass is how an element's atomic mass is written on its card:
+// to four decimal places, or with -mass-format iupac the standard atomic
+// weight from the data with its uncertainty, falling back to the mass as
+// the data gives it. A -preset rounds fixed masses its own way.
+func (r *Renderer) FormatMass(e Element) string {
+	if r.preset != nil && r.Options.MassFormat == MassFixed {
+		return r.preset.formatMass(e)
+	}
+	if r.Options.MassFormat == MassIUPAC {
+		if e.Weight.Text != "" {
+			return e.Weight.String()
+		}
+		return strconv.FormatFloat(e.Mass, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%.4f", e.Mass)
+}
+
+// MassUnit is the -mass-unit label written under the mass, or "".
+func (r *Renderer) MassUnit() string {
+	switch r.Options.MassUnit {
+	case UnitU, UnitGMol:
+		return r.Options.MassUnit
+	case UnitBoth:
+		// The same number either way: the mass of one atom in daltons is
+		// the mass of a mole of them in grams.
+		return "u (g/mol)"
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package render
+
+import (
+	"testing"
+
+	"periodic-table-tiles/elements"
+)
+
+func TestFormatMass(t *testing.T) {
+	iron := testIron
+	iron.Weight = elements.AtomicWeight{Value: 55.845, Text: "55.845", Uncertainty: "2"}
+	hydrogen := Element{Number: 1, Symbol: "H", Mass: 1.008, Weight: elements.AtomicWeight{Value: 1.007975, Text: "1.00784, 1.00811", Interval: true}}
+	polonium := Element{Number: 84, Symbol: "Po", Mass: 209, Weight: elements.AtomicWeight{Value: 209, Text: "209", MassNumber: true}}
+	for _, tt := range []struct {
+		format string
+		e      Element
+		want   string
+	}{
+		{MassFixed, iron, "55.8450"},
+		{MassFixed, hydrogen, "1.0080"},
+		{MassIUPAC, iron, "55.845(2)"},
+		{MassIUPAC, hydrogen, "[1.00784, 1.00811]"},
+		{MassIUPAC, polonium, "[209]"},
+		// Without a standard atomic weight, the mass as the data gives it.
+		{MassIUPAC, testIron, "55.845"},
+	} {
+		r := &Renderer{Options: &Options{MassFormat: tt.format}}
+		if got := r.FormatMass(tt.e); got != tt.want {
+			t.Errorf("%s mass of %s = %q, want %q", tt.format, tt.e.Symbol, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"fmt"
+	"image"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+)
+
+// Back draws the reverse of an element's card, in the same border, with
+// its text in face.
+func (r *Renderer) Back(e Element, face font.Face) *image.RGBA {
+	bg := r.background(e.Type)
+	img := &image.RGBA{Pix: make([]uint8, len(bg.Pix)), Stride: bg.Stride, Rect: bg.Rect}
+	copy(img.Pix, bg.Pix)
+
+	bt, pad := r.borderThickness(), r.TileH/20
+	inner := image.Rect(bt+pad, bt+pad, r.TileW-bt-pad, r.TileH-bt-pad)
+
+	// The front, shrunk into the top left corner.
+	thumbH := inner.Dy() * 2 / 5
+	thumb := image.Rect(inner.Min.X, inner.Min.Y, inner.Min.X+thumbH*r.TileW/r.TileH, inner.Min.Y+thumbH)
+	front := r.Tile(e)
+	xdraw.CatmullRom.Scale(img, thumb, front, front.Bounds(), xdraw.Over, nil)
+
+	// The data beside it, carrying on underneath if it doesn't fit, and
+	// then the summary.
+	data := NewTextBox(img, face, image.Rect(thumb.Max.X+pad, inner.Min.Y, inner.Max.X, thumb.Max.Y))
+	rest := data.table(r.backRows(e))
+	text := NewTextBox(img, face, image.Rect(inner.Min.X, thumb.Max.Y+pad, inner.Max.X, inner.Max.Y))
+	if len(rest) > 0 {
+		text.table(rest)
+		text.gap(0.5)
+	}
+	text.Paragraph(e.Summary)
+	return img
+}
+
+// backRows are the key data listed on a card's back, leaving out what the
+// element data doesn't have.
+func (r *Renderer) backRows(e Element) [][2]string {
+	mass := r.FormatMass(e)
+	if unit := r.MassUnit(); unit != "" {
+		mass += " " + unit
+	}
+	rows := [][2]string{
+		{"Atomic number", fmt.Sprint(e.Number)},
+		{"Atomic mass", mass},
+		{"Category", e.Type},
+	}
+	add := func(label, value string, ok bool) {
+		if ok {
+			rows = append(rows, [2]string{label, value})
+		}
+	}
+	add("Phase", e.Phase, e.Phase != "")
+	density := "g/cm³"
+	if e.Phase == "Gas" {
+		density = "g/L"
+	}
+	add("Density", fmt.Sprintf("%g %s", e.Density, density), e.Density > 0)
+	add("Melting point", fmt.Sprintf("%g K", e.Melt), e.Melt > 0)
+	add("Boiling point", fmt.Sprintf("%g K", e.Boil), e.Boil > 0)
+	add("Configuration", e.Configuration, e.Configuration != "")
+	add("Electronegativity", fmt.Sprintf("%g", e.Electronegativity), e.Electronegativity > 0)
+	year, ok := DiscoveryYears[e.Symbol]
+	add("Discovered", DiscoveryLabel(year), ok)
+	return rows
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Strength of the -bevel shading.
+const (
+	bevelHighlight = 0.55 // white over the edges facing the light
+	bevelShade     = 0.45 // black over the edges facing away from it
+	bevelShadow    = 0.2  // inner shadow just inside the lit edges
+)
+
+// rectangle returns the corners of a w×h tile moved in by inset px.
+func rectangle(w, h int, inset float64) []Point {
+	x0, y0, x1, y1 := inset, inset, float64(w)-inset, float64(h)-inset
+	return []Point{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+}
+
+// drawBevel shades the border of a tile, whose outline moved in by width
+// px gives its inner edge, as though it were a raised frame lit from the
+// top left, and casts a soft shadow from it onto the face.
+func drawBevel(img *image.RGBA, outline func(w, h int, inset float64) []Point, width float64) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	lx, ly := -math.Sqrt2/2, -math.Sqrt2/2
+
+	band := func(from, to float64, alpha func(light float64) color.Color) {
+		outer, inner := outline(w, h, from), outline(w, h, to)
+		cx, cy := float64(w)/2, float64(h)/2
+		for i := range outer {
+			j := (i + 1) % len(outer)
+			a, b := outer[i], outer[j]
+			dx, dy := b.X-a.X, b.Y-a.Y
+			l := math.Hypot(dx, dy)
+			if l == 0 {
+				continue
+			}
+			nx, ny := dy/l, -dx/l
+			if nx*((a.X+b.X)/2-cx)+ny*((a.Y+b.Y)/2-cy) < 0 {
+				nx, ny = -nx, -ny // point it outwards
+			}
+			if c := alpha(nx*lx + ny*ly); c != nil {
+				FillPolygon(img, []Point{a, b, inner[j], inner[i]}, c)
+			}
+		}
+	}
+
+	// The frame: lit edges lighter, the others darker.
+	band(0, width, func(light float64) color.Color {
+		if light > 0 {
+			return color.NRGBA{255, 255, 255, uint8(255 * bevelHighlight * light)}
+		}
+		return color.NRGBA{0, 0, 0, uint8(255 * bevelShade * -light)}
+	})
+
+	// The inner shadow falls inside the frame's lit edges, fading out.
+	const steps = 4
+	step := width / 2 / steps
+	for i := range steps {
+		fade := bevelShadow * float64(steps-i) / steps
+		band(width+float64(i)*step, width+float64(i+1)*step, func(light float64) color.Color {
+			if light <= 0 {
+				return nil
+			}
+			return color.NRGBA{0, 0, 0, uint8(255 * fade * light)}
+		})
+	}
+}
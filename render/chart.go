@@ -0,0 +1,43 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+type Point struct{ X, Y float64 }
+
+// DrawLine draws an anti-aliased line of the given width.
+func DrawLine(img *image.RGBA, x0, y0, x1, y1, width float64, c color.Color) {
+	dx, dy := x1-x0, y1-y0
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return
+	}
+	nx, ny := -dy/l*width/2, dx/l*width/2
+	FillPolygon(img, []Point{{x0 + nx, y0 + ny}, {x1 + nx, y1 + ny}, {x1 - nx, y1 - ny}, {x0 - nx, y0 - ny}}, c)
+}
+
+func FillCircle(img *image.RGBA, cx, cy, r float64, c color.Color) {
+	const n = 24
+	pts := make([]Point, n)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / n
+		pts[i] = Point{cx + r*math.Cos(a), cy + r*math.Sin(a)}
+	}
+	FillPolygon(img, pts, c)
+}
+
+func FillPolygon(img *image.RGBA, pts []Point, c color.Color) {
+	b := img.Bounds()
+	z := vector.NewRasterizer(b.Dx(), b.Dy())
+	z.MoveTo(float32(pts[0].X), float32(pts[0].Y))
+	for _, p := range pts[1:] {
+		z.LineTo(float32(p.X), float32(p.Y))
+	}
+	z.ClosePath()
+	z.Draw(img, b, image.NewUniform(c), image.Point{})
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"strings"
+)
+
+type Colours map[string]string
+
+func LoadColours(path string) (Colours, error) {
+	var colours Colours
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bs, &colours); err != nil {
+		return nil, err
+	}
+	return colours, nil
+}
+
+// loadAliases reads a JSON object mapping extra category names to the ones
+// they stand for, e.g. {"coinage": "transition metal"}. Both sides are
+// normalised.
+func loadAliases(path string) (map[string]string, error) {
+	aliases := map[string]string{}
+	if path == "" {
+		return aliases, nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return nil, err
+	}
+	for from, to := range raw {
+		aliases[normaliseCategory(from)] = normaliseCategory(to)
+	}
+	return aliases, nil
+}
+
+// loadNotes reads a -notes file: a JSON object of free text to print on the
+// cards, by element symbol.
+func loadNotes(path string) (map[string]string, error) {
+	notes := map[string]string{}
+	if path == "" {
+		return notes, nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bs, &notes); err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+func HexToRGBA(h string) color.RGBA {
+	h = strings.TrimPrefix(strings.TrimSpace(h), "#")
+	if len(h) == 3 {
+		h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+	}
+	if len(h) != 6 {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	var c color.RGBA
+	fmt.Sscanf(h, "%02x%02x%02x", &c.R, &c.G, &c.B)
+	c.A = 255
+	return c
+}
@@ -0,0 +1,21 @@
+package render
+
+import "fmt"
+
+// -colour-space values: what the numbers in the raster output mean.
+// Cards are always drawn in sRGB; display-p3 converts them so they look
+// the same on a wide-gamut screen that honours the profile, and cmyk
+// separates them for printing.
+const (
+	ColourSpaceSRGB      = "srgb"
+	ColourSpaceDisplayP3 = "display-p3"
+	ColourSpaceCMYK      = "cmyk"
+)
+
+func checkColourSpace(space string) error {
+	switch space {
+	case ColourSpaceSRGB, ColourSpaceDisplayP3, ColourSpaceCMYK:
+		return nil
+	}
+	return fmt.Errorf("unknown -colour-space %q (want %s, %s or %s)", space, ColourSpaceSRGB, ColourSpaceDisplayP3, ColourSpaceCMYK)
+}
@@ -0,0 +1,137 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"strings"
+	"unicode"
+)
+
+//go:embed data/compounds.json
+var compoundsJSON []byte
+
+// compounds maps element symbols to a few well-known compounds of theirs,
+// written as plain formulas such as "Fe2O3".
+var compounds = func() map[string][]string {
+	m := map[string][]string{}
+	if err := json.Unmarshal(compoundsJSON, &m); err != nil {
+		panic("data/compounds.json: " + err.Error())
+	}
+	return m
+}()
+
+// subScale is the size of formula subscripts against the rest.
+const subScale = 0.7
+
+// formulaRun is a piece of a formula, either subscript or not.
+type formulaRun struct {
+	text string
+	sub  bool
+}
+
+// formulaRuns splits a formula into runs, the counts after an element or
+// a bracket being subscripts.
+func formulaRuns(f string) []formulaRun {
+	var runs []formulaRun
+	prev := rune(0)
+	for _, c := range f {
+		sub := unicode.IsDigit(c) && (unicode.IsLetter(prev) || prev == ')' || prev == ']' || unicode.IsDigit(prev) && len(runs) > 0 && runs[len(runs)-1].sub)
+		if len(runs) > 0 && runs[len(runs)-1].sub == sub {
+			runs[len(runs)-1].text += string(c)
+		} else {
+			runs = append(runs, formulaRun{string(c), sub})
+		}
+		prev = c
+	}
+	return runs
+}
+
+// formulaWidth measures a formula as drawFormula draws it.
+func (r *Renderer) formulaWidth(f string) int {
+	w := 0
+	for _, run := range formulaRuns(f) {
+		face := r.noteFont
+		if run.sub {
+			face = r.subFont
+		}
+		w += MeasureText(face, run.text).Round()
+	}
+	return w
+}
+
+// drawFormula draws a formula with its baseline at y, subscripts lowered.
+func (r *Renderer) drawFormula(img *image.RGBA, x, y int, f string) int {
+	drop := r.noteFont.Metrics().Ascent.Round() / 4
+	for _, run := range formulaRuns(f) {
+		if run.sub {
+			DrawText(img, r.subFont, x, y+drop, run.text, color.Black)
+			x += MeasureText(r.subFont, run.text).Round()
+		} else {
+			DrawText(img, r.noteFont, x, y, run.text, color.Black)
+			x += MeasureText(r.noteFont, run.text).Round()
+		}
+	}
+	return x
+}
+
+// compoundList returns as many of an element's compounds as fit in width
+// px, separated by commas.
+func (r *Renderer) compoundList(e Element, width int) []string {
+	sep := MeasureText(r.noteFont, ", ").Round()
+	var list []string
+	w := 0
+	for _, f := range compounds[e.Symbol] {
+		fw := r.formulaWidth(f)
+		if len(list) > 0 {
+			fw += sep
+		}
+		if w+fw > width {
+			break
+		}
+		list = append(list, f)
+		w += fw
+	}
+	return list
+}
+
+// drawCompounds draws an element's compounds along rect.
+func (r *Renderer) drawCompounds(img *image.RGBA, rect image.Rectangle, e Element) {
+	m := r.noteFont.Metrics()
+	y := rect.Min.Y + (rect.Dy()+m.Ascent.Round()-m.Descent.Round())/2
+	x := rect.Min.X
+	for i, f := range r.compoundList(e, rect.Dx()) {
+		if i > 0 {
+			DrawText(img, r.noteFont, x, y, ", ", color.Black)
+			x += MeasureText(r.noteFont, ", ").Round()
+		}
+		x = r.drawFormula(img, x, y, f)
+	}
+}
+
+// compoundsSVG returns an element's compounds as SVG text, using tspans
+// for the subscripts.
+func (r *Renderer) compoundsSVG(rect image.Rectangle, e Element, size float64) string {
+	var sb strings.Builder
+	for i, f := range r.compoundList(e, rect.Dx()) {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		for _, run := range formulaRuns(f) {
+			if run.sub {
+				fmt.Fprintf(&sb, `<tspan baseline-shift="sub" font-size="%.1f">%s</tspan>`, size*subScale, html.EscapeString(run.text))
+			} else {
+				sb.WriteString(html.EscapeString(run.text))
+			}
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	m := r.noteFont.Metrics()
+	y := rect.Min.Y + (rect.Dy()+m.Ascent.Round()-m.Descent.Round())/2
+	return fmt.Sprintf(`<text x="%d" y="%d" font-size="%.1f">%s</text>`, rect.Min.X, y, size, sb.String())
+}
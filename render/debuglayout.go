@@ -0,0 +1,88 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+
+	"golang.org/x/image/font"
+)
+
+// Colours of the -debug-layout guides, see-through so the card shows
+// under them.
+var (
+	debugSafe     = color.NRGBA{0, 160, 60, 200}  // the safe area inside the margin
+	debugMargin   = color.NRGBA{0, 90, 255, 200}  // the inside edge of the border
+	debugField    = color.NRGBA{230, 0, 40, 200}  // a line of text's box
+	debugBaseline = color.NRGBA{230, 0, 200, 200} // a line of text's baseline
+	debugArea     = color.NRGBA{255, 140, 0, 200} // the swatch, spectrum and other areas
+)
+
+// drawDebugLayout draws the guides of -debug-layout over a card: the inside
+// of the border, the safe area a padding inside it, a box from the ascent
+// to the descent of each line of text with its baseline, and the areas
+// kept for the options in use.
+func (r *Renderer) drawDebugLayout(img *image.RGBA, l CardLayout, e Element) {
+	bt, pad := float64(r.borderThickness()), float64(r.TileH/20)
+	w := max(1, float64(r.TileH)/200)
+	outline := r.Outline()
+	StrokePolygon(img, outline(r.TileW, r.TileH, bt), w, debugMargin)
+	StrokePolygon(img, outline(r.TileW, r.TileH, bt+pad), w, debugSafe)
+
+	field := func(face font.Face, p TextPos, txt string) {
+		adv := MeasureText(face, txt).Round()
+		x := p.X
+		switch p.Align {
+		case alignCentre:
+			x = (img.Bounds().Dx() - adv) / 2
+		case alignRight:
+			x -= adv
+		}
+		m := face.Metrics()
+		box := image.Rect(x, p.Y-m.Ascent.Round(), x+adv, p.Y+m.Descent.Round())
+		StrokePolygon(img, rectPoints(box), w, debugField)
+		DrawLine(img, float64(box.Min.X), float64(p.Y), float64(box.Max.X), float64(p.Y), w, debugBaseline)
+	}
+	field(r.numFont, l.Number, fmt.Sprintf("%d", e.Number))
+	if r.showMass() {
+		field(r.MassFont, l.Mass, r.cardMass(e))
+		if unit := r.MassUnit(); unit != "" {
+			field(r.noteFont, l.MassUnit, unit)
+		}
+	}
+	field(r.symFont, l.Symbol, e.Symbol)
+	if l.NameRadius == 0 {
+		nameFont, _ := r.cardNameFont(e)
+		for _, ln := range r.nameLines(l, nameFont, e.Name) {
+			field(nameFont, ln.pos, ln.txt)
+		}
+	}
+
+	areas := []struct {
+		on   bool
+		rect image.Rectangle
+	}{
+		{r.Options.Flame, l.Swatch},
+		{r.Options.Spectrum, l.Strip},
+		{r.Options.Valence, l.Ring},
+		{r.Options.Compounds, l.Compounds},
+		{r.Options.Shells, l.Shells},
+		{r.Options.Magnetism, l.Magnetism},
+		{r.Options.StableIsotopes, l.Isotopes},
+		{r.label != nil, l.Label},
+		{r.Options.PhaseBar, l.PhaseBar},
+		{r.Options.NotesPath != "", l.Note},
+		{r.Options.Illustrations != "", l.Illustration},
+	}
+	for _, a := range areas {
+		if a.on && !a.rect.Empty() {
+			StrokePolygon(img, rectPoints(a.rect), w, debugArea)
+		}
+	}
+}
+
+// rectPoints returns the corners of rc, clockwise from the top left.
+func rectPoints(rc image.Rectangle) []Point {
+	x0, y0, x1, y1 := float64(rc.Min.X), float64(rc.Min.Y), float64(rc.Max.X), float64(rc.Max.Y)
+	return []Point{{x0, y0}, {x1, y0}, {x1, y1}, {x0, y1}}
+}
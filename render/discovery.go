@@ -0,0 +1,27 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+//go:embed data/discovery.json
+var discoveryJSON []byte
+
+// DiscoveryYears maps element symbols to the year they were discovered. 0
+// means they were known in antiquity.
+var DiscoveryYears = func() map[string]int {
+	m := map[string]int{}
+	if err := json.Unmarshal(discoveryJSON, &m); err != nil {
+		panic("data/discovery.json: " + err.Error())
+	}
+	return m
+}()
+
+func DiscoveryLabel(year int) string {
+	if year == 0 {
+		return "Antiquity"
+	}
+	return fmt.Sprint(year)
+}
@@ -0,0 +1,14 @@
+// Package render draws the element cards: the number, symbol, name and
+// mass of an element on its category's colour, in a rectangle, hexagon
+// or circle, with whatever else the Options add. Options has a field for
+// each of the command's card flags, and DefaultOptions gives their
+// defaults.
+//
+//	opts := render.DefaultOptions()
+//	opts.Height = 300
+//	r, err := render.New(&opts)
+//	img := r.Tile(es[elements.Index(es, "Fe")])
+//
+// The data the cards show beyond package elements', such as flame test
+// colours, isotopes and spectra, is built in.
+package render
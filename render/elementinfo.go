@@ -0,0 +1,64 @@
+package render
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// nobleGases are the atomic numbers written as a core in square brackets
+// at the start of a configuration.
+var nobleGases = []struct {
+	number int
+	symbol string
+}{{86, "Rn"}, {54, "Xe"}, {36, "Kr"}, {18, "Ar"}, {10, "Ne"}, {2, "He"}}
+
+// AufbauConfiguration works out an element's ground state electron
+// configuration by filling subshells in the order of the Madelung rule, for
+// data that doesn't give one. A few elements, such as chromium and copper,
+// break the rule, so the data's configuration is used whenever it has one.
+func AufbauConfiguration(number int) string {
+	if number < 1 || number > 118 {
+		return ""
+	}
+	type subshell struct{ n, l, count int }
+	var order []subshell
+	for n := 1; n <= 7; n++ {
+		for l := 0; l < n && l <= 3; l++ {
+			order = append(order, subshell{n, l, 0})
+		}
+	}
+	slices.SortStableFunc(order, func(a, b subshell) int {
+		return cmp.Or(cmp.Compare(a.n+a.l, b.n+b.l), cmp.Compare(a.n, b.n))
+	})
+
+	core, inCore := "", 0
+	for _, g := range nobleGases {
+		if g.number < number {
+			core, inCore = "["+g.symbol+"] ", g.number
+			break
+		}
+	}
+	var outer []subshell
+	left := number
+	for _, s := range order {
+		if left == 0 {
+			break
+		}
+		s.count = min(left, 4*s.l+2)
+		left -= s.count
+		// A noble gas core is always a run of full subshells from the start.
+		if inCore > 0 {
+			inCore -= s.count
+			continue
+		}
+		outer = append(outer, s)
+	}
+	slices.SortFunc(outer, func(a, b subshell) int { return cmp.Or(cmp.Compare(a.n, b.n), cmp.Compare(a.l, b.l)) })
+	parts := make([]string, len(outer))
+	for i, s := range outer {
+		parts[i] = fmt.Sprintf("%d%c%d", s.n, "spdf"[s.l], s.count)
+	}
+	return core + strings.Join(parts, " ")
+}
@@ -0,0 +1,26 @@
+package render
+
+import (
+	"log/slog"
+
+	"periodic-table-tiles/elements"
+)
+
+// Element is an element as the cards draw it.
+type Element = elements.Element
+
+// categories, normaliseCategory and indexElement are short for those of
+// package elements, whose name the []Element variables everywhere here
+// hide.
+var categories = elements.Categories
+
+func normaliseCategory(c string) string { return elements.NormaliseCategory(c) }
+
+func indexElement(es []Element, name string) int { return elements.Index(es, name) }
+
+// logger carries the warnings drawing prints, such as a category with no
+// colour. It is slog's default logger unless SetLogger replaces it.
+var logger = slog.Default()
+
+// SetLogger sends the package's warnings to l.
+func SetLogger(l *slog.Logger) { logger = l }
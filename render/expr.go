@@ -0,0 +1,444 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// cardExpr is a -label expression, compiled: a small language for labels
+// worked out from each element's data, such as
+//
+//	round(mass, 2)
+//	upper(symbol)
+//	number + " / " + block
+//
+// It has numbers, "strings" (or 'strings'), the fields below, + - * /
+// and brackets, and the functions round(x, places), fixed(x, places) for
+// a number written with exactly that many places, upper(s), lower(s) and
+// len(s). + joins strings, writing any number in it in the shortest way.
+// Each part has a type known before anything is drawn, so mistakes such
+// as upper(mass) are reported up front rather than card by card. The
+// -rules conditions compare expressions, see cardCond.
+type cardExpr struct {
+	num  bool // a number rather than a string
+	eval func(e Element) any
+}
+
+// exprFields are the element data an expression can use.
+var exprFields = map[string]cardExpr{
+	"number":            {true, func(e Element) any { return float64(e.Number) }},
+	"mass":              {true, func(e Element) any { return e.Mass }},
+	"mass_decimals":     {true, func(e Element) any { return float64(massDecimals(e.Mass)) }},
+	"name_length":       {true, func(e Element) any { return float64(utf8.RuneCountInString(e.Name)) }},
+	"column":            {true, func(e Element) any { return float64(e.XPos) }},
+	"row":               {true, func(e Element) any { return float64(e.YPos) }},
+	"period":            {true, func(e Element) any { return float64(elementPeriod(e)) }},
+	"melt":              {true, func(e Element) any { return e.Melt }},
+	"boil":              {true, func(e Element) any { return e.Boil }},
+	"density":           {true, func(e Element) any { return e.Density }},
+	"electronegativity": {true, func(e Element) any { return e.Electronegativity }},
+	"valence":           {true, func(e Element) any { return float64(e.Valence) }},
+	"symbol":            {false, func(e Element) any { return e.Symbol }},
+	"name":              {false, func(e Element) any { return e.Name }},
+	"category":          {false, func(e Element) any { return e.Type }},
+	"phase":             {false, func(e Element) any { return e.Phase }},
+	"configuration":     {false, func(e Element) any { return e.Configuration }},
+	"block":             {false, func(e Element) any { return elementBlock(e) }},
+}
+
+// massDecimals is the number of decimal places of a mass as the data
+// gives it, 0 for the mass number of an element with no stable isotopes.
+func massDecimals(mass float64) int {
+	_, frac, _ := strings.Cut(strconv.FormatFloat(mass, 'f', -1, 64), ".")
+	return len(frac)
+}
+
+// elementPeriod is the period of an element, taking the lanthanides and
+// actinides from the rows under the table back to 6 and 7.
+func elementPeriod(e Element) int {
+	if e.YPos > 7 {
+		return e.YPos - 3
+	}
+	return e.YPos
+}
+
+// elementBlock is the s, p, d or f block of an element, from where it is
+// in the table.
+func elementBlock(e Element) string {
+	switch {
+	case e.YPos > 7:
+		return "f"
+	case e.XPos <= 2 || e.Number == 2: // helium sits over the p block
+		return "s"
+	case e.XPos <= 12:
+		return "d"
+	}
+	return "p"
+}
+
+// String evaluates the expression for an element as text.
+func (x cardExpr) String(e Element) string { return exprString(x.eval(e)) }
+
+func exprString(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return v.(string)
+}
+
+// cardCond is a -rules condition, compiled: one or more comparisons of
+// two expressions, joined by "and", such as
+//
+//	name_length > 10 and row <= 7
+//	category == 'unknown'
+//
+// Numbers are compared with ==, !=, <, <=, > or >=, and strings with ==
+// or != ignoring case.
+type cardCond func(e Element) bool
+
+// exprComparisons are the operators a cardCond compares with.
+var exprComparisons = []string{"==", "!=", "<", "<=", ">", ">="}
+
+// exprParser is a recursive descent parser over an expression's tokens.
+type exprParser struct {
+	toks []string
+	pos  int
+}
+
+// parseCardExpr compiles an expression.
+func parseCardExpr(src string) (cardExpr, error) {
+	toks, err := exprTokens(src)
+	if err != nil {
+		return cardExpr{}, err
+	}
+	p := &exprParser{toks: toks}
+	x, err := p.sum()
+	if err != nil {
+		return cardExpr{}, err
+	}
+	if p.pos < len(p.toks) {
+		return cardExpr{}, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return x, nil
+}
+
+// parseCardCond compiles a condition.
+func parseCardCond(src string) (cardCond, error) {
+	toks, err := exprTokens(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	var tests []cardCond
+	for {
+		test, err := p.compare()
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, test)
+		if p.peek() != "and" {
+			break
+		}
+		p.pos++
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return func(e Element) bool {
+		for _, test := range tests {
+			if !test(e) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// exprTokens splits an expression into numbers, quoted strings, names and
+// operators.
+func exprTokens(src string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(src); {
+		c, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(src[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed string %s", src[i:])
+			}
+			toks = append(toks, src[i:i+end+2])
+			i += end + 2
+		case c >= '0' && c <= '9' || c == '.':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, src[i:j])
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(src) {
+				d, n := utf8.DecodeRuneInString(src[j:])
+				if !unicode.IsLetter(d) && !unicode.IsDigit(d) && d != '_' {
+					break
+				}
+				j += n
+			}
+			toks = append(toks, src[i:j])
+			i = j
+		case strings.ContainsRune("+-*/(),", c):
+			toks = append(toks, string(c))
+			i += size
+		case strings.ContainsRune("=!<>", c):
+			j := i + 1
+			if j < len(src) && src[j] == '=' {
+				j++
+			}
+			if tok := src[i:j]; tok == "=" || tok == "!" {
+				return nil, fmt.Errorf("unexpected %q (want == or !=)", tok)
+			}
+			toks = append(toks, src[i:j])
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	return toks, nil
+}
+
+// isExprName reports whether a token is a field or function name.
+func isExprName(tok string) bool {
+	c, _ := utf8.DecodeRuneInString(tok)
+	return unicode.IsLetter(c) || c == '_'
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) expect(tok string) error {
+	if p.peek() != tok {
+		if p.peek() == "" {
+			return fmt.Errorf("missing %q at the end", tok)
+		}
+		return fmt.Errorf("want %q, not %q", tok, p.peek())
+	}
+	p.pos++
+	return nil
+}
+
+// compare parses two expressions and the comparison between them.
+func (p *exprParser) compare() (cardCond, error) {
+	a, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	if !slices.Contains(exprComparisons, op) {
+		if op == "" {
+			return nil, fmt.Errorf("missing comparison at the end (want one of %s)", strings.Join(exprComparisons, " "))
+		}
+		return nil, fmt.Errorf("want a comparison, not %q", op)
+	}
+	p.pos++
+	b, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if a.num != b.num {
+		return nil, fmt.Errorf("%s compares a number with a string", op)
+	}
+	if !a.num {
+		if op != "==" && op != "!=" {
+			return nil, fmt.Errorf("%s needs numbers on both sides", op)
+		}
+		equal := op == "=="
+		return func(e Element) bool { return strings.EqualFold(a.eval(e).(string), b.eval(e).(string)) == equal }, nil
+	}
+	return func(e Element) bool {
+		x, y := a.eval(e).(float64), b.eval(e).(float64)
+		switch op {
+		case "==":
+			return x == y
+		case "!=":
+			return x != y
+		case "<":
+			return x < y
+		case "<=":
+			return x <= y
+		case ">":
+			return x > y
+		}
+		return x >= y
+	}, nil
+}
+
+// sum parses terms joined by + and -.
+func (p *exprParser) sum() (cardExpr, error) {
+	x, err := p.product()
+	for err == nil && (p.peek() == "+" || p.peek() == "-") {
+		op := p.toks[p.pos]
+		p.pos++
+		var y cardExpr
+		if y, err = p.product(); err != nil {
+			break
+		}
+		a, b := x, y
+		switch {
+		case op == "+" && a.num && b.num:
+			x = cardExpr{true, func(e Element) any { return a.eval(e).(float64) + b.eval(e).(float64) }}
+		case op == "+":
+			x = cardExpr{false, func(e Element) any { return exprString(a.eval(e)) + exprString(b.eval(e)) }}
+		case a.num && b.num:
+			x = cardExpr{true, func(e Element) any { return a.eval(e).(float64) - b.eval(e).(float64) }}
+		default:
+			err = fmt.Errorf("- needs numbers on both sides")
+		}
+	}
+	return x, err
+}
+
+// product parses factors joined by * and /.
+func (p *exprParser) product() (cardExpr, error) {
+	x, err := p.factor()
+	for err == nil && (p.peek() == "*" || p.peek() == "/") {
+		op := p.toks[p.pos]
+		p.pos++
+		var y cardExpr
+		if y, err = p.factor(); err != nil {
+			break
+		}
+		if !x.num || !y.num {
+			return x, fmt.Errorf("%s needs numbers on both sides", op)
+		}
+		a, b := x, y
+		if op == "*" {
+			x = cardExpr{true, func(e Element) any { return a.eval(e).(float64) * b.eval(e).(float64) }}
+		} else {
+			x = cardExpr{true, func(e Element) any { return a.eval(e).(float64) / b.eval(e).(float64) }}
+		}
+	}
+	return x, err
+}
+
+// factor parses a number, string, field, function call, bracketed
+// expression or negation.
+func (p *exprParser) factor() (cardExpr, error) {
+	tok := p.peek()
+	if tok == "" {
+		return cardExpr{}, fmt.Errorf("unexpected end")
+	}
+	p.pos++
+	switch {
+	case tok == "(":
+		x, err := p.sum()
+		if err != nil {
+			return x, err
+		}
+		return x, p.expect(")")
+	case tok == "-":
+		x, err := p.factor()
+		if err != nil {
+			return x, err
+		}
+		if !x.num {
+			return x, fmt.Errorf("- needs a number")
+		}
+		return cardExpr{true, func(e Element) any { return -x.eval(e).(float64) }}, nil
+	case tok[0] == '"' || tok[0] == '\'':
+		s := tok[1 : len(tok)-1]
+		return cardExpr{false, func(Element) any { return s }}, nil
+	case tok[0] == '.' || tok[0] >= '0' && tok[0] <= '9':
+		f, err := strconv.ParseFloat(tok, 64)
+		if err != nil {
+			return cardExpr{}, fmt.Errorf("bad number %q", tok)
+		}
+		return cardExpr{true, func(Element) any { return f }}, nil
+	case !isExprName(tok):
+		return cardExpr{}, fmt.Errorf("unexpected %q", tok)
+	case p.peek() == "(":
+		p.pos++
+		return p.call(tok)
+	}
+	x, ok := exprFields[tok]
+	if !ok {
+		return x, fmt.Errorf("unknown field %q (quote text, as in '%s')", tok, tok)
+	}
+	return x, nil
+}
+
+// call parses the arguments of a function after its opening bracket.
+func (p *exprParser) call(name string) (cardExpr, error) {
+	var args []cardExpr
+	for p.peek() != ")" {
+		if p.peek() == "" {
+			return cardExpr{}, p.expect(")")
+		}
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return cardExpr{}, err
+			}
+		}
+		x, err := p.sum()
+		if err != nil {
+			return x, err
+		}
+		args = append(args, x)
+	}
+	p.pos++
+
+	want := func(types ...bool) error {
+		if len(args) != len(types) {
+			return fmt.Errorf("%s takes %d arguments, not %d", name, len(types), len(args))
+		}
+		for i, num := range types {
+			if args[i].num != num {
+				kind := "a string"
+				if num {
+					kind = "a number"
+				}
+				return fmt.Errorf("argument %d of %s must be %s", i+1, name, kind)
+			}
+		}
+		return nil
+	}
+	switch name {
+	case "round", "fixed":
+		if err := want(true, true); err != nil {
+			return cardExpr{}, err
+		}
+		v, places := args[0], args[1]
+		if name == "fixed" {
+			return cardExpr{false, func(e Element) any {
+				return strconv.FormatFloat(v.eval(e).(float64), 'f', max(0, int(places.eval(e).(float64))), 64)
+			}}, nil
+		}
+		return cardExpr{true, func(e Element) any {
+			scale := math.Pow(10, math.Round(places.eval(e).(float64)))
+			return math.Round(v.eval(e).(float64)*scale) / scale
+		}}, nil
+	case "upper", "lower", "len":
+		if err := want(false); err != nil {
+			return cardExpr{}, err
+		}
+		s := args[0]
+		switch name {
+		case "upper":
+			return cardExpr{false, func(e Element) any { return strings.ToUpper(s.eval(e).(string)) }}, nil
+		case "lower":
+			return cardExpr{false, func(e Element) any { return strings.ToLower(s.eval(e).(string)) }}, nil
+		}
+		return cardExpr{true, func(e Element) any { return float64(utf8.RuneCountInString(s.eval(e).(string))) }}, nil
+	}
+	return cardExpr{}, fmt.Errorf("unknown function %q (want round, fixed, upper, lower or len)", name)
+}
@@ -0,0 +1,159 @@
+package render
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCardExpr(t *testing.T) {
+	for _, tt := range []struct {
+		src, want string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"(1 + 2) * 3", "9"},
+		{"10 - 4 - 3", "3"},
+		{"12 / 4 / 3", "1"},
+		{"-2 * -3", "6"},
+		{"- (1 + 2)", "-3"},
+		{".5 + 1", "1.5"},
+		{"number", "26"},
+		{"round(mass, 2)", "55.85"},
+		{"round(mass, 0) - number", "30"},
+		{"fixed(mass, 4)", "55.8450"},
+		{"fixed(number, -1)", "26"},
+		{"upper(symbol) + lower(name)", "FEiron"},
+		{"len(name) * 2", "8"},
+		{`number + " / " + block`, "26 / d"},
+		{`'say "hi"'`, `say "hi"`},
+		{`"a" + 1 + 2`, "a12"},
+		{`1 + 2 + "a"`, "3a"},
+		{`1 + (2 + "a")`, "12a"},
+		{`"period " + period + ", row " + row`, "period 4, row 4"},
+		{"category", "transition metal"},
+		{"mass_decimals + name_length", "7"},
+	} {
+		t.Run(tt.src, func(t *testing.T) {
+			x, err := parseCardExpr(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := x.String(testIron); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCardExprErrors(t *testing.T) {
+	for _, tt := range []struct {
+		src, want string
+	}{
+		// Types, checked before any card is drawn.
+		{"upper(mass)", "argument 1 of upper must be a string"},
+		{`round(name, 2)`, "argument 1 of round must be a number"},
+		{"symbol - 1", "- needs numbers on both sides"},
+		{"name * 2", "* needs numbers on both sides"},
+		{"2 / symbol", "/ needs numbers on both sides"},
+		{"-name", "- needs a number"},
+		{"round(mass)", "round takes 2 arguments, not 1"},
+		{"len()", "len takes 1 arguments, not 0"},
+		{"weight", `unknown field "weight"`},
+		{"sqrt(2)", `unknown function "sqrt"`},
+
+		// Malformed input.
+		{"", "unexpected end"},
+		{"1 +", "unexpected end"},
+		{"(1 + 2", `missing ")" at the end`},
+		{"1 + 2)", `unexpected ")"`},
+		{"1 2", `unexpected "2"`},
+		{`"open`, `unclosed string "open`},
+		{"1..2", `bad number "1..2"`},
+		{"mass # comment", "unexpected '#'"},
+		{"round(mass, 2", `missing ")" at the end`},
+		{"round(mass 2)", `want ",", not "2"`},
+		{"round(, 2)", `unexpected ","`},
+		{"*", `unexpected "*"`},
+		{"٣", "unexpected '٣'"},
+		{"mass +٣", "unexpected '٣'"},
+	} {
+		t.Run(tt.src, func(t *testing.T) {
+			done := make(chan error, 1)
+			go func() {
+				_, err := parseCardExpr(tt.src)
+				done <- err
+			}()
+			select {
+			case err := <-done:
+				if err == nil || !strings.Contains(err.Error(), tt.want) {
+					t.Errorf("error = %v, want %q", err, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("parseCardExpr didn't return")
+			}
+		})
+	}
+}
+
+func TestParseCardCond(t *testing.T) {
+	for _, tt := range []struct {
+		src  string
+		want bool
+	}{
+		{"number == 26", true},
+		{"number != 26", false},
+		{"mass < 56", true},
+		{"mass <= 55.845", true},
+		{"mass > 55.845", false},
+		{"mass >= 55.845", true},
+		{"mass_decimals == 3", true},
+		{"name_length > 3 and row <= 7", true},
+		{"name_length > 3 and row > 7", false},
+		{"row>3 and column<9 and period==4", true},
+		{"round(mass, 0) - number == 30", true},
+		// Text is compared ignoring case, however it is quoted.
+		{"symbol == 'FE'", true},
+		{`name != "iron"`, false},
+		{"category == 'Transition Metal'", true},
+		{"upper(block) == 'D' and len(symbol) == 2", true},
+		{"'Fe' == symbol", true},
+		{`symbol + number == "fe26"`, true},
+		// "and" is only a word between comparisons.
+		{"name == 'and'", false},
+	} {
+		t.Run(tt.src, func(t *testing.T) {
+			cond, err := parseCardCond(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cond(testIron); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCardCondErrors(t *testing.T) {
+	for _, tt := range []struct {
+		src, want string
+	}{
+		{"number", "missing comparison at the end (want one of == != < <= > >=)"},
+		{"number 26", `want a comparison, not "26"`},
+		{"number == 26 and", "unexpected end"},
+		{"number == 26 or row == 4", `unexpected "or"`},
+		{"number == 26 == 27", `unexpected "=="`},
+		{"number = 26", `unexpected "=" (want == or !=)`},
+		{"!number", `unexpected "!" (want == or !=)`},
+		{"number == 'one'", "== compares a number with a string"},
+		{"name < 'J'", "< needs numbers on both sides"},
+		{"category == unknown", `unknown field "unknown" (quote text, as in 'unknown')`},
+		{"weight > 1", `unknown field "weight"`},
+	} {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := parseCardCond(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
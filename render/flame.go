@@ -0,0 +1,23 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+)
+
+type FlameColour struct {
+	Colour      string `json:"colour"`
+	Description string `json:"description"`
+}
+
+//go:embed data/flame.json
+var flameJSON []byte
+
+// FlameColours maps element symbols to the colour they give in a flame test.
+var FlameColours = func() map[string]FlameColour {
+	m := map[string]FlameColour{}
+	if err := json.Unmarshal(flameJSON, &m); err != nil {
+		panic("data/flame.json: " + err.Error())
+	}
+	return m
+}()
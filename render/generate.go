@@ -0,0 +1,66 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"image"
+	"time"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// Result is an element's card from RenderAll, or why it couldn't be drawn.
+type Result struct {
+	Element Element
+	Tile    *image.RGBA // nil if Err is set
+	Began   time.Time   // when rendering started
+	Err     error
+}
+
+// RenderAll draws the elements' cards with opts, sending them on the
+// channel it returns as they are drawn; see Renderer.RenderAll. The error
+// is for options New rejects, before any card is drawn.
+func RenderAll(ctx context.Context, opts *Options, elements []Element) (<-chan Result, error) {
+	r, err := New(opts)
+	if err != nil {
+		return nil, err
+	}
+	return r.RenderAll(ctx, elements)
+}
+
+// RenderAll renders the cards in a goroutine, sending each on the channel
+// it returns. It keeps one card ready ahead of the one being read, so the
+// next is drawn while the last is saved, and stops once ctx is cancelled,
+// closing the channel either way. With Options.Strict, a card with a
+// problem CheckStrict would report comes with it as its Err instead.
+func (r *Renderer) RenderAll(ctx context.Context, elements []Element) (<-chan Result, error) {
+	var f *sfnt.Font
+	if r.Options.Strict {
+		var err error
+		if f, err = r.strictFont(); err != nil {
+			return nil, err
+		}
+	}
+	ch := make(chan Result, 1)
+	go func() {
+		defer close(ch)
+		for _, e := range elements {
+			if ctx.Err() != nil {
+				return
+			}
+			res := Result{Element: e, Began: time.Now()}
+			if f != nil {
+				res.Err = errors.Join(r.checkCard(f, e)...)
+			}
+			if res.Err == nil {
+				res.Tile = r.Tile(e)
+			}
+			select {
+			case ch <- res:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,94 @@
+package render
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testOptions() Options {
+	opts := DefaultOptions()
+	opts.ColoursPath = "../colours.json"
+	opts.Height = 30
+	return opts
+}
+
+func TestRenderAll(t *testing.T) {
+	es := []Element{testIron, {Number: 2, Symbol: "He", Name: "Helium", Mass: 4.0026, Type: "noble gas", XPos: 18, YPos: 1}}
+	opts := testOptions()
+	ch, err := RenderAll(context.Background(), &opts, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for res := range ch {
+		if res.Err != nil || res.Tile == nil {
+			t.Errorf("%s: tile %v, error %v", res.Element.Symbol, res.Tile != nil, res.Err)
+		}
+		got = append(got, res.Element.Symbol)
+	}
+	if len(got) != 2 || got[0] != "Fe" || got[1] != "He" {
+		t.Errorf("got %v, want [Fe He]", got)
+	}
+}
+
+func TestRenderAllBadOptions(t *testing.T) {
+	opts := testOptions()
+	opts.Shape = "star"
+	if _, err := RenderAll(context.Background(), &opts, []Element{testIron}); err == nil {
+		t.Error("RenderAll succeeded, want an error")
+	}
+}
+
+// TestRenderAllErrors checks that with -strict a card that can't be
+// drawn comes back as an error while the others are still drawn.
+func TestRenderAllErrors(t *testing.T) {
+	colours := filepath.Join(t.TempDir(), "colours.json")
+	if err := os.WriteFile(colours, []byte(`{"transition metal": "#7a9e9f", "unknown": "#999999"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := testOptions()
+	opts.ColoursPath, opts.Strict = colours, true
+	es := []Element{testIron, {Number: 2, Symbol: "He", Name: "Helium", Mass: 4.0026, Type: "noble gas", XPos: 18, YPos: 1}}
+	ch, err := RenderAll(context.Background(), &opts, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []Result
+	for res := range ch {
+		results = append(results, res)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if fe := results[0]; fe.Err != nil || fe.Tile == nil {
+		t.Errorf("Fe: tile %v, error %v", fe.Tile != nil, fe.Err)
+	}
+	if he := results[1]; he.Err == nil || he.Tile != nil {
+		t.Errorf("He: tile %v, error %v; want only an error for its missing colour", he.Tile != nil, he.Err)
+	}
+}
+
+func TestRenderAllCancel(t *testing.T) {
+	es := make([]Element, 50)
+	for i := range es {
+		es[i] = testIron
+	}
+	opts := testOptions()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := RenderAll(ctx, &opts, es)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ch
+	cancel()
+	n := 0
+	for range ch {
+		n++
+	}
+	// At most the card ready ahead and the one being drawn get through.
+	if n > 2 {
+		t.Errorf("got %d cards after cancelling, want at most 2", n)
+	}
+}
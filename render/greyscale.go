@@ -0,0 +1,84 @@
+package render
+
+import (
+	"cmp"
+	"image/color"
+	"maps"
+	"math"
+	"slices"
+)
+
+// The -grayscale ramp runs between these CIE L* lightnesses, dark enough
+// at one end to tell from the black text's background and light enough at
+// the other to tell from the white inside of the cards.
+const (
+	greyDarkest  = 30
+	greyLightest = 85
+)
+
+// Linear turns an sRGB channel into linear light from 0 to 1.
+func Linear(v uint8) float64 {
+	f := float64(v) / 255
+	if f <= 0.04045 {
+		return f / 12.92
+	}
+	return math.Pow((f+0.055)/1.055, 2.4)
+}
+
+// Unlinear turns linear light from 0 to 1 back into an sRGB channel.
+func Unlinear(y float64) uint8 {
+	f := 12.92 * y
+	if y > 0.0031308 {
+		f = 1.055*math.Pow(y, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(math.Max(0, math.Min(1, f)) * 255))
+}
+
+// Lightness is the CIE L* perceived lightness of a colour, from 0 for
+// black to 100 for white.
+func Lightness(c color.RGBA) float64 {
+	y := 0.2126*Linear(c.R) + 0.7152*Linear(c.G) + 0.0722*Linear(c.B)
+	if y <= 216.0/24389 {
+		return y * 24389 / 27
+	}
+	return 116*math.Cbrt(y) - 16
+}
+
+// Grey is the grey of CIE L* lightness l.
+func Grey(l float64) color.RGBA {
+	y := math.Pow((l+16)/116, 3)
+	if l <= 8 {
+		y = l * 27 / 24389
+	}
+	v := Unlinear(y)
+	return color.RGBA{v, v, v, 255}
+}
+
+// greyscale turns colours into greys spread evenly in lightness, in the
+// order of the colours' own lightness, so that the categories stay as far
+// apart as they can be in black and white and a darker colour still prints
+// darker. Categories sharing a colour share a grey.
+func greyscale(colours Colours) Colours {
+	// The same colour may be written more than one way.
+	norm := Colours{}
+	for category, c := range colours {
+		norm[category] = RGBHex(HexToRGBA(c))
+	}
+	distinct := slices.Compact(slices.Sorted(maps.Values(norm)))
+	slices.SortStableFunc(distinct, func(a, b string) int {
+		return cmp.Compare(Lightness(HexToRGBA(a)), Lightness(HexToRGBA(b)))
+	})
+	greys := map[string]string{}
+	for i, c := range distinct {
+		l := float64(greyDarkest+greyLightest) / 2
+		if len(distinct) > 1 {
+			l = greyDarkest + float64(i)*(greyLightest-greyDarkest)/float64(len(distinct)-1)
+		}
+		greys[c] = RGBHex(Grey(l))
+	}
+	out := Colours{}
+	for category, c := range norm {
+		out[category] = greys[c]
+	}
+	return out
+}
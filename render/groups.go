@@ -0,0 +1,28 @@
+package render
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// LoadGroups reads a -groups file: a JSON object mapping category names to
+// the elements in them, by symbol or atomic number, e.g.
+// {"coinage metals": ["Cu", "Ag", "Au"]}. Category names are normalised.
+func LoadGroups(path string) (map[string][]string, error) {
+	groups := map[string][]string{}
+	if path == "" {
+		return groups, nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string][]string
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return nil, err
+	}
+	for name, members := range raw {
+		groups[normaliseCategory(name)] = members
+	}
+	return groups, nil
+}
@@ -0,0 +1,200 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// -hinting modes: how far the glyph metrics are rounded to whole pixels.
+// Full hinting keeps small text crisp but can space very large text
+// unevenly.
+var hintings = map[string]font.Hinting{
+	"none":     font.HintingNone,
+	"vertical": font.HintingVertical,
+	"full":     font.HintingFull,
+}
+
+// -subpixel orders of the red, green and blue stripes of LCD pixels, for
+// drawing text a third of a pixel at a time across.
+const (
+	SubpixelNone = "none"
+	SubpixelRGB  = "rgb"
+	SubpixelBGR  = "bgr"
+)
+
+// checkTextRendering checks the -hinting, -gamma and -subpixel flags.
+func checkTextRendering(o *Options) error {
+	if _, ok := hintings[o.Hinting]; !ok {
+		return fmt.Errorf("unknown -hinting %q (want none, vertical or full)", o.Hinting)
+	}
+	if o.Gamma <= 0 {
+		return fmt.Errorf("-gamma must be more than 0")
+	}
+	switch o.Subpixel {
+	case SubpixelNone, SubpixelRGB, SubpixelBGR:
+		return nil
+	}
+	return fmt.Errorf("unknown -subpixel %q (want %s, %s or %s)", o.Subpixel, SubpixelNone, SubpixelRGB, SubpixelBGR)
+}
+
+// tunedFace is a font face drawn as -hinting, -gamma and -subpixel ask.
+type tunedFace struct {
+	font.Face
+	font     *sfnt.Font
+	buf      sfnt.Buffer
+	ppem     fixed.Int26_6
+	gamma    [256]uint8 // coverage to the alpha it is drawn with
+	subpixel string
+}
+
+// CardFont loads the -font at size px for drawing cards, with the
+// -hinting, -gamma and -subpixel settings.
+func (r *Renderer) CardFont(size float64) (font.Face, error) {
+	o := r.Options
+	bs, err := ReadFont(o.FontPath)
+	if err != nil {
+		return nil, err
+	}
+	ft, err := opentype.Parse(bs)
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(ft, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: hintings[o.Hinting]})
+	if err != nil {
+		return nil, err
+	}
+	if o.Gamma == 1 && o.Subpixel == SubpixelNone {
+		return face, nil
+	}
+	t := &tunedFace{Face: face, font: ft, ppem: fixed.Int26_6(math.Round(size * 64)), subpixel: o.Subpixel}
+	for i := range t.gamma {
+		t.gamma[i] = uint8(math.Round(math.Pow(float64(i)/255, 1/o.Gamma) * 255))
+	}
+	return t, nil
+}
+
+// Glyph returns the glyph's mask with its coverage gamma corrected.
+func (t *tunedFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	dr, mask, maskp, adv, ok := t.Face.Glyph(dot, r)
+	if !ok {
+		return dr, mask, maskp, adv, ok
+	}
+	out := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	for y := 0; y < dr.Dy(); y++ {
+		for x := 0; x < dr.Dx(); x++ {
+			_, _, _, a := mask.At(maskp.X+x, maskp.Y+y).RGBA()
+			out.Pix[y*out.Stride+x] = t.gamma[a>>8]
+		}
+	}
+	return dr, out, image.Point{}, adv, true
+}
+
+// drawSubpixel draws a glyph with its baseline starting at dot, a third of
+// a pixel at a time across, each third in one of the red, green or blue
+// stripes of the pixel. It reports false, having drawn nothing, if it
+// can't, as for text drawn onto a transparent image to be turned or
+// scaled later, where the colours would fringe.
+func (t *tunedFace) drawSubpixel(dst *image.RGBA, dot fixed.Point26_6, r rune, col color.Color) bool {
+	gi, err := t.font.GlyphIndex(&t.buf, r)
+	if err != nil || gi == 0 {
+		return false
+	}
+	segs, err := t.font.LoadGlyph(&t.buf, gi, t.ppem, nil)
+	if err != nil {
+		return false
+	}
+	b, _, ok := t.Face.GlyphBounds(r)
+	if !ok {
+		return false
+	}
+	ox, oy := float64(dot.X)/64, float64(dot.Y)/64
+	// A pixel to spare either side for the filter to spread into.
+	minX := int(math.Floor(ox+float64(b.Min.X)/64)) - 1
+	maxX := int(math.Ceil(ox+float64(b.Max.X)/64)) + 1
+	minY := int(math.Floor(oy + float64(b.Min.Y)/64))
+	maxY := int(math.Ceil(oy + float64(b.Max.Y)/64))
+	area := image.Rect(minX, minY, maxX, maxY)
+	if area.Empty() || !area.In(dst.Bounds()) {
+		return false
+	}
+	for y := minY; y < maxY; y++ {
+		for x := minX; x < maxX; x++ {
+			if dst.Pix[dst.PixOffset(x, y)+3] != 255 {
+				return false
+			}
+		}
+	}
+
+	w, h := 3*area.Dx(), area.Dy()
+	z := vector.NewRasterizer(w, h)
+	pt := func(p fixed.Point26_6) (float32, float32) {
+		return float32(3 * (ox + float64(p.X)/64 - float64(minX))), float32(oy + float64(p.Y)/64 - float64(minY))
+	}
+	for _, s := range segs {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			z.MoveTo(pt(s.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			z.LineTo(pt(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			x1, y1 := pt(s.Args[0])
+			x2, y2 := pt(s.Args[1])
+			z.QuadTo(x1, y1, x2, y2)
+		case sfnt.SegmentOpCubeTo:
+			x1, y1 := pt(s.Args[0])
+			x2, y2 := pt(s.Args[1])
+			x3, y3 := pt(s.Args[2])
+			z.CubeTo(x1, y1, x2, y2, x3, y3)
+		}
+	}
+	cover := image.NewAlpha(image.Rect(0, 0, w, h))
+	z.Draw(cover, cover.Bounds(), image.Opaque, image.Point{})
+
+	cr, cg, cb, _ := col.RGBA()
+	src := [3]uint32{cr >> 8, cg >> 8, cb >> 8}
+	// The usual 1-2-3-2-1 filter across the thirds, so that the colours
+	// don't fringe.
+	weights := [5]uint32{1, 2, 3, 2, 1}
+	for y := 0; y < h; y++ {
+		row := cover.Pix[y*cover.Stride : y*cover.Stride+w]
+		for x := 0; x < area.Dx(); x++ {
+			i := dst.PixOffset(minX+x, minY+y)
+			for c := 0; c < 3; c++ {
+				var sum uint32
+				for k, wt := range weights {
+					if s := 3*x + c + k - 2; s >= 0 && s < w {
+						sum += uint32(row[s]) * wt
+					}
+				}
+				a := uint32(t.gamma[sum/9])
+				ch := c // the channel of this third of the pixel
+				if t.subpixel == SubpixelBGR {
+					ch = 2 - c
+				}
+				dst.Pix[i+ch] = uint8((uint32(dst.Pix[i+ch])*(255-a) + src[ch]*a) / 255)
+			}
+		}
+	}
+	return true
+}
+
+// drawGlyph draws the character c at the drawer's dot and advances it,
+// a subpixel at a time if the face is set up for it.
+func drawGlyph(d *font.Drawer, c rune, col color.Color) {
+	if t, ok := d.Face.(*tunedFace); ok && t.subpixel != SubpixelNone {
+		if dst, ok := d.Dst.(*image.RGBA); ok && t.drawSubpixel(dst, d.Dot, c, col) {
+			adv, _ := t.GlyphAdvance(c)
+			d.Dot.X += adv
+			return
+		}
+	}
+	d.DrawString(string(c))
+}
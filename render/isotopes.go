@@ -0,0 +1,79 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+// Isotopes lists the mass numbers of an element's stable isotopes. Elements
+// without any record their longest-lived isotope instead.
+type Isotopes struct {
+	Stable       []int `json:"stable"`
+	LongestLived int   `json:"longest_lived,omitempty"`
+}
+
+//go:embed data/isotopes.json
+var isotopesJSON []byte
+
+var IsotopesBySymbol = func() map[string]Isotopes {
+	m := map[string]Isotopes{}
+	if err := json.Unmarshal(isotopesJSON, &m); err != nil {
+		panic("data/isotopes.json: " + err.Error())
+	}
+	return m
+}()
+
+// ColourByStableIsotopes colours the cards as a heatmap of how many stable
+// isotopes each element has.
+const ColourByStableIsotopes = "stable-isotopes"
+
+// maxStableIsotopes is the most stable isotopes of any element, tin's ten.
+const maxStableIsotopes = 10
+
+// StableIsotopes is how many stable isotopes an element has, or -1 if the
+// isotope data doesn't have it.
+func StableIsotopes(symbol string) int {
+	iso, ok := IsotopesBySymbol[symbol]
+	if !ok {
+		return -1
+	}
+	return len(iso.Stable)
+}
+
+// stableIsotopesColours run from pale yellow for none through orange to
+// deep red for tin's ten, with grey for elements the data doesn't have.
+// -colours can change them by category name.
+var stableIsotopesColours = func() map[string]string {
+	m := map[string]string{"stable isotopes unknown": "#cccccc"}
+	for n := 0; n <= maxStableIsotopes; n++ {
+		f := float64(n) / maxStableIsotopes
+		m[fmt.Sprintf("stable isotopes %d", n)] = RGBHex(HSLToRGB(55-f*55, 0.85, 0.75-f*0.35))
+	}
+	return m
+}()
+
+// addStableIsotopesColours fills in the stable isotope categories missing
+// from -colours.
+func addStableIsotopesColours(r *Renderer) {
+	for category, c := range stableIsotopesColours {
+		if _, ok := r.Colours[category]; !ok {
+			r.Colours[category] = c
+		}
+	}
+}
+
+// stableIsotopesLabel is what -stable-isotopes writes on a card, or "" if
+// the isotope data doesn't have the element.
+func stableIsotopesLabel(symbol string) string {
+	switch n := StableIsotopes(symbol); n {
+	case -1:
+		return ""
+	case 0:
+		return "No stable isotopes"
+	case 1:
+		return "1 stable isotope"
+	default:
+		return fmt.Sprintf("%d stable isotopes", n)
+	}
+}
@@ -0,0 +1,67 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// lewisDots returns where the dots of an element's Lewis dot structure go
+// around the symbol, and their radius. Only the s- and p-block elements
+// have one; the others return no dots.
+func (r *Renderer) lewisDots(e Element) ([]Point, float64) {
+	mainGroup := e.XPos <= 2 || e.XPos >= 13
+	if !mainGroup || e.YPos > 7 || e.Valence < 1 || e.Valence > 8 {
+		return nil, 0
+	}
+
+	// The dots sit around the ink of the symbol, not its line height, so
+	// they hug the letters.
+	l := r.Layout()
+	ink, adv := BoundText(r.symFont, e.Symbol)
+	x0 := float64(r.TileW-adv.Round()) / 2
+	box := struct{ minX, minY, maxX, maxY float64 }{
+		x0 + float64(ink.Min.X.Floor()), float64(l.Symbol.Y + ink.Min.Y.Floor()),
+		x0 + float64(ink.Max.X.Ceil()), float64(l.Symbol.Y + ink.Max.Y.Ceil()),
+	}
+	rad := math.Max(1.5, float64(r.TileH)/70)
+	off := rad + float64(r.TileH)/50
+	cx, cy := (box.minX+box.maxX)/2, (box.minY+box.maxY)/2
+	sides := [4]Point{{cx, box.minY - off}, {box.maxX + off, cy}, {cx, box.maxY + off}, {box.minX - off, cy}}
+
+	// Each side gets one dot before any gets a second, going round from
+	// the top, except for helium's pair.
+	count := [4]int{}
+	if e.Number == 2 {
+		count[0] = 2
+	} else {
+		for i := range e.Valence {
+			count[i%4]++
+		}
+	}
+	var dots []Point
+	for i, s := range sides {
+		// Along the side: across for the top and bottom, down for the
+		// left and right.
+		dx, dy := 1.0, 0.0
+		if i%2 == 1 {
+			dx, dy = 0, 1
+		}
+		switch count[i] {
+		case 1:
+			dots = append(dots, s)
+		case 2:
+			d := rad * 1.8
+			dots = append(dots, Point{s.X - dx*d, s.Y - dy*d}, Point{s.X + dx*d, s.Y + dy*d})
+		}
+	}
+	return dots, rad
+}
+
+// drawLewis draws the dots of an element's Lewis dot structure.
+func (r *Renderer) drawLewis(img *image.RGBA, e Element) {
+	dots, rad := r.lewisDots(e)
+	for _, d := range dots {
+		FillCircle(img, d.X, d.Y, rad, color.Black)
+	}
+}
@@ -0,0 +1,70 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Magnetism is how an element responds to a magnetic field at room
+// temperature and, for superconductors, the temperature below which it
+// becomes one at normal pressure.
+type Magnetism struct {
+	Ordering         string  `json:"ordering"`                     // ferromagnetic, antiferromagnetic, paramagnetic or diamagnetic
+	SuperconductingK float64 `json:"superconducting_tc,omitempty"` // critical temperature in kelvin, 0 if it isn't one
+}
+
+//go:embed data/magnetism.json
+var magnetismJSON []byte
+
+// MagnetismBySymbol is the bundled data by element symbol. Elements whose
+// magnetism isn't known aren't in it.
+var MagnetismBySymbol = func() map[string]Magnetism {
+	m := map[string]Magnetism{}
+	if err := json.Unmarshal(magnetismJSON, &m); err != nil {
+		panic("data/magnetism.json: " + err.Error())
+	}
+	return m
+}()
+
+// ColourByMagnetism colours the cards by their magnetic ordering.
+const ColourByMagnetism = "magnetism"
+
+// MagnetismUnknown is the -colour-by magnetism category of elements
+// missing from the data.
+const MagnetismUnknown = "magnetism unknown"
+
+// magnetismColours go from the strongest response to a field to the
+// weakest. -colours can change them by category name.
+var magnetismColours = map[string]string{
+	"ferromagnetic":     "#d62728",
+	"antiferromagnetic": "#9467bd",
+	"paramagnetic":      "#ff9f40",
+	"diamagnetic":       "#4a90d9",
+	MagnetismUnknown:    "#cccccc",
+}
+
+// addMagnetismColours fills in the magnetism categories missing from
+// -colours.
+func addMagnetismColours(r *Renderer) {
+	for category, c := range magnetismColours {
+		if _, ok := r.Colours[category]; !ok {
+			r.Colours[category] = c
+		}
+	}
+}
+
+// magnetismLabel is what -magnetism writes on a card, such as
+// "Paramagnetic, Tc 9.25 K", or "" for an element it knows nothing about.
+func magnetismLabel(symbol string) string {
+	m, ok := MagnetismBySymbol[symbol]
+	if !ok {
+		return ""
+	}
+	label := strings.ToUpper(m.Ordering[:1]) + m.Ordering[1:]
+	if m.SuperconductingK > 0 {
+		label += fmt.Sprintf(", Tc %g K", m.SuperconductingK)
+	}
+	return label
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/text/unicode/norm"
+)
+
+// textLine is one line of text and where it goes on the card.
+type textLine struct {
+	pos TextPos
+	txt string
+}
+
+// nameLines returns the element name as it is drawn in face: on one line,
+// or with -wrap-names on two if it is too wide for the card there, centred on
+// where the single line would be and -name-spacing lines apart. It is
+// empty if a -preset leaves names off.
+func (r *Renderer) nameLines(l CardLayout, face font.Face, name string) []textLine {
+	if !r.showName() {
+		return nil
+	}
+	one := []textLine{{l.Name, name}}
+	if !r.Options.WrapNames || l.NameRadius > 0 || MeasureText(face, name).Round() <= r.nameRoom(l.Name.Y) {
+		return one
+	}
+	first, second := splitName(face, name)
+	if second == "" {
+		return one
+	}
+	step := int(float64(face.Metrics().Height.Round()) * r.Options.NameSpacing)
+	top, bottom := l.Name, l.Name
+	if r.Options.Style == StyleKids {
+		// The name sits on the bottom of the card, so it grows upwards.
+		top.Y -= step
+	} else {
+		top.Y -= step / 2
+		bottom.Y += step - step/2
+	}
+	return []textLine{{top, first}, {bottom, second}}
+}
+
+// nameRoom is how wide a centred name can be with its baseline at y.
+func (r *Renderer) nameRoom(y int) int {
+	bt, pad := r.borderThickness(), r.TileH/20
+	return widthAt(r.Outline()(r.TileW, r.TileH, float64(bt)), y) - 2*pad
+}
+
+// splitName breaks a name in two as evenly as it can: at a space or hyphen
+// if it has one, or else inside a word with a hyphen added. Names too
+// short to break come back whole.
+func splitName(face font.Face, name string) (string, string) {
+	width := func(s string) int { return MeasureText(face, s).Round() }
+	t := []rune(norm.NFC.String(name))
+	best, bestW := "", 0
+	var rest string
+	try := func(first, second string) {
+		if w := max(width(first), width(second)); best == "" || w < bestW {
+			best, bestW, rest = first, w, second
+		}
+	}
+	for i, c := range t {
+		if c == ' ' || c == '-' {
+			try(strings.TrimSpace(string(t[:i+1])), strings.TrimSpace(string(t[i+1:])))
+		}
+	}
+	if best != "" {
+		return best, rest
+	}
+	// Keep at least three letters on each line.
+	for i := 3; i <= len(t)-3; i++ {
+		if unicode.IsLetter(t[i-1]) && unicode.IsLetter(t[i]) {
+			try(string(t[:i])+"-", string(t[i:]))
+		}
+	}
+	if best == "" {
+		return name, ""
+	}
+	return best, rest
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// How an element occurs in nature, as the standard tables mark it.
+const (
+	// occurrencePrimordial elements have been on Earth since it formed.
+	occurrencePrimordial = "primordial"
+	// occurrenceFromDecay elements are only found as they are made by the
+	// decay of others, in traces too short-lived to have lasted.
+	occurrenceFromDecay = "from decay"
+	// occurrenceSynthetic elements are only made in reactors and
+	// accelerators.
+	occurrenceSynthetic = "synthetic"
+)
+
+// ColourByOccurrence colours the cards by natural occurrence.
+const ColourByOccurrence = "occurrence"
+
+// Occurrence classifies an element by its atomic number: every element up
+// to bismuth is primordial but technetium and promethium, which like the
+// rest up to plutonium are from decay except for thorium and uranium, and
+// everything heavier is synthetic.
+func Occurrence(number int) string {
+	switch {
+	case number == 43 || number == 61:
+		return occurrenceFromDecay
+	case number <= 83 || number == 90 || number == 92:
+		return occurrencePrimordial
+	case number <= 94:
+		return occurrenceFromDecay
+	}
+	return occurrenceSynthetic
+}
+
+// occurrenceColours are the -colour-by occurrence card colours. -colours
+// can change them by category name.
+var occurrenceColours = map[string]string{
+	occurrencePrimordial: "#5fa55a",
+	occurrenceFromDecay:  "#f2a93b",
+	occurrenceSynthetic:  "#7b68c4",
+}
+
+// addOccurrenceColours fills in the occurrence categories missing from
+// -colours.
+func addOccurrenceColours(r *Renderer) {
+	for category, c := range occurrenceColours {
+		if _, ok := r.Colours[category]; !ok {
+			r.Colours[category] = c
+		}
+	}
+}
+
+// occurrencePattern is the -occurrence hatching of a card: single lines
+// for elements from decay and a cross-hatch for synthetic ones. Primordial
+// elements aren't hatched.
+func occurrencePattern(occ string) fillPattern {
+	switch occ {
+	case occurrenceFromDecay:
+		return fillPattern{angles: []int{45}}
+	case occurrenceSynthetic:
+		return fillPattern{angles: []int{45, 135}}
+	}
+	return fillPattern{}
+}
+
+// hatchColour is the pale tint of a card's colour the hatching is drawn in.
+func hatchColour(c color.RGBA) color.RGBA {
+	h, s, _ := RGBToHSL(c)
+	return HSLToRGB(h, s, 0.85)
+}
+
+// hatchOccurrence hatches the white inside of an element's card for
+// -occurrence.
+func (r *Renderer) hatchOccurrence(img *image.RGBA, e Element) {
+	r.drawPattern(img, occurrencePattern(Occurrence(e.Number)), hatchColour(r.CategoryColour(e.Type)))
+}
+
+// occurrenceSVG returns the -occurrence hatching of an element's card as a
+// pattern filling the inside of the border, or "" if it has none.
+func (r *Renderer) occurrenceSVG(e Element) string {
+	return r.patternSVG(fmt.Sprintf("occurrence-%d", e.Number), occurrencePattern(Occurrence(e.Number)), hatchColour(r.CategoryColour(e.Type)))
+}
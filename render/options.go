@@ -0,0 +1,79 @@
+package render
+
+// Options says how the cards are drawn. Each field is the command's flag
+// of the same name, so the README's table of flags describes them in
+// more detail; start from DefaultOptions, which has the flags' defaults.
+type Options struct {
+	FontPath      string // .ttf or .otf font file, "" for Go Bold
+	ColoursPath   string // colours.json of colours by category; a palette is generated if it is missing
+	AliasesPath   string // JSON file mapping extra category names to the ones they stand for
+	GroupsPath    string // JSON file of your own categories and the elements in them
+	Preset        string // an exam board's table: gcse, ap-chem or ib
+	NotesPath     string // JSON file of notes by element symbol
+	Strict        bool   // fail on a missing colour or glyph, or text too wide, instead of drawing it
+	DebugLayout   bool   // draw the layout's guides over the cards
+	RandomStyle   bool   // give each card its own shade and background pattern, from Seed
+	Style         string // StyleClassic, StyleKids or StyleOutline
+	Illustrations string // with StyleKids, folder of pictures named after their element
+	Seed          int64  // for RandomStyle
+	ColourBy      string // ColourByCategory, ColourByValence, ColourByMagnetism, ColourByOccurrence or ColourByStableIsotopes
+
+	// What the cards show besides the number, symbol, name and mass.
+	Valence        bool   // number of valence electrons
+	Lewis          bool   // Lewis dot structure around the symbol
+	Compounds      bool   // a few common compounds
+	Shells         bool   // electrons in each shell
+	Magnetism      bool   // magnetic ordering at room temperature
+	Occurrence     bool   // hatching for elements from decay and synthetic ones
+	StableIsotopes bool   // number of stable isotopes
+	Label          string // expression worked out from the element's data, see cardExpr
+	PhaseBar       bool   // solid, liquid and gas ranges
+	Flame          bool   // flame test colour swatch
+	Spectrum       bool   // visible emission spectrum
+	SpectraPath    string // spectra file to use instead of the bundled data
+
+	Patterns  string // "auto", or JSON file of fill patterns by category
+	RulesPath string // JSON file of changes to the cards matching a condition, see rulesSpec
+	Greyscale bool   // colours as evenly spread greys, for black-and-white printing
+
+	// How the text is drawn.
+	Hinting     string  // full, vertical or none
+	Gamma       float64 // above 1 draws the text's edges heavier, below 1 lighter
+	Subpixel    string  // SubpixelNone, SubpixelRGB or SubpixelBGR
+	MassFormat  string  // MassFixed or MassIUPAC
+	MassUnit    string  // UnitNone, UnitU, UnitGMol or UnitBoth
+	VAlign      string  // vertical alignment, for every field or as field=alignment pairs
+	WrapNames   bool    // break names too wide for the card across two lines
+	NameSpacing float64 // distance between the lines of a wrapped name, in line heights
+
+	Supersample int    // draw the cards 2 or 4 times as big and scale them down
+	Resample    string // filter for scaling down, a key of Resamplers
+
+	Height      int    // in px
+	Width       int    // in px, 0 for the usual shape
+	ColourSpace string // ColourSpaceSRGB, ColourSpaceDisplayP3 or ColourSpaceCMYK
+	Shape       string // ShapeRect, ShapeHex or ShapeCircle
+	Bevel       bool   // shade the border like a raised tile
+}
+
+// DefaultOptions returns the options the command draws with when given
+// no flags.
+func DefaultOptions() Options {
+	return Options{
+		ColoursPath: "colours.json",
+		Style:       StyleClassic,
+		Seed:        1,
+		ColourBy:    ColourByCategory,
+		Hinting:     "full",
+		Gamma:       1,
+		Subpixel:    SubpixelNone,
+		MassFormat:  MassFixed,
+		MassUnit:    UnitNone,
+		NameSpacing: 1,
+		Supersample: 1,
+		Resample:    "catmull-rom",
+		Height:      600,
+		ColourSpace: ColourSpaceSRGB,
+		Shape:       ShapeRect,
+	}
+}
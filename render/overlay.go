@@ -0,0 +1,44 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// LoadImageFitted reads an image and scales it to fit inside box, keeping
+// its proportions, returning it and where it goes.
+func LoadImageFitted(path string, box image.Rectangle) (*image.RGBA, image.Rectangle, error) {
+	if path == "" {
+		return nil, box, fmt.Errorf("an image needs a src")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, box, err
+	}
+	defer f.Close()
+	src, _, err := image.Decode(f)
+	if err != nil {
+		return nil, box, fmt.Errorf("%s: %w", path, err)
+	}
+	sb := src.Bounds()
+	scale := math.Min(float64(box.Dx())/float64(sb.Dx()), float64(box.Dy())/float64(sb.Dy()))
+	w, h := max(1, int(float64(sb.Dx())*scale)), max(1, int(float64(sb.Dy())*scale))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(img, img.Bounds(), src, sb, xdraw.Src, nil)
+	at := box.Min.Add(image.Pt((box.Dx()-w)/2, (box.Dy()-h)/2))
+	return img, image.Rectangle{at, at.Add(image.Pt(w, h))}, nil
+}
+
+// StrokePolygon draws a closed outline with round joins.
+func StrokePolygon(img *image.RGBA, pts []Point, width float64, c color.Color) {
+	for i, a := range pts {
+		b := pts[(i+1)%len(pts)]
+		DrawLine(img, a.X, a.Y, b.X, b.Y, width, c)
+		FillCircle(img, a.X, a.Y, width/2, c)
+	}
+}
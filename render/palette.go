@@ -0,0 +1,83 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"math/rand/v2"
+)
+
+// PaletteConfig shapes a generated palette: the categories get hues spread
+// evenly from hueFrom to hueTo degrees, in an order shuffled by seed, at
+// the given saturation and lightness (0 to 1).
+type PaletteConfig struct {
+	HueFrom, HueTo float64
+	Saturation     float64
+	Lightness      float64
+	Seed           uint64
+}
+
+var DefaultPalette = PaletteConfig{HueFrom: 0, HueTo: 360, Saturation: 0.55, Lightness: 0.58, Seed: 1}
+
+// GeneratePalette returns a colour for every category. Neighbouring hues
+// are kept apart by alternating the lightness a little, and "unknown" is a
+// grey at the same lightness.
+func GeneratePalette(c PaletteConfig) Colours {
+	named := categories[:len(categories)-1] // all but "unknown"
+	span := c.HueTo - c.HueFrom
+	step := span / float64(len(named))
+	if math.Abs(span) < 360 {
+		// The ends of a partial range are different colours, so use both.
+		step = span / float64(len(named)-1)
+	}
+	rng := rand.New(rand.NewPCG(c.Seed, c.Seed))
+	order := rng.Perm(len(named))
+	jitter := rng.Float64() * step / 2
+
+	colours := Colours{}
+	for i, category := range named {
+		n := order[i]
+		h := c.HueFrom + float64(n)*step
+		if math.Abs(span) >= 360 {
+			h += jitter
+		}
+		l := c.Lightness
+		if n%2 == 1 {
+			l = min(1, l+0.1)
+		}
+		colours[category] = RGBHex(HSLToRGB(h, c.Saturation, l))
+	}
+	colours["unknown"] = RGBHex(HSLToRGB(0, 0, c.Lightness))
+	return colours
+}
+
+// HSLToRGB converts a hue in degrees and saturation and lightness from 0
+// to 1 to a colour.
+func HSLToRGB(h, s, l float64) color.RGBA {
+	h = math.Mod(math.Mod(h, 360)+360, 360)
+	ch := (1 - math.Abs(2*l-1)) * s
+	x := ch * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = ch, x
+	case h < 120:
+		r, g = x, ch
+	case h < 180:
+		g, b = ch, x
+	case h < 240:
+		g, b = x, ch
+	case h < 300:
+		r, b = x, ch
+	default:
+		r, b = ch, x
+	}
+	m := l - ch/2
+	to8 := func(v float64) uint8 { return uint8(math.Round(math.Max(0, math.Min(1, v+m)) * 255)) }
+	return color.RGBA{to8(r), to8(g), to8(b), 255}
+}
+
+func RGBHex(c color.RGBA) string { return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B) }
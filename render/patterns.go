@@ -0,0 +1,190 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+)
+
+// fillPattern is a pattern of lines or dots filling the inside of a card,
+// so cards can be told apart without their colours.
+type fillPattern struct {
+	angles []int // of the lines, in degrees: 0 across, 45 rising, 90 down or 135 falling
+	dots   bool
+	dense  bool // twice as many lines or dots
+}
+
+type namedPattern struct {
+	name    string
+	pattern fillPattern
+}
+
+// fillPatterns are the patterns -patterns accepts, by name, in the order
+// "auto" gives them out after none.
+var fillPatterns = []namedPattern{
+	{"none", fillPattern{}},
+	{"hatch", fillPattern{angles: []int{45}}},
+	{"dots", fillPattern{dots: true}},
+	{"crosshatch", fillPattern{angles: []int{45, 135}}},
+	{"horizontal", fillPattern{angles: []int{0}}},
+	{"backhatch", fillPattern{angles: []int{135}}},
+	{"grid", fillPattern{angles: []int{0, 90}}},
+	{"vertical", fillPattern{angles: []int{90}}},
+	{"dense-dots", fillPattern{dots: true, dense: true}},
+	{"dense-hatch", fillPattern{angles: []int{45}, dense: true}},
+	{"dense-backhatch", fillPattern{angles: []int{135}, dense: true}},
+}
+
+func lookupFillPattern(name string) (fillPattern, bool) {
+	i := slices.IndexFunc(fillPatterns, func(p namedPattern) bool { return p.name == name })
+	if i < 0 {
+		return fillPattern{}, false
+	}
+	return fillPatterns[i].pattern, true
+}
+
+func (p fillPattern) none() bool { return len(p.angles) == 0 && !p.dots }
+
+// step is how far apart the lines or dots are on a card tileH high.
+func (p fillPattern) step(tileH int) int {
+	step := max(4, tileH/12)
+	if p.dense {
+		step = max(3, step/2)
+	}
+	return step
+}
+
+// lineWidth is how thick the lines are, or the radius of the dots.
+func (p fillPattern) lineWidth(step int) int { return max(1, step/5) }
+
+// on reports whether the pattern covers a pixel.
+func (p fillPattern) on(x, y, step int) bool {
+	w := p.lineWidth(step)
+	if p.dots {
+		dx, dy := x%step-step/2, y%step-step/2
+		return dx*dx+dy*dy <= w*w
+	}
+	// Diagonals repeat further along each axis, so that they are step
+	// apart square on to them like the others.
+	diag := int(math.Round(float64(step) * math.Sqrt2))
+	for _, a := range p.angles {
+		var on bool
+		switch a {
+		case 0:
+			on = y%step < w
+		case 90:
+			on = x%step < w
+		case 45:
+			on = (x+y)%diag < int(math.Round(float64(w)*math.Sqrt2))
+		case 135:
+			on = ((x-y)%diag+diag)%diag < int(math.Round(float64(w)*math.Sqrt2))
+		}
+		if on {
+			return true
+		}
+	}
+	return false
+}
+
+// drawPattern draws the pattern in c over the white inside of a card,
+// leaving its border and anything already drawn on it alone.
+func (r *Renderer) drawPattern(img *image.RGBA, p fillPattern, c color.RGBA) {
+	if p.none() {
+		return
+	}
+	step := p.step(r.TileH)
+	for y := 0; y < r.TileH; y++ {
+		for x := 0; x < r.TileW; x++ {
+			if p.on(x, y, step) && img.RGBAAt(x, y) == (color.RGBA{255, 255, 255, 255}) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// patternSVG returns the pattern as an SVG pattern with the given id and a
+// shape filling the inside of the border with it, or "" for none.
+func (r *Renderer) patternSVG(id string, p fillPattern, c color.RGBA) string {
+	if p.none() {
+		return ""
+	}
+	step := p.step(r.TileH)
+	w := p.lineWidth(step)
+	var body, transform string
+	if p.dots {
+		body = fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="%d" fill="%s"/>`, float64(step)/2, float64(step)/2, w, RGBHex(c))
+	} else {
+		// Lines across or down the top left of each tile, with the whole
+		// pattern turned for the diagonals.
+		var sb strings.Builder
+		for _, a := range p.angles {
+			if a == 45 || a == 135 {
+				transform = ` patternTransform="rotate(-45)"`
+			}
+			if a == 0 || a == 45 {
+				fmt.Fprintf(&sb, `<line x1="0" y1="%.1f" x2="%d" y2="%.1f"/>`, float64(w)/2, step, float64(w)/2)
+			} else {
+				fmt.Fprintf(&sb, `<line x1="%.1f" y1="0" x2="%.1f" y2="%d"/>`, float64(w)/2, float64(w)/2, step)
+			}
+		}
+		body = fmt.Sprintf(`<g stroke="%s" stroke-width="%d">%s</g>`, RGBHex(c), w, sb.String())
+	}
+	return fmt.Sprintf(`<pattern id="%s" width="%d" height="%d" patternUnits="userSpaceOnUse"%s>%s</pattern>`, id, step, step, transform, body) +
+		SVGCardShape(r, float64(r.borderThickness()), fmt.Sprintf(`fill="url(#%s)"`, id))
+}
+
+// patternColour is the pale tint of a card's colour its pattern is drawn
+// in, dark enough to survive a photocopier without hiding the text.
+func patternColour(c color.RGBA) color.RGBA {
+	h, s, _ := RGBToHSL(c)
+	return HSLToRGB(h, s, 0.8)
+}
+
+// loadPatterns reads -patterns: "auto" to give each category its own
+// pattern, or a JSON file of pattern names by category, such as
+// {"noble gas": "dots"}. Category names are normalised.
+func loadPatterns(value string, r *Renderer) (map[string]fillPattern, error) {
+	patterns := map[string]fillPattern{}
+	switch value {
+	case "":
+		return patterns, nil
+	case "auto":
+		// The usual categories in order, then any others by name.
+		order := slices.Clone(categories)
+		for _, c := range slices.Sorted(maps.Keys(r.Colours)) {
+			if !slices.Contains(order, c) {
+				order = append(order, c)
+			}
+		}
+		for i, c := range order {
+			patterns[c] = fillPatterns[1+i%(len(fillPatterns)-1)].pattern
+		}
+		return patterns, nil
+	}
+	bs, err := os.ReadFile(value)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(bs, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", value, err)
+	}
+	for category, name := range raw {
+		p, ok := lookupFillPattern(name)
+		if !ok {
+			var names []string
+			for _, fp := range fillPatterns {
+				names = append(names, fp.name)
+			}
+			return nil, fmt.Errorf("%s: unknown pattern %q for %q (want one of %s)", value, name, category, strings.Join(names, ", "))
+		}
+		patterns[r.Category(category)] = p
+	}
+	return patterns, nil
+}
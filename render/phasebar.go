@@ -0,0 +1,87 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// The phase bar's scale is logarithmic from 1 K to 10,000 K, so helium's
+// few kelvin and tungsten's thousands show on the same bar.
+const (
+	phaseBarDecades = 4
+	RoomTemperature = 293.15
+)
+
+// phaseBarX returns how far along the bar, from 0 to 1, k kelvin is.
+func phaseBarX(k float64) float64 {
+	return min(1, max(0, math.Log10(max(k, 1))/phaseBarDecades))
+}
+
+// phaseBarSegments returns the solid, liquid and gas spans of an element's
+// bar as fractions of its length, in phaseColours. Elements without a
+// melting point have none.
+func phaseBarSegments(e Element) (spans [][2]float64, phases []string) {
+	if e.Melt == 0 {
+		return nil, nil
+	}
+	m := phaseBarX(e.Melt)
+	spans, phases = [][2]float64{{0, m}}, []string{PhaseSolid}
+	if e.Boil == 0 {
+		return append(spans, [2]float64{m, 1}), append(phases, PhaseLiquid)
+	}
+	b := phaseBarX(e.Boil)
+	return append(spans, [2]float64{m, b}, [2]float64{b, 1}), append(phases, PhaseLiquid, PhaseGas)
+}
+
+// phaseBarTrack returns the bar itself within its band, half as tall.
+func phaseBarTrack(rect image.Rectangle) image.Rectangle {
+	h := max(2, rect.Dy()/2)
+	y := rect.Min.Y + (rect.Dy()-h)/2
+	return image.Rect(rect.Min.X, y, rect.Max.X, y+h)
+}
+
+// DrawPhaseBar draws a thermometer-style bar of an element's states of
+// matter, coloured like the temperature mode, with a tick at room
+// temperature.
+func DrawPhaseBar(img *image.RGBA, rect image.Rectangle, e Element) {
+	spans, phases := phaseBarSegments(e)
+	if spans == nil {
+		return
+	}
+	track := phaseBarTrack(rect)
+	line := max(1, track.Dy()/6)
+	draw.Draw(img, track, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	inner := track.Inset(line)
+	for i, s := range spans {
+		x0 := inner.Min.X + int(s[0]*float64(inner.Dx()))
+		x1 := inner.Min.X + int(s[1]*float64(inner.Dx()))
+		draw.Draw(img, image.Rect(x0, inner.Min.Y, x1, inner.Max.Y), image.NewUniform(HexToRGBA(PhaseColours[phases[i]])), image.Point{}, draw.Src)
+	}
+	x := inner.Min.X + int(phaseBarX(RoomTemperature)*float64(inner.Dx()))
+	draw.Draw(img, image.Rect(x-line/2, rect.Min.Y, x-line/2+line, rect.Max.Y), image.NewUniform(color.Black), image.Point{}, draw.Src)
+}
+
+// phaseBarSVG returns the phase bar as SVG rectangles.
+func phaseBarSVG(rect image.Rectangle, e Element) string {
+	spans, phases := phaseBarSegments(e)
+	if spans == nil {
+		return ""
+	}
+	track := phaseBarTrack(rect)
+	line := max(1, track.Dy()/6)
+	inner := track.Inset(line)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="#000"/>`, track.Min.X, track.Min.Y, track.Dx(), track.Dy())
+	for i, s := range spans {
+		x0 := inner.Min.X + int(s[0]*float64(inner.Dx()))
+		x1 := inner.Min.X + int(s[1]*float64(inner.Dx()))
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, x0, inner.Min.Y, x1-x0, inner.Dy(), PhaseColours[phases[i]])
+	}
+	x := inner.Min.X + int(phaseBarX(RoomTemperature)*float64(inner.Dx()))
+	fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="#000"/>`, x-line/2, rect.Min.Y, line, rect.Dy())
+	return sb.String()
+}
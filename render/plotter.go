@@ -0,0 +1,448 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// plotterDropped are the SVG elements a pen plotter has no use for, left
+// out of -plotter output with everything inside them.
+var plotterDropped = map[string]bool{
+	"style": true, "script": true, "title": true, "filter": true, "pattern": true, "image": true,
+}
+
+// plotterShapes are the SVG elements a plotter draws round.
+var plotterShapes = map[string]bool{
+	"rect": true, "circle": true, "ellipse": true, "line": true, "polyline": true, "polygon": true, "path": true,
+}
+
+// SVGOutliner turns the text of SVG into the outlines of its glyphs from
+// the -font, so it looks the same without the font. For a pen plotter it
+// goes further: nothing is filled, and every shape is stroked once with a
+// pen pen px wide.
+type SVGOutliner struct {
+	font *sfnt.Font
+	buf  sfnt.Buffer
+	pen  float64 // 0 to only outline the text
+
+	paths map[string]string // d of each path with an id, for textPaths
+	out   bytes.Buffer
+}
+
+func NewPlotter(fontPath string, pen float64) (*SVGOutliner, error) {
+	if pen <= 0 {
+		return nil, fmt.Errorf("-pen-width must be more than 0")
+	}
+	o, err := NewTextOutliner(fontPath)
+	if err != nil {
+		return nil, err
+	}
+	o.pen = pen
+	return o, nil
+}
+
+// NewTextOutliner returns an svgOutliner that only outlines the text.
+func NewTextOutliner(fontPath string) (*SVGOutliner, error) {
+	bs, err := ReadFont(fontPath)
+	if err != nil {
+		return nil, err
+	}
+	f, err := opentype.Parse(bs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fontPath, err)
+	}
+	return &SVGOutliner{font: f, paths: map[string]string{}}, nil
+}
+
+// WriteOutlined writes the SVG write writes, through o if it isn't nil.
+func WriteOutlined(w io.Writer, o *SVGOutliner, write func(io.Writer) error) error {
+	if o == nil {
+		return write(w)
+	}
+	var b bytes.Buffer
+	if err := write(&b); err != nil {
+		return err
+	}
+	out, err := o.rewrite(b.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// paint is the fill and stroke an element has, its own or inherited.
+type paint struct{ fill, stroke string }
+
+// rewrite returns svg with its text outlined, made ready for the plotter
+// if there is one.
+func (p *SVGOutliner) rewrite(svg []byte) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	stack := []paint{{fill: "#000", stroke: "none"}}
+	skip := 0 // depth inside a dropped element
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 || p.pen > 0 && plotterDropped[t.Name.Local] {
+				skip++
+				continue
+			}
+			inherited := stack[len(stack)-1]
+			own := inherited
+			if v, ok := attr(t, "fill"); ok {
+				own.fill = v
+			}
+			if v, ok := attr(t, "stroke"); ok {
+				own.stroke = v
+			}
+			if t.Name.Local == "text" {
+				if err := p.text(d, t, own); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if id, ok := attr(t, "id"); ok && t.Name.Local == "path" {
+				p.paths[id], _ = attr(t, "d")
+			}
+			stack = append(stack, own)
+			if p.pen == 0 {
+				p.start(t, t.Attr)
+				continue
+			}
+			if !plotterShapes[t.Name.Local] {
+				p.start(t, setAttr(t.Attr, "filter", ""))
+				continue
+			}
+			stroke := own.stroke
+			if stroke == "none" {
+				stroke = own.fill
+			}
+			_, hasID := attr(t, "id")
+			if !plainColour(stroke) || isWhite(stroke) && !plainColour(own.stroke) {
+				// Nothing to draw but a fill, or white paper on white
+				// paper. A path with an id may be one text follows.
+				if !hasID {
+					p.skipElement(d)
+					stack = stack[:len(stack)-1]
+					continue
+				}
+				stroke = "none"
+			}
+			a := setAttr(t.Attr, "fill", "none")
+			a = setAttr(a, "stroke", stroke)
+			a = setAttr(a, "stroke-width", strconv.FormatFloat(p.pen, 'f', -1, 64))
+			a = setAttr(a, "filter", "")
+			p.start(t, a)
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			fmt.Fprintf(&p.out, "</%s>", qualified(t.Name))
+		case xml.CharData:
+			if skip == 0 {
+				p.out.WriteString(html.EscapeString(string(t)))
+			}
+		case xml.ProcInst, xml.Directive, xml.Comment:
+			// Nothing the generator writes.
+		}
+	}
+	return p.out.Bytes(), nil
+}
+
+// skipElement reads past the rest of the element just started.
+func (p *SVGOutliner) skipElement(d *xml.Decoder) {
+	for depth := 1; depth > 0; {
+		tok, err := d.RawToken()
+		if err != nil {
+			return
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+func (p *SVGOutliner) start(t xml.StartElement, attrs []xml.Attr) {
+	fmt.Fprintf(&p.out, "<%s", qualified(t.Name))
+	for _, a := range attrs {
+		fmt.Fprintf(&p.out, ` %s="%s"`, qualified(a.Name), html.EscapeString(a.Value))
+	}
+	p.out.WriteString(">")
+}
+
+// textRun is part of a text element in one size.
+type textRun struct {
+	txt  string
+	size float64
+	dy   float64 // down from the baseline, for subscripts
+}
+
+// text turns a text element, whose start has been read, into a path of
+// its glyphs' outlines, stroked in the colour the text would have been.
+func (p *SVGOutliner) text(d *xml.Decoder, t xml.StartElement, own paint) error {
+	num := func(t xml.StartElement, name string, def float64) float64 {
+		if v, ok := attr(t, name); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+		return def
+	}
+	x, y := num(t, "x", 0), num(t, "y", 0)
+	anchor, _ := attr(t, "text-anchor")
+	baseline, _ := attr(t, "dominant-baseline")
+	var runs []textRun
+	var onPath string
+	sizes := []float64{num(t, "font-size", 16)}
+	shifts := []float64{0}
+	for depth := 1; depth > 0; {
+		tok, err := d.RawToken()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			size, shift := sizes[len(sizes)-1], shifts[len(shifts)-1]
+			if bs, _ := attr(t, "baseline-shift"); bs == "sub" {
+				shift += size / 4
+			}
+			sizes = append(sizes, num(t, "font-size", size))
+			shifts = append(shifts, shift)
+			if t.Name.Local == "textPath" {
+				onPath, _ = attr(t, "href")
+				if a, ok := attr(t, "text-anchor"); ok {
+					anchor = a
+				}
+			}
+		case xml.EndElement:
+			depth--
+			sizes, shifts = sizes[:len(sizes)-1], shifts[:len(shifts)-1]
+		case xml.CharData:
+			if depth > 0 && strings.TrimSpace(string(t)) != "" {
+				runs = append(runs, textRun{string(t), sizes[len(sizes)-1], shifts[len(shifts)-1]})
+			}
+		}
+	}
+
+	colour := own.fill
+	if !plainColour(colour) {
+		colour = own.stroke
+	}
+	if p.pen > 0 && !plainColour(colour) {
+		return nil
+	}
+
+	width := 0.0
+	for _, run := range runs {
+		width += p.advance(run)
+	}
+	var sb strings.Builder
+	if onPath != "" {
+		if err := p.arcText(&sb, p.paths[strings.TrimPrefix(onPath, "#")], runs, width); err != nil {
+			return err
+		}
+	} else {
+		switch anchor {
+		case "middle":
+			x -= width / 2
+		case "end":
+			x -= width
+		}
+		if baseline == "central" && len(runs) > 0 {
+			m, _ := p.font.Metrics(&p.buf, p.ppem(runs[0].size), font.HintingNone)
+			y += float64(m.Ascent-m.Descent) / 64 / 2
+		}
+		for _, run := range runs {
+			p.glyphs(run, func(gi sfnt.GlyphIndex, at, _ float64) {
+				ox, oy := x+at, y+run.dy
+				p.outline(&sb, gi, run.size, func(gx, gy float64) (float64, float64) { return ox + gx, oy + gy })
+			})
+			x += p.advance(run)
+		}
+	}
+	switch {
+	case sb.Len() == 0:
+	case p.pen > 0:
+		fmt.Fprintf(&p.out, `<path d="%s" fill="none" stroke="%s" stroke-width="%s"/>`,
+			strings.TrimSpace(sb.String()), html.EscapeString(colour), strconv.FormatFloat(p.pen, 'f', -1, 64))
+	default:
+		// Painted as the text was, inheriting what it inherited.
+		fmt.Fprintf(&p.out, `<path d="%s"`, strings.TrimSpace(sb.String()))
+		for _, name := range []string{"fill", "stroke", "stroke-width", "opacity"} {
+			if v, ok := attr(t, name); ok {
+				fmt.Fprintf(&p.out, ` %s="%s"`, name, html.EscapeString(v))
+			}
+		}
+		p.out.WriteString("/>")
+	}
+	return nil
+}
+
+// arcText lays runs out centred along the top of a circle, as the names
+// go round -shape circle cards. d is the arc the SVG text follows, a half
+// circle from its left to its right.
+func (p *SVGOutliner) arcText(sb *strings.Builder, d string, runs []textRun, width float64) error {
+	var x1, y1, rx, ry, x2, y2 float64
+	if _, err := fmt.Sscanf(d, "M %g %g A %g %g 0 0 1 %g %g", &x1, &y1, &rx, &ry, &x2, &y2); err != nil {
+		return fmt.Errorf("can't outline text along the path %q", d)
+	}
+	cx, cy, r := (x1+x2)/2, y1, rx
+	pos := -width / 2 // arc length from the top
+	for _, run := range runs {
+		p.glyphs(run, func(gi sfnt.GlyphIndex, at, adv float64) {
+			// Turned about the middle of the glyph's baseline, which sits
+			// on the circle at angle a clockwise from the top.
+			a := (pos + at + adv/2) / r
+			sin, cos := math.Sin(a), math.Cos(a)
+			px, py := cx+r*sin, cy-r*cos
+			p.outline(sb, gi, run.size, func(gx, gy float64) (float64, float64) {
+				gx -= adv / 2
+				gy += run.dy
+				return px + cos*gx - sin*gy, py + sin*gx + cos*gy
+			})
+		})
+		pos += p.advance(run)
+	}
+	return nil
+}
+
+func (p *SVGOutliner) ppem(size float64) fixed.Int26_6 { return fixed.Int26_6(math.Round(size * 64)) }
+
+// glyphs calls fn with each glyph of a run, how far along the run it
+// starts and how far it advances, all in px.
+func (p *SVGOutliner) glyphs(run textRun, fn func(gi sfnt.GlyphIndex, at, adv float64)) {
+	ppem := p.ppem(run.size)
+	at := 0.0
+	prev := sfnt.GlyphIndex(0)
+	for _, c := range run.txt {
+		gi, err := p.font.GlyphIndex(&p.buf, c)
+		if err != nil || gi == 0 {
+			continue
+		}
+		if prev != 0 {
+			if k, err := p.font.Kern(&p.buf, prev, gi, ppem, font.HintingNone); err == nil {
+				at += float64(k) / 64
+			}
+		}
+		adv, err := p.font.GlyphAdvance(&p.buf, gi, ppem, font.HintingNone)
+		if err != nil {
+			continue
+		}
+		if fn != nil {
+			fn(gi, at, float64(adv)/64)
+		}
+		at += float64(adv) / 64
+		prev = gi
+	}
+}
+
+// advance is how wide a run is in px.
+func (p *SVGOutliner) advance(run textRun) float64 {
+	var last, lastAdv float64
+	p.glyphs(run, func(_ sfnt.GlyphIndex, at, adv float64) { last, lastAdv = at, adv })
+	return last + lastAdv
+}
+
+// outline writes the outline of a glyph as path data, each point moved by
+// to from the glyph's origin on its baseline.
+func (p *SVGOutliner) outline(sb *strings.Builder, gi sfnt.GlyphIndex, size float64, to func(x, y float64) (float64, float64)) {
+	segs, err := p.font.LoadGlyph(&p.buf, gi, p.ppem(size), nil)
+	if err != nil {
+		return
+	}
+	pt := func(f fixed.Point26_6) string {
+		x, y := to(float64(f.X)/64, float64(f.Y)/64)
+		return fmt.Sprintf("%.2f %.2f", x, y)
+	}
+	for i, s := range segs {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if i > 0 {
+				sb.WriteString("Z ")
+			}
+			fmt.Fprintf(sb, "M %s ", pt(s.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			fmt.Fprintf(sb, "L %s ", pt(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			fmt.Fprintf(sb, "Q %s %s ", pt(s.Args[0]), pt(s.Args[1]))
+		case sfnt.SegmentOpCubeTo:
+			fmt.Fprintf(sb, "C %s %s %s ", pt(s.Args[0]), pt(s.Args[1]), pt(s.Args[2]))
+		}
+	}
+	if len(segs) > 0 {
+		sb.WriteString("Z ")
+	}
+}
+
+// attr returns the value of an element's attribute.
+func attr(t xml.StartElement, name string) (string, bool) {
+	for _, a := range t.Attr {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// setAttr returns attrs with name set to value, or taken out for "".
+func setAttr(attrs []xml.Attr, name, value string) []xml.Attr {
+	var out []xml.Attr
+	set := false
+	for _, a := range attrs {
+		if a.Name.Space == "" && a.Name.Local == name {
+			if value != "" && !set {
+				out = append(out, xml.Attr{Name: a.Name, Value: value})
+				set = true
+			}
+			continue
+		}
+		out = append(out, a)
+	}
+	if value != "" && !set {
+		out = append(out, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	}
+	return out
+}
+
+func qualified(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+// plainColour reports whether an SVG paint is a colour a pen can draw,
+// not none or a pattern.
+func plainColour(c string) bool { return c != "" && c != "none" && !strings.HasPrefix(c, "url(") }
+
+func isWhite(c string) bool {
+	switch strings.ToLower(c) {
+	case "#fff", "#ffffff", "white":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Preset is the table an exam board prints for its students: which
+// elements are on it, what each card shows and how the masses are rounded.
+type Preset struct {
+	Elements       string         `json:"elements"` // e.g. "1-20, Fe, Cu"; every element if empty
+	Fields         []string       `json:"fields"`   // of number, symbol, name and mass
+	MassDecimals   int            `json:"mass_decimals"`
+	MassExceptions map[string]int `json:"mass_exceptions"` // decimals for the elements rounded differently, by symbol
+}
+
+//go:embed data/presets.json
+var presetsJSON []byte
+
+// presets are the -preset choices, by name.
+var presets = func() map[string]Preset {
+	m := map[string]Preset{}
+	if err := json.Unmarshal(presetsJSON, &m); err != nil {
+		panic("data/presets.json: " + err.Error())
+	}
+	for name, p := range m {
+		for _, f := range p.Fields {
+			if f != "number" && f != "symbol" && f != "name" && f != "mass" {
+				panic(fmt.Sprintf("data/presets.json: %s: unknown field %q", name, f))
+			}
+		}
+		if !slices.Contains(p.Fields, "number") || !slices.Contains(p.Fields, "symbol") {
+			panic(fmt.Sprintf("data/presets.json: %s: every card has its number and symbol", name))
+		}
+	}
+	return m
+}()
+
+// LookupPreset returns the -preset named, or nil for none.
+func LookupPreset(name string) (*Preset, error) {
+	if name == "" {
+		return nil, nil
+	}
+	p, ok := presets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown -preset %q (want %s)", name, strings.Join(slices.Sorted(maps.Keys(presets)), ", "))
+	}
+	return &p, nil
+}
+
+// Filter keeps the elements on the preset's table, given by symbol, atomic
+// number or a range of atomic numbers such as 1-20.
+func (p *Preset) Filter(elements []Element) ([]Element, error) {
+	if p.Elements == "" {
+		return elements, nil
+	}
+	keep := map[int]bool{}
+	for _, item := range strings.Split(p.Elements, ",") {
+		item = strings.TrimSpace(item)
+		if from, to, ok := strings.Cut(item, "-"); ok {
+			lo, err1 := strconv.Atoi(from)
+			hi, err2 := strconv.Atoi(to)
+			if err1 != nil || err2 != nil || lo > hi {
+				return nil, fmt.Errorf("bad range %q in -preset elements", item)
+			}
+			for n := lo; n <= hi; n++ {
+				keep[n] = true
+			}
+			continue
+		}
+		// A -data file may not have every element on the table.
+		if i := indexElement(elements, item); i >= 0 {
+			keep[elements[i].Number] = true
+		}
+	}
+	return slices.DeleteFunc(elements, func(e Element) bool { return !keep[e.Number] }), nil
+}
+
+// shows reports whether the cards have field on them.
+func (p *Preset) shows(field string) bool { return p == nil || slices.Contains(p.Fields, field) }
+
+// showName reports whether the cards have the element's name on them.
+func (r *Renderer) showName() bool { return r.preset.shows("name") }
+
+// formatMass rounds a mass as the exam board does. A whole-number mass is
+// the mass number of the longest-lived isotope of an element without a
+// stable one, which goes in square brackets.
+func (p *Preset) formatMass(e Element) string {
+	if e.Weight.MassNumber || e.Mass > 0 && e.Mass == math.Trunc(e.Mass) {
+		return fmt.Sprintf("[%d]", int(math.Round(e.Mass)))
+	}
+	decimals, ok := p.MassExceptions[e.Symbol]
+	if !ok {
+		decimals = p.MassDecimals
+	}
+	return strconv.FormatFloat(e.Mass, 'f', decimals, 64)
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+)
+
+// Background patterns of -random-style.
+const (
+	patternNone = iota
+	patternStripes
+	patternDots
+	patternChecks
+	patternCount
+)
+
+// randomBackground draws an element's card for -random-style: its category
+// colour turned up to 40° round the colour wheel, with a pale pattern of
+// that colour inside. The choices come from -seed and the atomic number
+// alone, so each card is the same every run and doesn't depend on which
+// other elements are drawn.
+func (r *Renderer) randomBackground(e Element) *image.RGBA {
+	rng := rand.New(rand.NewPCG(uint64(r.Options.Seed), uint64(e.Number)))
+	h, s, l := RGBToHSL(r.CategoryColour(e.Type))
+	h += rng.Float64()*80 - 40
+	s = math.Min(1, math.Max(0, s+rng.Float64()*0.2-0.1))
+	img := r.drawBackground(HSLToRGB(h, s, l))
+
+	tint := HSLToRGB(h, s, 0.92)
+	step := max(4, r.TileH/(8+rng.IntN(8)))
+	pattern := rng.IntN(patternCount)
+	slope := 1
+	if rng.IntN(2) == 0 {
+		slope = -1
+	}
+	on := func(x, y int) bool {
+		switch pattern {
+		case patternStripes:
+			return ((x+slope*y)%step+step)%step < step/2
+		case patternDots:
+			dx, dy := x%step-step/2, y%step-step/2
+			return dx*dx+dy*dy < step*step/10
+		case patternChecks:
+			return (x/step+y/step)%2 == 0
+		}
+		return false
+	}
+	// Only the white inside of the card is patterned, leaving the border
+	// and the corners outside the shape alone.
+	for y := 0; y < r.TileH; y++ {
+		for x := 0; x < r.TileW; x++ {
+			if img.RGBAAt(x, y) == (color.RGBA{255, 255, 255, 255}) && on(x, y) {
+				img.SetRGBA(x, y, tint)
+			}
+		}
+	}
+	return img
+}
+
+// RGBToHSL converts a colour to a hue in degrees and saturation and
+// lightness from 0 to 1, the inverse of hslToRGB.
+func RGBToHSL(c color.RGBA) (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (hi + lo) / 2
+	d := hi - lo
+	if d == 0 {
+		return 0, 0, l
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch hi {
+	case r:
+		h = 60 * math.Mod((g-b)/d+6, 6)
+	case g:
+		h = 60 * ((b-r)/d + 2)
+	default:
+		h = 60 * ((r-g)/d + 4)
+	}
+	return h, s, l
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// Stroke styles accepted by the "style" of overlay items and -region-style.
+const (
+	StrokeSolid  = "solid"
+	strokeDashed = "dashed"
+	strokeDotted = "dotted"
+)
+
+func CheckStrokeStyle(style string) error {
+	switch style {
+	case "", StrokeSolid, strokeDashed, strokeDotted:
+		return nil
+	}
+	return fmt.Errorf("unknown stroke style %q (want %s, %s or %s)", style, StrokeSolid, strokeDashed, strokeDotted)
+}
+
+// dashPattern returns the lengths of the dashes and the gaps between them
+// for a style, or zero for a solid line. Dotted lines are dashes of no
+// length, drawn as round dots.
+func dashPattern(style string, width float64) (dash, gap float64) {
+	switch style {
+	case strokeDashed:
+		return width * 3, width * 2
+	case strokeDotted:
+		return 0, width * 2
+	}
+	return 0, 0
+}
+
+// StrokeStyled draws a closed outline in a stroke style.
+func StrokeStyled(img *image.RGBA, pts []Point, width float64, c color.Color, style string) {
+	dash, gap := dashPattern(style, width)
+	if dash == 0 && gap == 0 {
+		StrokePolygon(img, pts, width, c)
+		return
+	}
+	// Walk round the outline, carrying how far into the pattern each edge
+	// starts so dashes turn corners.
+	period := dash + gap
+	pos := 0.0
+	for i, a := range pts {
+		b := pts[(i+1)%len(pts)]
+		l := math.Hypot(b.X-a.X, b.Y-a.Y)
+		if l == 0 {
+			continue
+		}
+		at := func(d float64) (float64, float64) { return a.X + (b.X-a.X)*d/l, a.Y + (b.Y-a.Y)*d/l }
+		for d := -math.Mod(pos, period); d < l; d += period {
+			from, to := math.Max(0, d), math.Min(l, d+dash)
+			if to < from {
+				continue
+			}
+			x0, y0 := at(from)
+			x1, y1 := at(to)
+			if dash == 0 {
+				FillCircle(img, x0, y0, width/2, c)
+				continue
+			}
+			DrawLine(img, x0, y0, x1, y1, width, c)
+			FillCircle(img, x0, y0, width/2, c)
+			FillCircle(img, x1, y1, width/2, c)
+		}
+		pos += l
+	}
+}
+
+// SVGDash returns the SVG attributes for a stroke style.
+func SVGDash(style string, width float64) string {
+	dash, gap := dashPattern(style, width)
+	if dash == 0 && gap == 0 {
+		return ""
+	}
+	return fmt.Sprintf(` stroke-dasharray="%.1f %.1f" stroke-linecap="round"`, dash, gap)
+}
@@ -0,0 +1,743 @@
+package render
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+)
+
+// Settings
+const numSize = 8.8
+const symSize = 2.5
+const nameSize = 6.5
+const massSize = 8.8
+
+// Hexagons and circles narrow towards the top and bottom, so their text is
+// smaller.
+const narrowSymSize = 3.3
+const narrowNameSize = 8
+const narrowMassSize = 11
+const hexMassSize = 13 // fits in the bottom point
+
+// -notes text is one line in a band above the bottom border.
+const noteSize = 20
+
+// -style kids has bigger type, with the room the mass took.
+const kidsNumSize = 7.5
+const kidsSymSize = 2.4
+const kidsNameSize = 6
+
+// Renderer draws element cards. Make one with New. Its font faces can't
+// be shared between goroutines, so use one Renderer in each, or take turns.
+type Renderer struct {
+	Options *Options
+	Colours Colours           // by normalised category
+	aliases map[string]string // from -aliases
+	Spectra Spectra           // from -spectra, or the bundled data
+	notes   map[string]string // from -notes, by symbol
+	valign  map[string]string // from -valign, by field
+	preset  *Preset           // from -preset, or nil
+	label   *cardExpr         // from -label, or nil
+
+	patterns map[string]fillPattern // from -patterns, by normalised category
+
+	rules         []cardRule            // from -rules
+	ruleNameFonts map[float64]font.Face // the name font at the sizes the -rules ask for
+
+	illustrations map[string]illustration // from -illustrations, by element symbol
+	TileW         int                     // size of the cards in px
+	TileH         int
+
+	super *Renderer // drawing the cards -supersample times as big, or nil
+
+	bgMu        sync.Mutex
+	backgrounds map[string]*image.RGBA // by category, and border style for -rules
+
+	numFont  font.Face
+	symFont  font.Face
+	NameFont font.Face
+	MassFont font.Face
+	noteFont font.Face
+	subFont  font.Face // formula subscripts
+}
+
+// New checks the options and loads the fonts, colours and other files
+// they name, returning a Renderer for them.
+func New(o *Options) (*Renderer, error) {
+	if err := checkShape(o.Shape); err != nil {
+		return nil, err
+	}
+	if err := checkTextRendering(o); err != nil {
+		return nil, err
+	}
+	if err := checkResample(o.Resample); err != nil {
+		return nil, err
+	}
+	if err := checkColourSpace(o.ColourSpace); err != nil {
+		return nil, err
+	}
+	if o.Supersample != 1 && o.Supersample != 2 && o.Supersample != 4 {
+		return nil, fmt.Errorf("-supersample must be 1, 2 or 4, not %d", o.Supersample)
+	}
+	if o.Supersample > 1 && o.Subpixel != SubpixelNone {
+		return nil, fmt.Errorf("-subpixel draws for the pixels of the card as it is saved; it can't be used with -supersample")
+	}
+	if err := checkColourBy(o.ColourBy); err != nil {
+		return nil, err
+	}
+	if err := checkStyle(o.Style, o.Shape); err != nil {
+		return nil, err
+	}
+	if o.Illustrations != "" && o.Style != StyleKids {
+		return nil, fmt.Errorf("-illustrations needs -style %s", StyleKids)
+	}
+	if err := checkMassFormat(o.MassFormat); err != nil {
+		return nil, err
+	}
+	if err := checkMassUnit(o.MassUnit); err != nil {
+		return nil, err
+	}
+	preset, err := LookupPreset(o.Preset)
+	if err != nil {
+		return nil, err
+	}
+	valign, err := parseVAlign(o.VAlign)
+	if err != nil {
+		return nil, err
+	}
+	if o.NameSpacing <= 0 {
+		return nil, fmt.Errorf("-name-spacing must be positive, not %g", o.NameSpacing)
+	}
+	if o.MassUnit != UnitNone && o.Shape == ShapeHex {
+		return nil, fmt.Errorf("-mass-unit doesn't fit in the bottom point of %s cards", ShapeHex)
+	}
+	colours, err := LoadColours(o.ColoursPath)
+	if os.IsNotExist(err) {
+		logger.Warn("No colours file, using a generated palette", "path", o.ColoursPath)
+		colours, err = GeneratePalette(DefaultPalette), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading colours: %w", err)
+	}
+	aliases, err := loadAliases(o.AliasesPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading aliases: %w", err)
+	}
+
+	spectra, err := LoadSpectra(o.SpectraPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading spectra: %w", err)
+	}
+
+	groups, err := LoadGroups(o.GroupsPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading groups: %w", err)
+	}
+
+	notes, err := loadNotes(o.NotesPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading notes: %w", err)
+	}
+	if o.NotesPath != "" && o.Shape != ShapeRect {
+		return nil, fmt.Errorf("-notes needs -shape %s; %s cards have no room for them", ShapeRect, o.Shape)
+	}
+	if o.PhaseBar && o.Spectrum && o.Shape != ShapeRect {
+		return nil, fmt.Errorf("-phase-bar and -spectrum both go under the name on %s cards; use one", o.Shape)
+	}
+	if o.Compounds && o.Shape != ShapeRect {
+		return nil, fmt.Errorf("-compounds needs -shape %s; %s cards have no room for them", ShapeRect, o.Shape)
+	}
+	if o.Shells && o.Shape != ShapeRect {
+		return nil, fmt.Errorf("-shells needs -shape %s; %s cards have no room for them", ShapeRect, o.Shape)
+	}
+	if o.Magnetism && o.Shape != ShapeRect {
+		return nil, fmt.Errorf("-magnetism needs -shape %s; %s cards have no room for it", ShapeRect, o.Shape)
+	}
+	if o.StableIsotopes && o.Shape != ShapeRect {
+		return nil, fmt.Errorf("-stable-isotopes needs -shape %s; %s cards have no room for it", ShapeRect, o.Shape)
+	}
+	var label *cardExpr
+	if o.Label != "" {
+		if o.Shape != ShapeRect {
+			return nil, fmt.Errorf("-label needs -shape %s; %s cards have no room for it", ShapeRect, o.Shape)
+		}
+		x, err := parseCardExpr(o.Label)
+		if err != nil {
+			return nil, fmt.Errorf("-label: %w", err)
+		}
+		label = &x
+	}
+
+	r := &Renderer{
+		Options: o,
+		Colours: Colours{},
+		aliases: aliases,
+		Spectra: spectra,
+		notes:   notes,
+		valign:  valign,
+		preset:  preset,
+		label:   label,
+	}
+	for name, c := range colours {
+		category := r.Category(name)
+		_, group := groups[category]
+		if !slices.Contains(categories, category) && !group && PhaseColours[category] == "" && valenceColours[category] == "" && magnetismColours[category] == "" && occurrenceColours[category] == "" && stableIsotopesColours[category] == "" {
+			logger.Warn("Colour for unknown category", "category", name, "path", o.ColoursPath)
+		}
+		r.Colours[category] = c
+	}
+	switch o.ColourBy {
+	case ColourByValence:
+		addValenceColours(r)
+	case ColourByMagnetism:
+		addMagnetismColours(r)
+	case ColourByOccurrence:
+		addOccurrenceColours(r)
+	case ColourByStableIsotopes:
+		addStableIsotopesColours(r)
+	}
+	if o.Greyscale {
+		r.Colours = greyscale(r.Colours)
+	}
+	if o.Style == StyleOutline && (o.Patterns != "" || o.RandomStyle || o.Occurrence) {
+		return nil, fmt.Errorf("-style %s leaves the cards unfilled; it can't be used with -patterns, -random-style or -occurrence", StyleOutline)
+	}
+	if o.Patterns != "" && (o.RandomStyle || o.Occurrence) {
+		return nil, fmt.Errorf("-patterns can't be used with -random-style or -occurrence, which draw their own")
+	}
+	if r.patterns, err = loadPatterns(o.Patterns, r); err != nil {
+		return nil, fmt.Errorf("reading patterns: %w", err)
+	}
+	if r.rules, err = loadRules(o.RulesPath); err != nil {
+		return nil, fmt.Errorf("reading rules: %w", err)
+	}
+	if o.RandomStyle && slices.ContainsFunc(r.rules, func(rule cardRule) bool { return rule.Border != "" && rule.Border != StrokeSolid }) {
+		return nil, fmt.Errorf("-rules can't change the border with -random-style, which draws its own")
+	}
+	r.TileW, r.TileH = TileSize(o.Shape, o.Height)
+	if o.Width > 0 {
+		if o.Shape != ShapeRect {
+			return nil, fmt.Errorf("-width needs -shape %s; %s cards keep their shape", ShapeRect, o.Shape)
+		}
+		r.TileW = o.Width
+	}
+
+	// Load font faces of different sizes
+	fs := r.fontSizes()
+	sizes := []struct {
+		face *font.Face
+		size float64
+	}{
+		{&r.numFont, fs.num},
+		{&r.symFont, fs.sym},
+		{&r.NameFont, fs.name},
+		{&r.MassFont, fs.mass},
+		{&r.noteFont, fs.note},
+		{&r.subFont, fs.note * subScale},
+	}
+	for _, s := range sizes {
+		f, err := r.CardFont(s.size)
+		if err != nil {
+			return nil, fmt.Errorf("loading font: %w", err)
+		}
+		*s.face = f
+	}
+	if err := r.loadRuleFonts(); err != nil {
+		return nil, err
+	}
+	if r.illustrations, err = loadIllustrations(o.Illustrations, r.Layout().Illustration); err != nil {
+		return nil, fmt.Errorf("reading illustrations: %w", err)
+	}
+	if o.Supersample > 1 {
+		so := *o
+		so.Supersample = 1
+		so.Height *= o.Supersample
+		so.Width *= o.Supersample
+		if r.super, err = New(&so); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// fontSizes are the sizes of the card text in px. They scale with the
+// card's height, or with its width if -width makes it narrower than usual.
+type fontSizes struct{ num, sym, name, mass, note float64 }
+
+func (r *Renderer) fontSizes() fontSizes {
+	h := float64(r.TileH)
+	if r.Options.Shape == ShapeRect {
+		h = min(h, float64(r.TileW)/rectRatio)
+	}
+	if r.Options.Style == StyleKids {
+		return fontSizes{h / kidsNumSize, h / kidsSymSize, h / kidsNameSize, h / massSize, h / noteSize}
+	}
+	switch r.Options.Shape {
+	case ShapeHex:
+		return fontSizes{h / numSize, h / narrowSymSize, h / narrowNameSize, h / hexMassSize, h / noteSize}
+	case ShapeCircle:
+		return fontSizes{h / numSize, h / narrowSymSize, h / narrowNameSize, h / narrowMassSize, h / noteSize}
+	}
+	return fontSizes{
+		num:  h / numSize,  // ~large enough
+		sym:  h / symSize,  // biggest
+		name: h / nameSize, // medium
+		mass: h / massSize, // smallest
+		note: h / noteSize,
+	}
+}
+
+// ReadFont reads a -font file, or returns Go Bold, which is built in, if
+// path is "".
+func ReadFont(path string) ([]byte, error) {
+	if path == "" {
+		return gobold.TTF, nil
+	}
+	return os.ReadFile(path)
+}
+
+// drawCentred draws black text centred across the first width px of img.
+func drawCentred(img *image.RGBA, face font.Face, width, y int, txt string) {
+	w := MeasureText(face, txt).Round()
+	DrawText(img, face, (width-w)/2, y, txt, color.Black)
+}
+
+// FitText shortens txt with an ellipsis until it is at most width px wide,
+// never cutting a combining mark off its character.
+func FitText(face font.Face, txt string, width int) string {
+	gs := textGlyphs(face, txt)
+	join := func() string {
+		var sb strings.Builder
+		for _, g := range gs {
+			sb.WriteString(g.String())
+		}
+		return sb.String()
+	}
+	for len(gs) > 1 && MeasureText(face, join()).Round() > width {
+		gs = append(gs[:len(gs)-2], glyph{base: '…'})
+	}
+	return join()
+}
+
+// Category normalises a category name and resolves -aliases.
+func (r *Renderer) Category(name string) string {
+	c := normaliseCategory(name)
+	if to, ok := r.aliases[c]; ok {
+		return to
+	}
+	return c
+}
+
+// CategoryColour returns a category's colour from colours.json, falling back
+// to the colour for "unknown" and then black.
+func (r *Renderer) CategoryColour(category string) color.RGBA {
+	if h, ok := r.Colours[r.Category(category)]; ok {
+		return HexToRGBA(h)
+	}
+	if h, ok := r.Colours["unknown"]; ok {
+		return HexToRGBA(h)
+	}
+	return color.RGBA{0, 0, 0, 255}
+}
+
+// borderThickness is proportional to the tile height.
+func (r *Renderer) borderThickness() int { return r.TileH / 15 }
+
+// Outline returns the function giving the card's outline for its shape.
+func (r *Renderer) Outline() func(w, h int, inset float64) []Point {
+	switch r.Options.Shape {
+	case ShapeHex:
+		return hexagon
+	case ShapeCircle:
+		return Circle
+	}
+	if r.Options.Style == StyleKids {
+		return roundedRect
+	}
+	return rectangle
+}
+
+// background returns the card for a category with nothing but its fill and
+// border drawn. Every element in a category shares the same background, so
+// it is rendered once and cached.
+func (r *Renderer) background(category string) *image.RGBA {
+	r.bgMu.Lock()
+	defer r.bgMu.Unlock()
+	if bg, ok := r.backgrounds[category]; ok {
+		return bg
+	}
+
+	if _, ok := r.Colours[r.Category(category)]; !ok {
+		logger.Warn("No colour for category, using unknown", "category", category, "path", r.Options.ColoursPath)
+	}
+	img := r.drawBackground(r.CategoryColour(category))
+	if p, ok := r.patterns[r.Category(category)]; ok {
+		r.drawPattern(img, p, patternColour(r.CategoryColour(category)))
+	}
+	if r.backgrounds == nil {
+		r.backgrounds = map[string]*image.RGBA{}
+	}
+	r.backgrounds[category] = img
+	return img
+}
+
+// drawBackground draws a blank card with a border of the given colour.
+func (r *Renderer) drawBackground(border color.RGBA) *image.RGBA {
+	tileW, tileH := r.TileW, r.TileH
+	img := image.NewRGBA(image.Rect(0, 0, tileW, tileH))
+	bt := r.borderThickness()
+
+	outline := r.Outline()
+	switch {
+	case r.Options.Shape != ShapeRect || r.Options.Style == StyleKids:
+		// Clipped to the outline, leaving the corners transparent.
+		FillPolygon(img, outline(tileW, tileH, 0), border)
+		if r.Options.Style == StyleOutline {
+			cutOut(img, outline(tileW, tileH, float64(bt)))
+		} else {
+			FillPolygon(img, outline(tileW, tileH, float64(bt)), color.White)
+		}
+	default:
+		if r.Options.Style != StyleOutline {
+			draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		}
+		// Draw borders
+		draw.Draw(img, image.Rect(0, 0, tileW, bt), &image.Uniform{border}, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(0, tileH-bt, tileW, tileH), &image.Uniform{border}, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(0, 0, bt, tileH), &image.Uniform{border}, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(tileW-bt, 0, tileW, tileH), &image.Uniform{border}, image.Point{}, draw.Src)
+	}
+
+	if r.Options.Bevel {
+		drawBevel(img, outline, float64(bt))
+	}
+	return img
+}
+
+// Text alignments in a cardLayout.
+const (
+	alignLeft   = iota // X is the left edge
+	alignCentre        // centred across the tile, X is unused
+	alignRight         // X is the right edge
+)
+
+// TextPos places a line of text on a card. Y is its baseline.
+type TextPos struct{ X, Y, Align int }
+
+// CardLayout is where each part of a card goes. It depends only on the
+// shape and size, and both the PNG and SVG output draw from it.
+type CardLayout struct {
+	Number, Mass, Symbol, Name TextPos
+	MassUnit                   TextPos // in the note font
+
+	// NameRadius, if set, curves the name around the top of the card
+	// instead, its baseline this far from the centre.
+	NameRadius int
+
+	Swatch    image.Rectangle // flame test colour
+	Strip     image.Rectangle // emission spectrum
+	Note      image.Rectangle // -notes text
+	Compounds image.Rectangle // -compounds list
+	Shells    image.Rectangle // -shells occupancy
+	Magnetism image.Rectangle // -magnetism label
+	Isotopes  image.Rectangle // -stable-isotopes count
+	Label     image.Rectangle // -label text
+	PhaseBar  image.Rectangle // -phase-bar
+	Ring      image.Rectangle // -valence count
+
+	Illustration image.Rectangle // -illustrations picture
+}
+
+// Layout returns where each part of the cards goes.
+func (r *Renderer) Layout() CardLayout {
+	tileW, tileH := r.TileW, r.TileH
+	bt := r.borderThickness()
+
+	// Padding
+	pad := tileH / 20
+
+	symY := tileH/2 + r.symFont.Metrics().Height.Round()/4
+	l := CardLayout{
+		// Atomic Number (top-left)
+		Number: TextPos{bt + pad, bt + pad + r.numFont.Metrics().Height.Round(), alignLeft},
+		// Atomic Mass (top-right)
+		Mass: TextPos{tileW - bt - pad, bt + pad + r.MassFont.Metrics().Height.Round(), alignRight},
+		// Symbol (center)
+		Symbol: TextPos{Y: symY, Align: alignCentre},
+		// Name (below symbol)
+		Name: TextPos{Y: symY + r.NameFont.Metrics().Height.Round() + pad, Align: alignCentre},
+		// Flame test swatch (bottom-right)
+		Swatch: image.Rect(tileW-bt-pad-tileH/8, tileH-bt-pad-tileH/8, tileW-bt-pad, tileH-bt-pad),
+		// Emission spectrum (strip along the bottom border)
+		Strip: image.Rect(bt, tileH-bt-tileH/20, tileW-bt, tileH-bt),
+		// Mass unit (under the mass)
+		MassUnit: TextPos{tileW - bt - pad, bt + pad*3/2 + r.MassFont.Metrics().Height.Round() + r.noteFont.Metrics().Height.Round(), alignRight},
+		// Valence electrons (top, between the number and the wider mass)
+		Ring: image.Rect(tileW*9/20-tileH/16, bt+pad, tileW*9/20+tileH/16, bt+pad+tileH/8),
+	}
+
+	// Only rectangles have room for notes, compounds, shells, magnetism and
+	// the phase bar, in bands stacked up from the spectrum made by moving the
+	// symbol and name up.
+	bottom := l.Strip.Min.Y
+	band := func() image.Rectangle {
+		l.Symbol.Y -= tileH / 20
+		l.Name.Y -= tileH / 20
+		rc := image.Rect(bt+pad, bottom-tileH/16, l.Swatch.Min.X-pad, bottom)
+		bottom = rc.Min.Y
+		return rc
+	}
+	if r.Options.NotesPath != "" {
+		l.Note = band()
+	}
+	if r.Options.Compounds {
+		l.Compounds = band()
+	}
+	if r.Options.Shells {
+		l.Shells = band()
+	}
+	if r.Options.Magnetism {
+		l.Magnetism = band()
+	}
+	if r.Options.StableIsotopes {
+		l.Isotopes = band()
+	}
+	if r.label != nil {
+		l.Label = band()
+	}
+	if r.Options.PhaseBar && r.Options.Shape == ShapeRect {
+		l.PhaseBar = band()
+	}
+
+	swatch := tileH / 10
+	switch r.Options.Shape {
+	case ShapeCircle:
+		// The name curves around the top like a medallion's, with the
+		// rest centred below the symbol.
+		l.Symbol.Y = tileH * 58 / 100
+		l.NameRadius = tileH/2 - bt - pad - r.NameFont.Metrics().Ascent.Round()
+		l.Mass = TextPos{Y: tileH * 71 / 100, Align: alignCentre}
+		l.Number = TextPos{Y: tileH * 87 / 100, Align: alignCentre}
+		if r.Options.MassUnit != UnitNone {
+			l.MassUnit = TextPos{Y: tileH * 80 / 100, Align: alignCentre}
+			l.Number.Y = tileH * 91 / 100
+		}
+		x1, y1 := tileW-bt-pad*2, tileH*45/100
+		l.Swatch = image.Rect(x1-swatch, y1-swatch, x1, y1)
+		l.Ring = image.Rect(bt+pad*2, y1-swatch, bt+pad*2+swatch, y1)
+		l.Strip = image.Rect(tileW*22/100, tileH*745/1000, tileW*78/100, tileH*77/100)
+		l.PhaseBar = l.Strip
+	case ShapeHex:
+		// Everything is centred in a column: the number in the top point,
+		// the mass in the bottom one and the spectrum just above it. The
+		// swatch goes beside the symbol, between the straight sides.
+		l.Symbol.Y, l.Name.Y = tileH*58/100, tileH*72/100
+		l.Number = TextPos{Y: tileH * 30 / 100, Align: alignCentre}
+		l.Mass = TextPos{Y: tileH * 83 / 100, Align: alignCentre}
+		x1, y1 := tileW-bt-pad, tileH*45/100
+		l.Swatch = image.Rect(x1-swatch, y1-swatch, x1, y1)
+		l.Ring = image.Rect(bt+pad, y1-swatch, bt+pad+swatch, y1)
+		l.Strip = image.Rect(bt+pad, tileH*745/1000, tileW-bt-pad, tileH*77/100)
+		l.PhaseBar = l.Strip
+	}
+
+	if r.Options.Style == StyleKids {
+		// The number moves in from the rounded corner. A picture goes on
+		// the right, level with the symbol, which moves over for it.
+		in := int(cornerRadius(tileH)) / 3
+		l.Number.X += in
+		l.Number.Y += in / 2
+		// The bigger name sits on the bottom padding, clear of the border.
+		l.Name.Y = tileH - bt - pad - r.NameFont.Metrics().Descent.Round()
+		s, mid := tileH*7/20, l.Symbol.Y-capHeight(r.symFont)/2
+		l.Illustration = image.Rect(tileW-bt-pad-in-s, mid-s/2, tileW-bt-pad-in, mid+s/2)
+	}
+
+	if len(r.valign) > 0 {
+		sizes := r.fontSizes()
+		fields := []struct {
+			name string
+			pos  *TextPos
+			face font.Face
+			size float64
+		}{
+			{"number", &l.Number, r.numFont, sizes.num},
+			{"mass", &l.Mass, r.MassFont, sizes.mass},
+			{"symbol", &l.Symbol, r.symFont, sizes.sym},
+			{"name", &l.Name, r.NameFont, sizes.name},
+		}
+		for _, f := range fields {
+			alignVertically(f.pos, f.face, f.size, r.valign[f.name])
+		}
+	}
+
+	if r.Options.Lewis {
+		// Make room for the dots above and below the symbol.
+		switch r.Options.Shape {
+		case ShapeRect:
+			l.Name.Y += tileH / 30
+		case ShapeHex:
+			l.Number.Y, l.Symbol.Y = tileH*25/100, tileH*55/100
+		case ShapeCircle:
+			l.Mass.Y = tileH * 74 / 100
+		}
+	}
+	return l
+}
+
+// drawField draws black text at p.
+func drawField(img *image.RGBA, face font.Face, p TextPos, txt string) {
+	switch p.Align {
+	case alignCentre:
+		drawCentred(img, face, img.Bounds().Dx(), p.Y, txt)
+	case alignRight:
+		DrawText(img, face, p.X-MeasureText(face, txt).Round(), p.Y, txt, color.Black)
+	default:
+		DrawText(img, face, p.X, p.Y, txt, color.Black)
+	}
+}
+
+// Tile draws an element's card.
+func (r *Renderer) Tile(e Element) *image.RGBA {
+	if r.super != nil {
+		big := r.super.Tile(e)
+		img := image.NewRGBA(image.Rect(0, 0, r.TileW, r.TileH))
+		Resamplers[r.Options.Resample].Scale(img, img.Bounds(), big, big.Bounds(), xdraw.Src, nil)
+		return img
+	}
+	ch := r.cardChanges(e)
+	var bg *image.RGBA
+	switch {
+	case r.Options.RandomStyle:
+		bg = r.randomBackground(e)
+	case ch.border != "":
+		bg = r.dashedBackground(e.Type, ch.border)
+	default:
+		bg = r.background(e.Type)
+	}
+	img := &image.RGBA{Pix: make([]uint8, len(bg.Pix)), Stride: bg.Stride, Rect: bg.Rect}
+	// -style outline draws everything on its own, to be outlined over the
+	// background at the end.
+	if r.Options.Style != StyleOutline {
+		copy(img.Pix, bg.Pix)
+	}
+	if r.Options.Occurrence {
+		r.hatchOccurrence(img, e)
+	}
+
+	l := r.illustrated(r.Layout(), e)
+	drawField(img, r.numFont, l.Number, fmt.Sprintf("%d", e.Number))
+	if r.showMass() {
+		drawField(img, r.MassFont, l.Mass, r.cardMass(e))
+		if unit := r.MassUnit(); unit != "" {
+			drawField(img, r.noteFont, l.MassUnit, unit)
+		}
+	}
+	if pic, ok := r.illustrations[e.Symbol]; ok {
+		draw.Draw(img, pic.rect, pic.img, image.Point{}, draw.Over)
+	}
+	drawField(img, r.symFont, l.Symbol, e.Symbol)
+	if r.Options.Lewis {
+		r.drawLewis(img, e)
+	}
+	nameFont, _ := r.cardNameFont(e)
+	if l.NameRadius > 0 && r.showName() {
+		drawArcText(img, nameFont, r.TileW/2, r.TileH/2, l.NameRadius, e.Name)
+	} else {
+		for _, ln := range r.nameLines(l, nameFont, e.Name) {
+			drawField(img, nameFont, ln.pos, ln.txt)
+		}
+	}
+
+	if r.Options.Flame {
+		if fc, ok := FlameColours[e.Symbol]; ok {
+			DrawSwatch(img, l.Swatch, HexToRGBA(fc.Colour))
+		}
+	}
+
+	if r.Options.Spectrum {
+		if lines, ok := r.Spectra[e.Symbol]; ok {
+			DrawSpectrum(img, l.Strip, lines)
+		}
+	}
+
+	if r.Options.Valence {
+		drawValence(img, r.noteFont, l.Ring, e.Valence)
+	}
+
+	if r.Options.Compounds {
+		r.drawCompounds(img, l.Compounds, e)
+	}
+
+	if r.Options.Shells {
+		r.drawBandText(img, l.Shells, FormatShells(ShellOccupancy(e)))
+	}
+
+	if r.Options.Magnetism {
+		r.drawBandText(img, l.Magnetism, magnetismLabel(e.Symbol))
+	}
+
+	if r.Options.StableIsotopes {
+		r.drawBandText(img, l.Isotopes, stableIsotopesLabel(e.Symbol))
+	}
+
+	if r.label != nil {
+		r.drawBandText(img, l.Label, r.label.String(e))
+	}
+
+	if r.Options.PhaseBar {
+		DrawPhaseBar(img, l.PhaseBar, e)
+	}
+
+	if note, ok := r.notes[e.Symbol]; ok {
+		m := r.noteFont.Metrics()
+		y := l.Note.Min.Y + (l.Note.Dy()+m.Ascent.Round()-m.Descent.Round())/2
+		DrawText(img, r.noteFont, l.Note.Min.X, y, FitText(r.noteFont, note, l.Note.Dx()), color.Black)
+	}
+
+	if r.Options.Style == StyleOutline {
+		ink := strokeInk(img, r.outlineWidth(), r.CategoryColour(e.Type))
+		copy(img.Pix, bg.Pix)
+		draw.Draw(img, img.Bounds(), ink, image.Point{}, draw.Over)
+	}
+
+	if r.Options.DebugLayout {
+		r.drawDebugLayout(img, l, e)
+	}
+	return img
+}
+
+// drawBandText writes one line of text in a band of the card, such as the
+// -shells occupancy, shortened if it doesn't fit.
+func (r *Renderer) drawBandText(img *image.RGBA, rect image.Rectangle, txt string) {
+	m := r.noteFont.Metrics()
+	y := rect.Min.Y + (rect.Dy()+m.Ascent.Round()-m.Descent.Round())/2
+	DrawText(img, r.noteFont, rect.Min.X, y, FitText(r.noteFont, txt, rect.Dx()), color.Black)
+}
+
+// bandTextSVG is drawBandText for SVG cards.
+func (r *Renderer) bandTextSVG(rect image.Rectangle, txt string, size float64) string {
+	if txt == "" {
+		return ""
+	}
+	m := r.noteFont.Metrics()
+	y := rect.Min.Y + (rect.Dy()+m.Ascent.Round()-m.Descent.Round())/2
+	return fmt.Sprintf(`<text x="%d" y="%d" font-size="%.1f">%s</text>`, rect.Min.X, y, size, html.EscapeString(FitText(r.noteFont, txt, rect.Dx())))
+}
+
+// DrawSwatch fills rect with c and outlines it so pale flame colours stay
+// visible against the white card.
+func DrawSwatch(img *image.RGBA, rect image.Rectangle, c color.Color) {
+	draw.Draw(img, rect, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	draw.Draw(img, rect.Inset(max(1, rect.Dy()/24)), image.NewUniform(c), image.Point{}, draw.Src)
+}
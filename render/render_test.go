@@ -0,0 +1,42 @@
+package render
+
+import "testing"
+
+var testIron = Element{Number: 26, Symbol: "Fe", Name: "Iron", Mass: 55.845, Type: "transition metal", XPos: 8, YPos: 4}
+
+func TestTileSize(t *testing.T) {
+	for _, shape := range []string{ShapeRect, ShapeHex, ShapeCircle} {
+		t.Run(shape, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.ColoursPath = "../colours.json"
+			opts.Height, opts.Shape = 60, shape
+			r, err := New(&opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, h := TileSize(shape, 60)
+			if r.TileW != w || r.TileH != h {
+				t.Errorf("renderer is %dx%d, want %dx%d", r.TileW, r.TileH, w, h)
+			}
+			if b := r.Tile(testIron).Bounds(); b.Dx() != w || b.Dy() != h {
+				t.Errorf("card is %v, want %dx%d", b, w, h)
+			}
+		})
+	}
+}
+
+func TestNewChecksOptions(t *testing.T) {
+	for name, change := range map[string]func(*Options){
+		"shape":       func(o *Options) { o.Shape = "star" },
+		"supersample": func(o *Options) { o.Supersample = 3 },
+		"mass format": func(o *Options) { o.MassFormat = "rounded" },
+		"kids hex":    func(o *Options) { o.Style, o.Shape = StyleKids, ShapeHex },
+	} {
+		opts := DefaultOptions()
+		opts.ColoursPath = "../colours.json"
+		change(&opts)
+		if _, err := New(&opts); err == nil {
+			t.Errorf("%s: New succeeded, want an error", name)
+		}
+	}
+}
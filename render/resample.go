@@ -0,0 +1,40 @@
+package render
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// lanczos is the Lanczos filter with three lobes, the sharpest of the
+// -resample filters.
+var lanczos = &xdraw.Kernel{Support: 3, At: func(t float64) float64 {
+	if t == 0 {
+		return 1
+	}
+	if t >= 3 {
+		return 0
+	}
+	pt := math.Pi * t
+	return 3 * math.Sin(pt) * math.Sin(pt/3) / (pt * pt)
+}}
+
+// Resamplers are the -resample filters, for scaling down a card drawn
+// bigger than it is saved.
+var Resamplers = map[string]xdraw.Interpolator{
+	"catmull-rom": xdraw.CatmullRom,
+	"lanczos":     lanczos,
+	"bilinear":    xdraw.BiLinear,
+	"nearest":     xdraw.NearestNeighbor,
+}
+
+func checkResample(name string) error {
+	if _, ok := Resamplers[name]; !ok {
+		return fmt.Errorf("unknown -resample %q (want %s)", name, strings.Join(slices.Sorted(maps.Keys(Resamplers)), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+// rulesSpec is a -rules file of changes to the cards of the elements that
+// match a condition, checked for each card as it is drawn:
+//
+//	{"rules": [
+//	  {"if": "mass_decimals == 0", "mass": "brackets"},
+//	  {"if": "category == 'unknown'", "border": "dashed"},
+//	  {"if": "name_length > 10 and row <= 7", "name_size": 0.8}
+//	]}
+//
+// A condition compares -label expressions, so it has the same fields and
+// functions, see cardCond. The rules are applied in order, so a later one
+// overrides an earlier one.
+type rulesSpec struct {
+	Rules []cardRule `json:"rules"`
+}
+
+type cardRule struct {
+	If       string  `json:"if"`
+	Mass     string  `json:"mass"`      // brackets to write it as [209]
+	Border   string  `json:"border"`    // solid, dashed or dotted
+	NameSize float64 `json:"name_size"` // times the usual size
+
+	cond cardCond
+}
+
+// loadRules reads a -rules file.
+func loadRules(path string) ([]cardRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec rulesSpec
+	if err := json.Unmarshal(bs, &spec); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i := range spec.Rules {
+		rule := &spec.Rules[i]
+		fail := func(err error) error { return fmt.Errorf("%s: rule %d: %w", path, i+1, err) }
+		if strings.TrimSpace(rule.If) == "" {
+			return nil, fail(fmt.Errorf("no \"if\" condition"))
+		}
+		if rule.cond, err = parseCardCond(rule.If); err != nil {
+			return nil, fail(fmt.Errorf("%q: %w", rule.If, err))
+		}
+		if rule.Mass != "" && rule.Mass != "brackets" {
+			return nil, fail(fmt.Errorf("unknown mass %q (want brackets)", rule.Mass))
+		}
+		if err := CheckStrokeStyle(rule.Border); err != nil {
+			return nil, fail(err)
+		}
+		if rule.NameSize < 0 {
+			return nil, fail(fmt.Errorf("name_size must be more than 0"))
+		}
+	}
+	return spec.Rules, nil
+}
+
+// cardChanges are what the -rules matching an element change on its card.
+type cardChanges struct {
+	massBrackets bool
+	border       string  // stroke style, "" for solid
+	nameSize     float64 // times the usual size
+}
+
+func (r *Renderer) cardChanges(e Element) cardChanges {
+	ch := cardChanges{nameSize: 1}
+	for _, rule := range r.rules {
+		if !rule.cond(e) {
+			continue
+		}
+		if rule.Mass != "" {
+			ch.massBrackets = true
+		}
+		if rule.Border != "" {
+			ch.border = rule.Border
+		}
+		if rule.NameSize > 0 {
+			ch.nameSize = rule.NameSize
+		}
+	}
+	if ch.border == StrokeSolid {
+		ch.border = ""
+	}
+	return ch
+}
+
+// loadRuleFonts loads the name font at every size the -rules ask for.
+func (r *Renderer) loadRuleFonts() error {
+	r.ruleNameFonts = map[float64]font.Face{}
+	for _, rule := range r.rules {
+		if rule.NameSize == 0 || rule.NameSize == 1 || r.ruleNameFonts[rule.NameSize] != nil {
+			continue
+		}
+		face, err := r.CardFont(r.fontSizes().name * rule.NameSize)
+		if err != nil {
+			return fmt.Errorf("loading font: %w", err)
+		}
+		r.ruleNameFonts[rule.NameSize] = face
+	}
+	return nil
+}
+
+// cardMass is the mass written on an element's card.
+func (r *Renderer) cardMass(e Element) string {
+	mass := r.FormatMass(e)
+	if r.cardChanges(e).massBrackets && !strings.HasPrefix(mass, "[") {
+		mass = "[" + mass + "]"
+	}
+	return mass
+}
+
+// cardNameFont is the font an element's name is written in, and its size.
+func (r *Renderer) cardNameFont(e Element) (font.Face, float64) {
+	scale := r.cardChanges(e).nameSize
+	if face, ok := r.ruleNameFonts[scale]; ok {
+		return face, r.fontSizes().name * scale
+	}
+	return r.NameFont, r.fontSizes().name
+}
+
+// dashedBackground is background for a card whose -rules give its border
+// a dashed or dotted stroke.
+func (r *Renderer) dashedBackground(category, style string) *image.RGBA {
+	key := category + "\x00" + style
+	r.bgMu.Lock()
+	defer r.bgMu.Unlock()
+	if bg, ok := r.backgrounds[key]; ok {
+		return bg
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, r.TileW, r.TileH))
+	outline := r.Outline()
+	if r.Options.Style != StyleOutline {
+		FillPolygon(img, outline(r.TileW, r.TileH, 0), color.White)
+	}
+	bt := float64(r.borderThickness())
+	StrokeStyled(img, outline(r.TileW, r.TileH, bt/2), bt, r.CategoryColour(category), style)
+	if p, ok := r.patterns[r.Category(category)]; ok {
+		r.drawPattern(img, p, patternColour(r.CategoryColour(category)))
+	}
+	if r.backgrounds == nil {
+		r.backgrounds = map[string]*image.RGBA{}
+	}
+	r.backgrounds[key] = img
+	return img
+}
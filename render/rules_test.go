@@ -0,0 +1,52 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	src := `{"rules": [
+		{"if": "mass_decimals == 0", "mass": "brackets"},
+		{"if": "category == 'unknown'", "border": "dashed", "name_size": 0.8},
+		{"if": "number > 100 and category == 'Unknown'", "border": "dotted"}
+	]}`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Renderer{rules: rules}
+	for _, tt := range []struct {
+		e    Element
+		want cardChanges
+	}{
+		{testIron, cardChanges{nameSize: 1}},
+		{Element{Number: 43, Mass: 97, Type: "transition metal"}, cardChanges{massBrackets: true, nameSize: 1}},
+		{Element{Number: 99, Mass: 252, Type: "unknown"}, cardChanges{massBrackets: true, border: strokeDashed, nameSize: 0.8}},
+		// Later rules override earlier ones.
+		{Element{Number: 109, Mass: 278, Type: "unknown"}, cardChanges{massBrackets: true, border: strokeDotted, nameSize: 0.8}},
+	} {
+		if got := r.cardChanges(tt.e); got != tt.want {
+			t.Errorf("element %d: got %+v, want %+v", tt.e.Number, got, tt.want)
+		}
+	}
+
+	for src, want := range map[string]string{
+		`{"rules": [{"mass": "brackets"}]}`:                  `rule 1: no "if" condition`,
+		`{"rules": [{"if": "number > 1"}, {"if": "row"}]}`:   `rule 2: "row": missing comparison`,
+		`{"rules": [{"if": "phase == gas", "border": "x"}]}`: `rule 1: "phase == gas": unknown field "gas"`,
+	} {
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadRules(path); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error = %v, want %q", src, err, want)
+		}
+	}
+}
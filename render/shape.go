@@ -0,0 +1,110 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/f64"
+)
+
+// Tile shapes accepted by -shape.
+const (
+	ShapeRect   = "rect"
+	ShapeHex    = "hex"
+	ShapeCircle = "circle"
+)
+
+func checkShape(shape string) error {
+	switch shape {
+	case ShapeRect, ShapeHex, ShapeCircle:
+		return nil
+	}
+	return fmt.Errorf("unknown -shape %q (want %s, %s or %s)", shape, ShapeRect, ShapeHex, ShapeCircle)
+}
+
+// TileSize returns the card size for a shape at the given height. Hexagons
+// are regular and pointy-topped, so they tessellate into a honeycomb.
+func TileSize(shape string, height int) (w, h int) {
+	switch shape {
+	case ShapeHex:
+		return int(math.Round(float64(height) * math.Sqrt(3) / 2)), height
+	case ShapeCircle:
+		return height, height
+	}
+	return int(rectRatio * float64(height)), height
+}
+
+// rectRatio is the width of a rectangular card over its height, unless
+// -width says otherwise.
+const rectRatio = float64(2456) / float64(1882)
+
+// hexagon returns the corners of the pointy-topped hexagon filling a w×h
+// tile, moved in by inset px on every side.
+func hexagon(w, h int, inset float64) []Point {
+	cx, cy := float64(w)/2, float64(h)/2
+	r := float64(h)/2 - inset*2/math.Sqrt(3)
+	pts := make([]Point, 6)
+	for i := range pts {
+		a := math.Pi/3*float64(i) - math.Pi/2
+		pts[i] = Point{cx + r*math.Cos(a), cy + r*math.Sin(a)}
+	}
+	return pts
+}
+
+// Circle returns a polygon close enough to the circle filling a w×h tile,
+// moved in by inset px, to look round at any size.
+func Circle(w, h int, inset float64) []Point {
+	cx, cy := float64(w)/2, float64(h)/2
+	r := math.Min(cx, cy) - inset
+	// The same number of points at every inset, so the -bevel bands line up.
+	pts := make([]Point, max(24, min(w, h)/2))
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / float64(len(pts))
+		pts[i] = Point{cx + r*math.Cos(a), cy + r*math.Sin(a)}
+	}
+	return pts
+}
+
+// drawArcText draws black text centred over the top of a circle around
+// (cx, cy), each glyph upright to the curve with its baseline radius px
+// from the centre.
+func drawArcText(img *image.RGBA, face font.Face, cx, cy, radius int, txt string) {
+	if radius <= 0 {
+		return
+	}
+	m := face.Metrics()
+	asc, h := m.Ascent.Ceil(), m.Height.Ceil()+m.Descent.Ceil()
+	r := float64(radius)
+	pos := -float64(MeasureText(face, txt).Round()) / 2 // arc length from the top
+	prev := rune(-1)
+	for _, g := range textGlyphs(face, txt) {
+		if prev >= 0 {
+			pos += float64(face.Kern(prev, g.base).Round())
+		}
+		prev = g.base
+		adv, ok := face.GlyphAdvance(g.base)
+		if !ok {
+			continue
+		}
+		a := adv.Ceil()
+		glyph := image.NewRGBA(image.Rect(0, 0, max(1, a), h))
+		DrawText(glyph, face, 0, asc, g.String(), color.Black)
+
+		// Rotate about the middle of the glyph's baseline, which sits on
+		// the circle at angle t clockwise from the top.
+		t := (pos + float64(a)/2) / r
+		sin, cos := math.Sin(t), math.Cos(t)
+		px, py := float64(cx)+r*sin, float64(cy)-r*cos
+		ox, oy := -float64(a)/2, -float64(asc)
+		aff := f64.Aff3{
+			cos, -sin, px + cos*ox - sin*oy,
+			sin, cos, py + sin*ox + cos*oy,
+		}
+		xdraw.BiLinear.Transform(img, aff, glyph, glyph.Bounds(), xdraw.Over, nil)
+		pos += float64(a)
+	}
+}
@@ -0,0 +1,71 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ShellOccupancy returns the number of electrons in each shell of an
+// element, from the innermost out: the data's shells if it has them, or
+// else counted from its configuration, or from the one aufbauConfiguration
+// works out.
+func ShellOccupancy(e Element) []int {
+	if len(e.Shells) > 0 {
+		return e.Shells
+	}
+	if shells, ok := shellsFromConfiguration(e.Configuration); ok {
+		return shells
+	}
+	shells, _ := shellsFromConfiguration(AufbauConfiguration(e.Number))
+	return shells
+}
+
+// shellsFromConfiguration counts the electrons in each shell of a
+// configuration such as "[Ar] 3d10 4s1", or reports that it can't read it.
+func shellsFromConfiguration(cfg string) ([]int, bool) {
+	var shells []int
+	add := func(n, count int) {
+		for len(shells) < n {
+			shells = append(shells, 0)
+		}
+		shells[n-1] += count
+	}
+	for _, part := range strings.Fields(cfg) {
+		if core, ok := strings.CutPrefix(part, "["); ok {
+			sym, ok := strings.CutSuffix(core, "]")
+			number := 0
+			for _, g := range nobleGases {
+				if g.symbol == sym {
+					number = g.number
+				}
+			}
+			if !ok || number == 0 {
+				return nil, false
+			}
+			inner, _ := shellsFromConfiguration(AufbauConfiguration(number))
+			for n, count := range inner {
+				add(n+1, count)
+			}
+			continue
+		}
+		// A subshell: its shell, its letter and how many electrons it has.
+		if len(part) < 3 || part[0] < '1' || part[0] > '7' || !strings.ContainsRune("spdf", rune(part[1])) {
+			return nil, false
+		}
+		count, err := strconv.Atoi(part[2:])
+		if err != nil {
+			return nil, false
+		}
+		add(int(part[0]-'0'), count)
+	}
+	return shells, len(shells) > 0
+}
+
+// FormatShells writes shell occupancy as it goes on the cards, "2,8,14,2".
+func FormatShells(shells []int) string {
+	parts := make([]string, len(shells))
+	for i, n := range shells {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	_ "embed"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+)
+
+// SpectralLine is one emission line. Intensity is relative to the strongest
+// line of the same element.
+type SpectralLine struct {
+	Wavelength float64 `json:"wavelength"` // nm, in air
+	Intensity  float64 `json:"intensity"`
+}
+
+type Spectra map[string][]SpectralLine
+
+// Visible range drawn by the spectrum strip.
+const (
+	MinVisible = 380.0
+	MaxVisible = 750.0
+)
+
+//go:embed data/spectra.json
+var spectraJSON []byte
+
+func bundledSpectra() Spectra {
+	var s Spectra
+	if err := json.Unmarshal(spectraJSON, &s); err != nil {
+		panic("data/spectra.json: " + err.Error())
+	}
+	return s
+}
+
+// LoadSpectra reads a spectra file written by "spectra import", falling back
+// to the bundled dataset when path is empty.
+func LoadSpectra(path string) (Spectra, error) {
+	if path == "" {
+		return bundledSpectra(), nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Spectra
+	if err := json.Unmarshal(bs, &s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s Spectra) Save(path string) error {
+	bs, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bs, '\n'), 0644)
+}
+
+// wavelengthColour approximates the colour of visible light of the given
+// wavelength in nm.
+func wavelengthColour(wl float64) color.RGBA {
+	var r, g, b float64
+	switch {
+	case wl < 440:
+		r, b = -(wl-440)/(440-380), 1
+	case wl < 490:
+		g, b = (wl-440)/(490-440), 1
+	case wl < 510:
+		g, b = 1, -(wl-510)/(510-490)
+	case wl < 580:
+		r, g = (wl-510)/(580-510), 1
+	case wl < 645:
+		r, g = 1, -(wl-645)/(645-580)
+	default:
+		r = 1
+	}
+	// Fade out towards the edges of human vision
+	f := 1.0
+	switch {
+	case wl < 420:
+		f = 0.3 + 0.7*(wl-380)/(420-380)
+	case wl > 700:
+		f = 0.3 + 0.7*(750-wl)/(750-700)
+	}
+	c := func(v float64) uint8 { return uint8(math.Round(255 * math.Pow(v*f, 0.8))) }
+	return color.RGBA{c(r), c(g), c(b), 255}
+}
+
+// DrawSpectrum draws the emission lines across rect on a black background,
+// dimming weaker lines.
+func DrawSpectrum(img *image.RGBA, rect image.Rectangle, lines []SpectralLine) {
+	draw.Draw(img, rect, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	lw := max(1, rect.Dx()/200)
+	for _, l := range lines {
+		if l.Wavelength < MinVisible || l.Wavelength > MaxVisible {
+			continue
+		}
+		x := rect.Min.X + int((l.Wavelength-MinVisible)/(MaxVisible-MinVisible)*float64(rect.Dx()-lw))
+		c := wavelengthColour(l.Wavelength)
+		a := 0.35 + 0.65*math.Min(1, l.Intensity)
+		c = color.RGBA{uint8(float64(c.R) * a), uint8(float64(c.G) * a), uint8(float64(c.B) * a), 255}
+		draw.Draw(img, image.Rect(x, rect.Min.Y, x+lw, rect.Max.Y), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+}
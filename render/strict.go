@@ -0,0 +1,146 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// CheckStrict returns every problem that -strict turns into an error, for
+// all the elements: a category without a colour, a character the font has
+// no glyph for, or text too wide for its place on the card. Without
+// -strict, the cards are drawn anyway with the unknown colour, a blank box
+// for the glyph and the text running over the border.
+func (r *Renderer) CheckStrict(elements []Element) error {
+	if !r.Options.Strict {
+		return nil
+	}
+	f, err := r.strictFont()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range elements {
+		errs = append(errs, r.checkCard(f, e)...)
+	}
+	return errors.Join(errs...)
+}
+
+// strictFont parses the -font for checkCard to look up glyphs in.
+func (r *Renderer) strictFont() (*sfnt.Font, error) {
+	bs, err := ReadFont(r.Options.FontPath)
+	if err != nil {
+		return nil, err
+	}
+	return opentype.Parse(bs)
+}
+
+func (r *Renderer) checkCard(f *sfnt.Font, e Element) []error {
+	var errs []error
+	if _, ok := r.Colours[r.Category(e.Type)]; !ok {
+		errs = append(errs, fmt.Errorf("%s: no colour for category %q in %s", e.Symbol, e.Type, r.Options.ColoursPath))
+	}
+
+	l := r.illustrated(r.Layout(), e)
+	fields := []struct {
+		what string
+		face font.Face
+		pos  TextPos
+		txt  string
+	}{
+		{"number", r.numFont, l.Number, fmt.Sprint(e.Number)},
+		{"symbol", r.symFont, l.Symbol, e.Symbol},
+	}
+	if r.showMass() {
+		fields = append(fields, struct {
+			what string
+			face font.Face
+			pos  TextPos
+			txt  string
+		}{"mass", r.MassFont, l.Mass, r.cardMass(e)})
+	}
+	nameFont, _ := r.cardNameFont(e)
+	for _, ln := range r.nameLines(l, nameFont, e.Name) {
+		fields = append(fields, struct {
+			what string
+			face font.Face
+			pos  TextPos
+			txt  string
+		}{"name", nameFont, ln.pos, ln.txt})
+	}
+	if unit := r.MassUnit(); unit != "" && l.MassUnit.Align == alignCentre {
+		fields = append(fields, struct {
+			what string
+			face font.Face
+			pos  TextPos
+			txt  string
+		}{"mass unit", r.noteFont, l.MassUnit, unit})
+	}
+	var buf sfnt.Buffer
+	glyphs := fields
+	if note, ok := r.notes[e.Symbol]; ok {
+		glyphs = append(glyphs, fields[0])
+		glyphs[len(glyphs)-1].what, glyphs[len(glyphs)-1].txt = "note", note
+	}
+	for _, fl := range glyphs {
+		for _, g := range textGlyphs(fl.face, fl.txt) {
+			for _, c := range []rune(g.String()) {
+				if i, err := f.GlyphIndex(&buf, c); err != nil || i == 0 {
+					errs = append(errs, fmt.Errorf("%s: font has no glyph for %q in the %s %q", e.Symbol, c, fl.what, fl.txt))
+				}
+			}
+		}
+	}
+
+	// Centred text has to fit inside the border from the top of its glyphs
+	// to the bottom. Text in the corners shares its row with the text in the
+	// other corner.
+	bt, pad := r.borderThickness(), r.TileH/20
+	outline := r.Outline()(r.TileW, r.TileH, float64(bt))
+	corners := 0
+	for _, fl := range fields {
+		w := MeasureText(fl.face, fl.txt).Round()
+		room := 0
+		switch {
+		case fl.what == "name" && l.NameRadius > 0:
+			// Curved over the top half of the card.
+			room = int(math.Pi * float64(l.NameRadius) * 0.9)
+		case fl.pos.Align == alignCentre:
+			ink, _ := BoundText(fl.face, fl.txt)
+			room = min(widthAt(outline, fl.pos.Y+ink.Min.Y.Floor()), widthAt(outline, fl.pos.Y+ink.Max.Y.Ceil()))
+		default:
+			corners += w + pad
+			continue
+		}
+		if w > room {
+			errs = append(errs, fmt.Errorf("%s: the %s %q is %dpx wide but only %dpx fit", e.Symbol, fl.what, fl.txt, w, room))
+		}
+	}
+	if room := r.TileW - 2*(bt+pad); corners-pad > room {
+		errs = append(errs, fmt.Errorf("%s: the number and mass are %dpx wide together but only %dpx fit", e.Symbol, corners-pad, room))
+	}
+	return errs
+}
+
+// widthAt returns the width of a convex polygon along the line at y, or 0
+// if the line misses it.
+func widthAt(pts []Point, y int) int {
+	fy := float64(y)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, a := range pts {
+		b := pts[(i+1)%len(pts)]
+		if (a.Y > fy) == (b.Y > fy) {
+			continue
+		}
+		x := a.X + (fy-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+		lo, hi = math.Min(lo, x), math.Max(hi, x)
+	}
+	if hi < lo {
+		return 0
+	}
+	return int(hi - lo)
+}
@@ -0,0 +1,169 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Card styles accepted by -style.
+const (
+	StyleClassic = "classic"
+	// StyleKids is for young children: rounded corners, no atomic mass,
+	// bigger type and a picture of something made of or with each element
+	// from -illustrations, such as a balloon for helium.
+	StyleKids = "kids"
+	// StyleOutline leaves the cards unfilled and draws their border and
+	// text as outlines in the category colour, for laser engraving, pen
+	// plotters and colouring-in worksheets.
+	StyleOutline = "outline"
+)
+
+func checkStyle(style, shape string) error {
+	switch style {
+	case StyleClassic:
+		return nil
+	case StyleKids:
+		if shape != ShapeRect {
+			return fmt.Errorf("-style %s draws its own rounded cards; leave -shape as %s", StyleKids, ShapeRect)
+		}
+		return nil
+	case StyleOutline:
+		return nil
+	}
+	return fmt.Errorf("unknown -style %q (want %s, %s or %s)", style, StyleClassic, StyleKids, StyleOutline)
+}
+
+// showMass reports whether the cards have the atomic mass on them.
+func (r *Renderer) showMass() bool { return r.Options.Style != StyleKids && r.preset.shows("mass") }
+
+// cornerRadius is how round the corners of -style kids cards are.
+func cornerRadius(h int) float64 { return float64(h) / 7 }
+
+// roundedRect returns the outline of a w×h card with rounded corners,
+// moved in by inset px. The corners share their centres at every inset,
+// so a border drawn between two of them is the same width all round.
+func roundedRect(w, h int, inset float64) []Point {
+	rad := math.Max(0, cornerRadius(h)-inset)
+	c := cornerRadius(h)
+	centres := []Point{{float64(w) - c, c}, {float64(w) - c, float64(h) - c}, {c, float64(h) - c}, {c, c}}
+	const steps = 12
+	var pts []Point
+	for i, ctr := range centres {
+		// Clockwise from the top right, each corner a quarter turn on.
+		start := -math.Pi/2 + float64(i)*math.Pi/2
+		for s := 0; s <= steps; s++ {
+			a := start + math.Pi/2*float64(s)/steps
+			pts = append(pts, Point{ctr.X + rad*math.Cos(a), ctr.Y + rad*math.Sin(a)})
+		}
+	}
+	return pts
+}
+
+// illustrated returns the layout of an element's card: l, with the symbol
+// moved over to the left of the picture if the element has one.
+func (r *Renderer) illustrated(l CardLayout, e Element) CardLayout {
+	if _, ok := r.illustrations[e.Symbol]; !ok {
+		return l
+	}
+	left := r.borderThickness() + r.TileH/20
+	w := MeasureText(r.symFont, e.Symbol).Round()
+	l.Symbol = TextPos{X: (left + l.Illustration.Min.X - w) / 2, Y: l.Symbol.Y, Align: alignLeft}
+	return l
+}
+
+// illustration is a picture for an element's card and where it goes.
+type illustration struct {
+	img  *image.RGBA
+	rect image.Rectangle
+}
+
+// loadIllustrations finds the pictures in an -illustrations folder, named
+// after the element they go on, such as He.png or C.jpg, and scales each
+// to fit in box.
+func loadIllustrations(dir string, box image.Rectangle) (map[string]illustration, error) {
+	pics := map[string]illustration{}
+	if dir == "" {
+		return pics, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, de := range entries {
+		ext := strings.ToLower(filepath.Ext(de.Name()))
+		if de.IsDir() || ext != ".png" && ext != ".jpg" && ext != ".jpeg" {
+			continue
+		}
+		img, rect, err := LoadImageFitted(filepath.Join(dir, de.Name()), box)
+		if err != nil {
+			return nil, err
+		}
+		pics[strings.TrimSuffix(de.Name(), filepath.Ext(de.Name()))] = illustration{img, rect}
+	}
+	return pics, nil
+}
+
+// outlineWidth is how thick the lines of -style outline text are.
+func (r *Renderer) outlineWidth() int { return max(1, r.TileH/150) }
+
+// cutOut clears the inside of the polygon pts from img, leaving it
+// transparent with a smooth edge.
+func cutOut(img *image.RGBA, pts []Point) {
+	mask := image.NewRGBA(img.Bounds())
+	FillPolygon(mask, pts, color.White)
+	for i := 3; i < len(img.Pix); i += 4 {
+		keep := 255 - uint32(mask.Pix[i])
+		for j := i - 3; j <= i; j++ {
+			img.Pix[j] = uint8(uint32(img.Pix[j]) * keep / 255)
+		}
+	}
+}
+
+// strokeInk returns the outlines of everything drawn on ink, as lines
+// width px thick on the inside of each shape's edge, in c. A pixel is ink
+// if it is at least half opaque.
+func strokeInk(ink *image.RGBA, width int, c color.RGBA) *image.RGBA {
+	b := ink.Bounds()
+	w, h := b.Dx(), b.Dy()
+	on := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			on[y*w+x] = ink.Pix[y*ink.Stride+x*4+3] >= 128
+		}
+	}
+	// Erode the ink by width across and then down; what the erosion takes
+	// away is the outline.
+	erode := func(src []bool, n, stride, count, step int) []bool {
+		dst := make([]bool, len(src))
+		for line := 0; line < count; line++ {
+			base := line * step
+			run := 0 // ink pixels in a row up to here
+			for i := 0; i < n; i++ {
+				if src[base+i*stride] {
+					run++
+				} else {
+					run = 0
+				}
+				// A pixel survives if the width px either side of it are
+				// ink; mark it once the run reaches past it.
+				if run > 2*width && i-width >= 0 {
+					dst[base+(i-width)*stride] = true
+				}
+			}
+		}
+		return dst
+	}
+	eroded := erode(erode(on, w, 1, h, w), h, w, w, 1)
+	out := image.NewRGBA(b)
+	for i, ink := range on {
+		if ink && !eroded[i] {
+			out.SetRGBA(b.Min.X+i%w, b.Min.Y+i/w, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image/png"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+)
+
+// FontFamily returns the family name of the font at path, for SVG output
+// to ask the browser for the same font.
+func FontFamily(path string) string {
+	bs, err := ReadFont(path)
+	if err == nil {
+		if f, err := opentype.Parse(bs); err == nil {
+			if name, err := f.Name(nil, sfnt.NameIDFamily); err == nil {
+				return name
+			}
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// SVGCardShape returns the outline of a card, moved in by inset px, as an
+// SVG element with the given attributes.
+func SVGCardShape(r *Renderer, inset float64, attrs string) string {
+	tileW, tileH := r.TileW, r.TileH
+	switch r.Options.Shape {
+	case ShapeHex:
+		var sb strings.Builder
+		for _, p := range hexagon(tileW, tileH, inset) {
+			fmt.Fprintf(&sb, "%.1f,%.1f ", p.X, p.Y)
+		}
+		return fmt.Sprintf(`<polygon points="%s" %s/>`, sb.String(), attrs)
+	case ShapeCircle:
+		return fmt.Sprintf(`<circle cx="%d" cy="%d" r="%.1f" %s/>`, tileW/2, tileH/2, float64(tileH)/2-inset, attrs)
+	}
+	if r.Options.Style == StyleKids {
+		attrs = fmt.Sprintf(`rx="%.1f" %s`, math.Max(0, cornerRadius(tileH)-inset), attrs)
+	}
+	return fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" %s/>`, inset, inset, float64(tileW)-2*inset, float64(tileH)-2*inset, attrs)
+}
+
+// WriteCardSVG writes the shapes and text of an element's card, at the
+// origin, as SVG.
+func WriteCardSVG(b *bytes.Buffer, r *Renderer, l CardLayout, e Element) {
+	tileW, tileH := r.TileW, r.TileH
+	bt := float64(r.borderThickness())
+	text := func(p TextPos, size float64, txt string) {
+		x, anchor := p.X, "start"
+		switch p.Align {
+		case alignCentre:
+			x, anchor = tileW/2, "middle"
+		case alignRight:
+			anchor = "end"
+		}
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="%.1f" text-anchor="%s">%s</text>`, x, p.Y, size, anchor, html.EscapeString(txt))
+	}
+	sizes := r.fontSizes()
+	c := r.CategoryColour(e.Type)
+	l = r.illustrated(l, e)
+
+	// The border is stroked along the middle of where it is drawn on the PNG
+	// cards.
+	fill := "#fff"
+	if r.Options.Style == StyleOutline {
+		fill = "none"
+	}
+	ch := r.cardChanges(e)
+	stroke := fmt.Sprintf(`fill="%s" stroke="#%02x%02x%02x" stroke-width="%.1f"%s`, fill, c.R, c.G, c.B, bt, SVGDash(ch.border, bt))
+	b.WriteString(SVGCardShape(r, bt/2, stroke))
+	if r.Options.Occurrence {
+		b.WriteString(r.occurrenceSVG(e))
+	}
+	if p, ok := r.patterns[r.Category(e.Type)]; ok {
+		b.WriteString(r.patternSVG(fmt.Sprintf("pattern-%d", e.Number), p, patternColour(c)))
+	}
+	if r.Options.Style == StyleOutline {
+		// Everything on the card that isn't filled with a colour of its
+		// own, the text above all, is outlined instead of filled.
+		fmt.Fprintf(b, `<g fill="none" stroke="%s" stroke-width="%d" stroke-linejoin="round">`, RGBHex(c), r.outlineWidth())
+		defer b.WriteString("</g>")
+	}
+
+	text(l.Number, sizes.num, fmt.Sprint(e.Number))
+	if r.showMass() {
+		text(l.Mass, sizes.mass, r.cardMass(e))
+		if unit := r.MassUnit(); unit != "" {
+			text(l.MassUnit, sizes.note, unit)
+		}
+	}
+	if pic, ok := r.illustrations[e.Symbol]; ok {
+		var data bytes.Buffer
+		png.Encode(&data, pic.img) // can't fail in memory
+		fmt.Fprintf(b, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`,
+			pic.rect.Min.X, pic.rect.Min.Y, pic.rect.Dx(), pic.rect.Dy(), base64.StdEncoding.EncodeToString(data.Bytes()))
+	}
+	text(l.Symbol, sizes.sym, e.Symbol)
+	if r.Options.Lewis {
+		dots, rad := r.lewisDots(e)
+		for _, d := range dots {
+			fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f"/>`, d.X, d.Y, rad)
+		}
+	}
+	nameFont, nameSize := r.cardNameFont(e)
+	if l.NameRadius > 0 && r.showName() {
+		id := fmt.Sprintf("arc-%d", e.Number)
+		cx, cy, rad := tileW/2, tileH/2, l.NameRadius
+		fmt.Fprintf(b, `<path id="%s" d="M %d %d A %d %d 0 0 1 %d %d" fill="none"/>`, id, cx-rad, cy, rad, rad, cx+rad, cy)
+		fmt.Fprintf(b, `<text font-size="%.1f"><textPath href="#%s" startOffset="50%%" text-anchor="middle">%s</textPath></text>`, nameSize, id, html.EscapeString(e.Name))
+	} else {
+		for _, ln := range r.nameLines(l, nameFont, e.Name) {
+			text(ln.pos, nameSize, ln.txt)
+		}
+	}
+	if r.Options.Valence {
+		ring := l.Ring
+		fmt.Fprintf(b, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="#000" stroke-width="%.1f"/>`,
+			float64(ring.Min.X+ring.Max.X)/2, float64(ring.Min.Y+ring.Max.Y)/2, float64(ring.Dx())/2*11/12, math.Max(1, float64(ring.Dx())/12))
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="%.1f" text-anchor="middle" dominant-baseline="central">%d</text>`,
+			(ring.Min.X+ring.Max.X)/2, (ring.Min.Y+ring.Max.Y)/2, sizes.note, e.Valence)
+	}
+	if r.Options.PhaseBar {
+		b.WriteString(phaseBarSVG(l.PhaseBar, e))
+	}
+	if r.Options.Compounds {
+		b.WriteString(r.compoundsSVG(l.Compounds, e, sizes.note))
+	}
+	if r.Options.Shells {
+		b.WriteString(r.bandTextSVG(l.Shells, FormatShells(ShellOccupancy(e)), sizes.note))
+	}
+	if r.Options.Magnetism {
+		b.WriteString(r.bandTextSVG(l.Magnetism, magnetismLabel(e.Symbol), sizes.note))
+	}
+	if r.Options.StableIsotopes {
+		b.WriteString(r.bandTextSVG(l.Isotopes, stableIsotopesLabel(e.Symbol), sizes.note))
+	}
+	if r.label != nil {
+		b.WriteString(r.bandTextSVG(l.Label, r.label.String(e), sizes.note))
+	}
+	if note, ok := r.notes[e.Symbol]; ok {
+		fmt.Fprintf(b, `<text x="%d" y="%d" font-size="%.1f">%s</text>`, l.Note.Min.X, l.Note.Max.Y-l.Note.Dy()/4, sizes.note, html.EscapeString(FitText(r.noteFont, note, l.Note.Dx())))
+	}
+}
@@ -0,0 +1,17 @@
+package render
+
+// States of matter. Each is drawn as a category, so -colours can change
+// them; these are the colours used when it doesn't.
+const (
+	PhaseSolid   = "solid"
+	PhaseLiquid  = "liquid"
+	PhaseGas     = "gas"
+	PhaseUnknown = "unknown"
+)
+
+var PhaseColours = map[string]string{
+	PhaseSolid:   "#3d4f63",
+	PhaseLiquid:  "#2f86d6",
+	PhaseGas:     "#e4572e",
+	PhaseUnknown: "#cccccc",
+}
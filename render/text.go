@@ -0,0 +1,135 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/text/unicode/norm"
+)
+
+// glyph is one character as it is drawn: a base and the combining marks
+// placed on it.
+type glyph struct {
+	base  rune
+	marks []rune
+}
+
+func (g glyph) String() string { return string(append([]rune{g.base}, g.marks...)) }
+
+func isMark(c rune) bool { return unicode.In(c, unicode.Mn, unicode.Me) }
+
+// textGlyphs splits text into the glyphs to draw it with. The text is
+// normalised to its composed form first, so "Ç" typed as a C and a
+// combining cedilla is drawn with the font's own Ç; characters the font
+// has no glyph for are drawn decomposed, as a base and marks, if it has
+// those instead.
+func textGlyphs(face font.Face, txt string) []glyph {
+	has := func(c rune) bool { _, ok := face.GlyphAdvance(c); return ok }
+	var gs []glyph
+	for _, c := range norm.NFC.String(txt) {
+		if isMark(c) && len(gs) > 0 {
+			gs[len(gs)-1].marks = append(gs[len(gs)-1].marks, c)
+			continue
+		}
+		gs = append(gs, glyph{base: c})
+	}
+	for i, g := range gs {
+		if has(g.base) {
+			continue
+		}
+		d := []rune(norm.NFD.String(string(g.base)))
+		if len(d) > 1 && has(d[0]) {
+			gs[i] = glyph{base: d[0], marks: append(d[1:], g.marks...)}
+		}
+	}
+	return gs
+}
+
+// MeasureText returns how far drawing text advances the dot: the advances
+// of its base characters and the kerning between them, as combining marks
+// don't take up any room of their own.
+func MeasureText(face font.Face, txt string) fixed.Int26_6 {
+	var w fixed.Int26_6
+	prev := rune(-1)
+	for _, g := range textGlyphs(face, txt) {
+		if prev >= 0 {
+			w += face.Kern(prev, g.base)
+		}
+		adv, _ := face.GlyphAdvance(g.base)
+		w += adv
+		prev = g.base
+	}
+	return w
+}
+
+// markOffset is how far right of the start of its base a mark is drawn.
+// Marks made to be typeset have no advance and are drawn back over the
+// character before them; any others are centred over their base.
+func markOffset(face font.Face, base, mark rune) fixed.Int26_6 {
+	adv, _ := face.GlyphAdvance(base)
+	madv, _ := face.GlyphAdvance(mark)
+	if madv == 0 {
+		return adv
+	}
+	return (adv - madv) / 2
+}
+
+// BoundText returns the ink bounds of text drawn from the origin, marks
+// included, and how far it advances the dot.
+func BoundText(face font.Face, txt string) (fixed.Rectangle26_6, fixed.Int26_6) {
+	var bounds fixed.Rectangle26_6
+	var x fixed.Int26_6
+	prev := rune(-1)
+	for _, g := range textGlyphs(face, txt) {
+		if prev >= 0 {
+			x += face.Kern(prev, g.base)
+		}
+		add := func(c rune, at fixed.Int26_6) {
+			b, _, ok := face.GlyphBounds(c)
+			if !ok || b.Empty() {
+				return
+			}
+			b.Min.X += at
+			b.Max.X += at
+			bounds = bounds.Union(b)
+		}
+		add(g.base, x)
+		for _, m := range g.marks {
+			add(m, x+markOffset(face, g.base, m))
+		}
+		adv, _ := face.GlyphAdvance(g.base)
+		x += adv
+		prev = g.base
+	}
+	return bounds, x
+}
+
+// DrawText draws text with its baseline starting at (x, y), placing any
+// combining marks on the characters they belong to.
+func DrawText(img *image.RGBA, face font.Face, x, y int, txt string, col color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	prev := rune(-1)
+	for _, g := range textGlyphs(face, txt) {
+		if prev >= 0 {
+			d.Dot.X += face.Kern(prev, g.base)
+		}
+		start := d.Dot
+		drawGlyph(d, g.base, col)
+		end := d.Dot
+		for _, m := range g.marks {
+			d.Dot = start
+			d.Dot.X += markOffset(face, g.base, m)
+			drawGlyph(d, m, col)
+		}
+		d.Dot = end
+		prev = g.base
+	}
+}
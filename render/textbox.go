@@ -0,0 +1,97 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+// TextBox lays text out down a rectangle of a card: paragraphs wrapped to
+// its width and tables of labelled values, one after another from the top.
+// Whatever doesn't fit is cut off with an ellipsis at the bottom.
+type TextBox struct {
+	img  *image.RGBA
+	face font.Face
+	rect image.Rectangle
+	y    int // baseline of the next line
+	full bool
+}
+
+func NewTextBox(img *image.RGBA, face font.Face, rect image.Rectangle) *TextBox {
+	return &TextBox{img: img, face: face, rect: rect, y: rect.Min.Y + face.Metrics().Ascent.Round()}
+}
+
+func (b *TextBox) lineHeight() int { return b.face.Metrics().Height.Round() }
+
+// line starts a new line, or reports that there is no room for one. The
+// last line that fits is marked full, so it can end with an ellipsis.
+func (b *TextBox) line() (y int, ok bool) {
+	if b.full || b.y+b.face.Metrics().Descent.Round() > b.rect.Max.Y {
+		b.full = true
+		return 0, false
+	}
+	y = b.y
+	b.y += b.lineHeight()
+	if b.y+b.face.Metrics().Descent.Round() > b.rect.Max.Y {
+		b.full = true
+	}
+	return y, true
+}
+
+// gap leaves a fraction of a line empty.
+func (b *TextBox) gap(lines float64) { b.y += int(float64(b.lineHeight()) * lines) }
+
+// Paragraph draws txt wrapped to the width of the box.
+func (b *TextBox) Paragraph(txt string) {
+	lines := WrapText(b.face, txt, b.rect.Dx())
+	for i, ln := range lines {
+		y, ok := b.line()
+		if !ok {
+			return
+		}
+		if b.full && i < len(lines)-1 {
+			ln = FitText(b.face, ln+"…", b.rect.Dx())
+		}
+		DrawText(b.img, b.face, b.rect.Min.X, y, ln, color.Black)
+	}
+}
+
+// table draws one row per label and value, the labels on the left and the
+// values right-aligned, shortened if they would run into their label. It
+// returns the rows there wasn't room for.
+func (b *TextBox) table(rows [][2]string) [][2]string {
+	for i, row := range rows {
+		y, ok := b.line()
+		if !ok {
+			return rows[i:]
+		}
+		DrawText(b.img, b.face, b.rect.Min.X, y, row[0], color.Black)
+		room := b.rect.Dx() - MeasureText(b.face, row[0]+"  ").Round()
+		v := FitText(b.face, row[1], room)
+		DrawText(b.img, b.face, b.rect.Max.X-MeasureText(b.face, v).Round(), y, v, color.Black)
+	}
+	return nil
+}
+
+// WrapText breaks text into lines at most width px wide, between words.
+// A word wider than a line on its own gets a line to itself.
+func WrapText(face font.Face, txt string, width int) []string {
+	var lines []string
+	for _, para := range strings.Split(txt, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			if line != "" && MeasureText(face, line+" "+word).Round() > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
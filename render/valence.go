@@ -0,0 +1,65 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"golang.org/x/image/font"
+)
+
+// What -colour-by colours the cards by.
+const (
+	ColourByCategory = "category"
+	ColourByValence  = "valence"
+)
+
+func checkColourBy(by string) error {
+	switch by {
+	case ColourByCategory, ColourByValence, ColourByMagnetism, ColourByOccurrence, ColourByStableIsotopes:
+		return nil
+	}
+	return fmt.Errorf("unknown -colour-by %q (want %s, %s, %s, %s or %s)", by,
+		ColourByCategory, ColourByValence, ColourByMagnetism, ColourByOccurrence, ColourByStableIsotopes)
+}
+
+// ValenceCategory is the category a card is drawn as with -colour-by
+// valence.
+func ValenceCategory(n int) string { return fmt.Sprintf("valence %d", n) }
+
+// valenceColours run from blue for one valence electron to red for twelve,
+// with grey for none. -colours can change them by category name.
+var valenceColours = func() map[string]string {
+	m := map[string]string{ValenceCategory(0): "#cccccc"}
+	for n := 1; n <= 12; n++ {
+		m[ValenceCategory(n)] = RGBHex(HSLToRGB(220-float64(n-1)*20, 0.55, 0.55))
+	}
+	return m
+}()
+
+// addValenceColours fills in the valence categories missing from -colours.
+func addValenceColours(r *Renderer) {
+	for category, c := range valenceColours {
+		if _, ok := r.Colours[category]; !ok {
+			r.Colours[category] = c
+		}
+	}
+}
+
+// drawValence draws the valence electron count in a ring, so it isn't
+// mistaken for the atomic number.
+func drawValence(img *image.RGBA, face font.Face, rect image.Rectangle, n int) {
+	w := math.Max(1, float64(rect.Dx())/12)
+	ring := Circle(rect.Dx(), rect.Dy(), w/2)
+	for i := range ring {
+		ring[i].X += float64(rect.Min.X)
+		ring[i].Y += float64(rect.Min.Y)
+	}
+	StrokePolygon(img, ring, w, color.Black)
+	txt := fmt.Sprint(n)
+	ink, _ := BoundText(face, txt)
+	x := (rect.Min.X+rect.Max.X)/2 - MeasureText(face, txt).Round()/2
+	y := (rect.Min.Y+rect.Max.Y)/2 - (ink.Min.Y+ink.Max.Y).Round()/2
+	DrawText(img, face, x, y, txt, color.Black)
+}
@@ -0,0 +1,82 @@
+package render
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+// Vertical alignments accepted by -valign. The layout places each field by
+// its baseline for a typical font, whose capitals are about 0.7 em tall;
+// the others centre the field on the same line using the real font's
+// metrics, so fonts with unusually tall or short letters still look
+// centred.
+const (
+	valignBaseline = "baseline"
+	valignCap      = "cap"    // the capitals
+	valignMiddle   = "middle" // the line from ascent to descent
+)
+
+// valignFields are the fields -valign can set.
+var valignFields = []string{"number", "mass", "symbol", "name"}
+
+// parseVAlign reads -valign: either one alignment for every field, or
+// comma-separated field=alignment pairs such as "symbol=cap,name=middle".
+func parseVAlign(s string) (map[string]string, error) {
+	m := map[string]string{}
+	check := func(a string) error {
+		switch a {
+		case valignBaseline, valignCap, valignMiddle:
+			return nil
+		}
+		return fmt.Errorf("unknown -valign %q (want %s, %s or %s)", a, valignBaseline, valignCap, valignMiddle)
+	}
+	if s == "" {
+		return m, nil
+	}
+	if !strings.Contains(s, "=") {
+		if err := check(s); err != nil {
+			return nil, err
+		}
+		for _, f := range valignFields {
+			m[f] = s
+		}
+		return m, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		field, a, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		if !slices.Contains(valignFields, field) {
+			return nil, fmt.Errorf("-valign: unknown field %q (want %s)", field, strings.Join(valignFields, ", "))
+		}
+		if err := check(a); err != nil {
+			return nil, err
+		}
+		m[field] = a
+	}
+	return m, nil
+}
+
+// capHeight returns the height of a face's capitals, measuring an H if
+// the font doesn't say.
+func capHeight(face font.Face) int {
+	if c := face.Metrics().CapHeight; c > 0 {
+		return c.Round()
+	}
+	ink, _ := BoundText(face, "H")
+	return -ink.Min.Y.Round()
+}
+
+// alignVertically moves p's baseline so the field is centred, by a, on the
+// line a typical font at size px would centre its capitals on.
+func alignVertically(p *TextPos, face font.Face, size float64, a string) {
+	mid := p.Y - int(size*0.35)
+	switch a {
+	case valignCap:
+		p.Y = mid + capHeight(face)/2
+	case valignMiddle:
+		m := face.Metrics()
+		p.Y = mid + (m.Ascent.Round()-m.Descent.Round())/2
+	}
+}
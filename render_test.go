@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+
+	"periodic-table-tiles/render"
+)
+
+// TestDefaultOptions checks that render.DefaultOptions draws the cards the
+// tool draws without flags.
+func TestDefaultOptions(t *testing.T) {
+	opts := render.DefaultOptions()
+	opts.Height = 60
+	r, err := render.New(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	samePixels(t, testRenderer(t, "-height", "60").Tile(testHelium), r.Tile(testHelium))
+}
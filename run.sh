@@ -1 +1 @@
-go run main.go   -font Roboto-Bold.ttf   -colours colours.json   -outdir elements   -height 600
\ No newline at end of file
+go run .   -font Roboto-Bold.ttf   -colours colours.json   -outdir elements   -height 600
\ No newline at end of file
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log/slog"
+	"math/rand/v2"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+
+	"periodic-table-tiles/render"
+)
+
+// sampleEstimate is what a -sample-report run predicts for the whole run
+// from the cards it rendered.
+type sampleEstimate struct {
+	Sampled int
+	Total   int
+	Elapsed time.Duration // of the whole run
+	Bytes   int64         // of the whole run's cards
+}
+
+func (s sampleEstimate) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("sampled", s.Sampled),
+		slog.Int("total", s.Total),
+		slog.Duration("elapsed", s.Elapsed),
+		slog.Int64("bytes", s.Bytes),
+	)
+}
+
+func (s sampleEstimate) String() string {
+	return fmt.Sprintf("%d of %d cards rendered; all of them would take about %s and %.1f MB",
+		s.Sampled, s.Total, s.Elapsed.Round(time.Second), float64(s.Bytes)/1e6)
+}
+
+// sampleReport renders n cards chosen at random, at the run's settings,
+// into a review folder with a contact sheet of them, and logs how long the
+// whole batch would take and how much room it would need, to check an
+// expensive run before starting it. The folder is review inside -outdir,
+// or in the current directory when -outdir is an archive or a bucket.
+func sampleReport(ctx context.Context, r *render.Renderer, elements []Element, o *options, n int) error {
+	ro := *o
+	ro.outdir = "review"
+	if _, archive := archiveFormats[strings.ToLower(filepath.Ext(o.outdir))]; !archive && !strings.Contains(o.outdir, "://") {
+		ro.outdir = filepath.Join(o.outdir, "review")
+	}
+	st, err := openStore(&ro)
+	if err != nil {
+		return err
+	}
+
+	picked := rand.Perm(len(elements))[:min(n, len(elements))]
+	slices.Sort(picked)
+	var took time.Duration
+	var size int64
+	var thumbs []*image.RGBA
+	var names []string
+	for _, i := range picked {
+		if ctx.Err() != nil {
+			return errors.New("interrupted")
+		}
+		e := elements[i]
+		fname := tileFilename(e)
+		began := time.Now()
+		img := r.Tile(e)
+		if err := savePNG(st, fname, img, &ro); err != nil {
+			return err
+		}
+		elapsed := time.Since(began)
+		_, written, err := st.sha256(fname)
+		if err != nil {
+			return err
+		}
+		took += elapsed
+		size += written
+		logger.Info("Written", "path", filepath.Join(ro.outdir, fname), "elapsed", elapsed.Round(time.Millisecond))
+
+		thumbH := min(r.TileH, 240)
+		thumb := image.NewRGBA(image.Rect(0, 0, max(1, r.TileW*thumbH/r.TileH), thumbH))
+		render.Resamplers[o.Resample].Scale(thumb, thumb.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+		thumbs = append(thumbs, thumb)
+		names = append(names, fname+", "+elapsed.Round(time.Millisecond).String())
+	}
+	if len(thumbs) > 0 {
+		sheet, err := contactSheet(o.FontPath, thumbs, names, min(len(thumbs), 10))
+		if err != nil {
+			return err
+		}
+		if err := savePNG(st, "contact_sheet.png", sheet, &ro); err != nil {
+			return err
+		}
+		logger.Info("Written", "path", filepath.Join(ro.outdir, "contact_sheet.png"))
+	}
+	if err := st.Close(); err != nil {
+		return err
+	}
+
+	est := sampleEstimate{Sampled: len(picked), Total: len(elements)}
+	if est.Sampled > 0 {
+		est.Elapsed = took / time.Duration(est.Sampled) * time.Duration(est.Total)
+		est.Bytes = size / int64(est.Sampled) * int64(est.Total)
+	}
+	logger.Info("Sample report", "estimate", est)
+	return nil
+}
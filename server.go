@@ -0,0 +1,725 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"periodic-table-tiles/render"
+)
+
+// Limits on what a request to the server may ask for.
+const (
+	minServeHeight = 16
+	maxServeHeight = 8000
+
+	// maxRenderPixels is the most pixels one /tiles or /render request may
+	// have drawn, supersampling included. A card can be 8000 px high,
+	// which is 83 megapixels; this is about every element at 2600 px.
+	maxRenderPixels = 1 << 30
+
+	// maxCachedRenderers is how many renderers /tiles and /render keep
+	// between requests. A renderer caches the backgrounds of its cards,
+	// which at the biggest heights are hundreds of MB each.
+	maxCachedRenderers = 16
+)
+
+// job is an asynchronous render of a set of cards into a zip file. The
+// exported fields are what GET /jobs/{id} reports.
+type job struct {
+	ID       string     `json:"id"`
+	Status   string     `json:"status"` // queued, running, done or failed
+	Elements []string   `json:"elements,omitempty"`
+	Height   int        `json:"height"`
+	Summary  *summary   `json:"summary,omitempty"`
+	Error    string     `json:"error,omitempty"`
+	Created  time.Time  `json:"created"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Result   string     `json:"result,omitempty"` // where to download the zip once done
+
+	key       string // identical requests share a job
+	theme     int    // the server's theme when the job ran, see server.theme
+	notifyURL string
+	zip       []byte
+}
+
+// jobRequest is the body of POST /jobs. No elements means all of them, and
+// no height the server's -height.
+type jobRequest struct {
+	Elements  []string `json:"elements"`
+	Height    int      `json:"height"`
+	NotifyURL string   `json:"notify_url"`
+}
+
+// server renders cards over HTTP. Single cards are rendered while the
+// client waits; anything bigger goes through the job queue so requests
+// don't time out, and finished jobs are kept for -job-ttl so repeating a
+// request is served from them.
+type server struct {
+	opts     *options
+	elements []Element
+	jobTTL   time.Duration
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	queue chan *job
+	theme int // counts the reloads of the files jobs are drawn from
+
+	renderMu   sync.Mutex
+	renderers  map[rendererKey]*cachedRenderer // for /tiles and /render, up to maxCachedRenderers
+	renderTick int64                           // counts renderer lookups, for cachedRenderer.used
+
+	themesDir string // colours files /render can choose from, by name
+
+	notifyHosts []string // from -notify-hosts, the hosts a job's notify_url may name
+
+	apiKeys []string     // from -api-keys, or nil to let anyone in
+	limiter *rateLimiter // from -rate-limit, or nil
+	maxBody int64
+}
+
+func runServe(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	o := addFlags(fs)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	workers := fs.Int("workers", 1, "number of jobs rendered at once")
+	queueLen := fs.Int("queue", 16, "number of jobs that can wait before new ones are refused")
+	jobTTL := fs.Duration("job-ttl", time.Hour, "how long finished jobs and their results are kept")
+	apiKeys := fs.String("api-keys", "", "file of API keys, one a line, one of which every request must send as \"Authorization: Bearer <key>\" or \"X-API-Key: <key>\"")
+	rateLimit := fs.Int("rate-limit", 0, "requests a minute each API key, or address without one, may make; 0 for no limit")
+	maxBody := fs.Int64("max-body", 64<<10, "largest request body accepted, in bytes")
+	themesDir := fs.String("themes", "themes", "folder of colours files that requests to /render can choose as themes, by file name")
+	fs.StringVar(&o.notifyURL, "notify-url", "", "POST a JSON report to this URL when each job finishes, unless it names its own notify_url")
+	notifyHosts := fs.String("notify-hosts", "", "comma-separated hosts a job's notify_url may POST its report to, over https; without it only -notify-url is used")
+	watch := fs.Duration("watch", time.Second, "how often to check the colours, rules, font and other files the cards are drawn from, and redraw with them when they change; 0 to never")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if o.optimizer != "" {
+		return errors.New("serve keeps results in memory; -optimizer needs a local -outdir")
+	}
+	if *rateLimit < 0 || *maxBody < 1 {
+		return errors.New("-rate-limit can't be negative and -max-body must be at least 1")
+	}
+
+	// Check the flags render before taking requests.
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	if err := r.CheckStrict(elements); err != nil {
+		return err
+	}
+
+	s := &server{
+		opts:      o,
+		elements:  elements,
+		jobTTL:    *jobTTL,
+		jobs:      map[string]*job{},
+		queue:     make(chan *job, *queueLen),
+		renderers: map[rendererKey]*cachedRenderer{},
+		maxBody:   *maxBody,
+		themesDir: *themesDir,
+	}
+	for _, h := range strings.Split(*notifyHosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			s.notifyHosts = append(s.notifyHosts, strings.ToLower(h))
+		}
+	}
+	if *apiKeys != "" {
+		if s.apiKeys, err = loadAPIKeys(*apiKeys); err != nil {
+			return err
+		}
+	}
+	if *rateLimit > 0 {
+		s.limiter = newRateLimiter(*rateLimit)
+	}
+	if host, _, _ := net.SplitHostPort(*addr); s.apiKeys == nil && host != "localhost" && !net.ParseIP(host).IsLoopback() {
+		logger.Warn("Listening beyond this machine without -api-keys: anyone who can reach it can render", "addr", *addr)
+	}
+	for range max(1, *workers) {
+		go s.worker(ctx)
+	}
+	go s.expire(ctx)
+	if *watch > 0 {
+		go s.watch(ctx, *watch)
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           s.guard(s.routes()),
+		ReadHeaderTimeout: 10 * time.Second,
+		MaxHeaderBytes:    16 << 10,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	logger.Info("Listening", "addr", *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tiles/{element}", s.handleTile)
+	mux.HandleFunc("POST /jobs", s.handleNewJob)
+	mux.HandleFunc("GET /jobs/{id}", s.handleJob)
+	mux.HandleFunc("GET /jobs/{id}/result", s.handleJobResult)
+	mux.HandleFunc("POST /render", s.handleRender)
+	mux.HandleFunc("GET "+openAPIPath, handleOpenAPI)
+	return mux
+}
+
+// findElement looks an element up by symbol or atomic number.
+func (s *server) findElement(name string) (Element, bool) {
+	name = strings.TrimSuffix(name, ".png")
+	n, _ := strconv.Atoi(name)
+	for _, e := range s.elements {
+		if e.Number == n || strings.EqualFold(e.Symbol, name) {
+			return e, true
+		}
+	}
+	return Element{}, false
+}
+
+// findSymbols looks elements up by symbol or atomic number and returns
+// their symbols, sorted and without repeats.
+func (s *server) findSymbols(names []string) ([]string, error) {
+	var symbols []string
+	for _, name := range names {
+		e, ok := s.findElement(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown element %q", name)
+		}
+		symbols = append(symbols, e.Symbol)
+	}
+	slices.Sort(symbols)
+	return slices.Compact(symbols), nil
+}
+
+// heightParam returns the requested tile height, or the server default.
+func (s *server) heightParam(h int) (int, error) {
+	if h == 0 {
+		return s.opts.Height, nil
+	}
+	if h < minServeHeight || h > maxServeHeight {
+		return 0, fmt.Errorf("height must be between %d and %d", minServeHeight, maxServeHeight)
+	}
+	return h, nil
+}
+
+// options returns the server's options with the tile height changed.
+func (s *server) options(height int) *options {
+	o := *s.opts
+	o.Height = height
+	o.settings = maps.Clone(s.opts.settings)
+	o.settings["height"] = strconv.Itoa(height)
+	return &o
+}
+
+// rendererKey picks a cached renderer: the colours file it draws with and
+// the card height.
+type rendererKey struct {
+	colours string
+	height  int
+}
+
+// cachedRenderer is a renderer kept between requests, with the files it
+// was drawn from so -watch can tell when it is out of date.
+type cachedRenderer struct {
+	mu    sync.Mutex // font faces can't be shared between goroutines
+	r     *render.Renderer
+	files []string
+	used  int64 // server.renderTick when last looked up, guarded by server.renderMu
+}
+
+func (cr *cachedRenderer) tile(e Element) *image.RGBA {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return cr.r.Tile(e)
+}
+
+// renderer returns the cached renderer for a colours file and height,
+// making it if there isn't one yet. Once maxCachedRenderers are cached,
+// making another throws away the one looked up least recently.
+func (s *server) renderer(colours string, height int) (*cachedRenderer, error) {
+	s.renderMu.Lock()
+	defer s.renderMu.Unlock()
+	s.renderTick++
+	k := rendererKey{colours, height}
+	if cr, ok := s.renderers[k]; ok {
+		cr.used = s.renderTick
+		return cr, nil
+	}
+	o := s.options(height)
+	o.ColoursPath = colours
+	r, err := newRenderer(o)
+	if err != nil {
+		return nil, err
+	}
+	for len(s.renderers) >= maxCachedRenderers {
+		var oldest rendererKey
+		for k, cr := range s.renderers {
+			if old, ok := s.renderers[oldest]; !ok || cr.used < old.used {
+				oldest = k
+			}
+		}
+		delete(s.renderers, oldest)
+	}
+	cr := &cachedRenderer{r: r, files: watchedFiles(o), used: s.renderTick}
+	s.renderers[k] = cr
+	return cr, nil
+}
+
+// cardPixels is how many pixels are drawn for a card of a height,
+// supersampling included, from the shape of the cards rather than a
+// renderer so a request can be turned down before one is made.
+func (s *server) cardPixels(height int) int64 {
+	w, h := render.TileSize(s.opts.Shape, height)
+	if s.opts.Width > 0 {
+		w = s.opts.Width
+	}
+	ss := int64(max(1, s.opts.Supersample))
+	return int64(w) * int64(h) * ss * ss
+}
+
+// tooManyPixels replies with a 413 and returns true if pixels is over
+// maxRenderPixels.
+func tooManyPixels(w http.ResponseWriter, pixels int64) bool {
+	if pixels <= maxRenderPixels {
+		return false
+	}
+	http.Error(w, fmt.Sprintf("%d megapixels of cards asked for, more than the %d one request may render; use smaller heights or /jobs, or split it up", (pixels+1e6-1)/1e6, int64(maxRenderPixels)/1e6), http.StatusRequestEntityTooLarge)
+	return true
+}
+
+func (s *server) handleTile(w http.ResponseWriter, req *http.Request) {
+	e, ok := s.findElement(req.PathValue("element"))
+	if !ok {
+		http.Error(w, "unknown element", http.StatusNotFound)
+		return
+	}
+	h := 0
+	if v := req.URL.Query().Get("height"); v != "" {
+		h, _ = strconv.Atoi(v)
+		if h == 0 {
+			http.Error(w, "bad height", http.StatusBadRequest)
+			return
+		}
+	}
+	h, err := s.heightParam(h)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if tooManyPixels(w, s.cardPixels(h)) {
+		return
+	}
+
+	cr, err := s.renderer(s.opts.ColoursPath, h)
+	var buf bytes.Buffer
+	if err == nil {
+		err = encodePNG(&buf, cr.tile(e), s.opts)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	// The card changes when the files it is drawn from do.
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(buf.Bytes())
+}
+
+func (s *server) handleNewJob(w http.ResponseWriter, req *http.Request) {
+	var jr jobRequest
+	if !s.decodeBody(w, req, &jr) {
+		return
+	}
+	h, err := s.heightParam(jr.Height)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	symbols, err := s.findSymbols(jr.Elements)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if jr.NotifyURL != "" {
+		if err := s.checkNotifyURL(jr.NotifyURL); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	key := fmt.Sprintf("%d %s", h, strings.Join(symbols, ","))
+
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if j.key == key && j.theme == s.theme && j.Status != "failed" {
+			done := *j
+			s.mu.Unlock()
+			writeJob(w, http.StatusOK, done)
+			return
+		}
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	j := &job{
+		ID:        hex.EncodeToString(id),
+		Status:    "queued",
+		Elements:  symbols,
+		Height:    h,
+		Created:   time.Now().UTC(),
+		key:       key,
+		theme:     s.theme,
+		notifyURL: cmp.Or(jr.NotifyURL, s.opts.notifyURL),
+	}
+	select {
+	case s.queue <- j:
+		s.jobs[j.ID] = j
+	default:
+		j = nil
+	}
+	var queued job
+	if j != nil {
+		queued = *j
+	}
+	s.mu.Unlock()
+	if j == nil {
+		http.Error(w, "job queue is full, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Location", "/jobs/"+queued.ID)
+	writeJob(w, http.StatusAccepted, queued)
+}
+
+// checkNotifyURL refuses a job's notify_url unless it is https on one of
+// the -notify-hosts, so clients can't have the server POST to addresses
+// only it can reach.
+func (s *server) checkNotifyURL(raw string) error {
+	if len(s.notifyHosts) == 0 {
+		return errors.New("notify_url isn't accepted by this server; start it with -notify-hosts to allow it")
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("notify_url %q must be an https URL", raw)
+	}
+	if !slices.Contains(s.notifyHosts, strings.ToLower(u.Hostname())) {
+		return fmt.Errorf("notify_url host %q isn't one of -notify-hosts", u.Hostname())
+	}
+	return nil
+}
+
+// decodeBody reads a request's JSON body into v, or reports why it can't
+// and returns false.
+func (s *server) decodeBody(w http.ResponseWriter, req *http.Request, v any) bool {
+	if err := json.NewDecoder(req.Body).Decode(v); err != nil {
+		code := http.StatusBadRequest
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, "bad request body: "+err.Error(), code)
+		return false
+	}
+	return true
+}
+
+// writeJob writes a copy of a job's status, taken while holding s.mu, as
+// JSON.
+func writeJob(w http.ResponseWriter, code int, j job) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(j)
+}
+
+func (s *server) handleJob(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	j, ok := s.jobs[req.PathValue("id")]
+	var snapshot job
+	if ok {
+		snapshot = *j
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "no such job", http.StatusNotFound)
+		return
+	}
+	writeJob(w, http.StatusOK, snapshot)
+}
+
+func (s *server) handleJobResult(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	j, ok := s.jobs[req.PathValue("id")]
+	var zip []byte
+	if ok {
+		zip = j.zip
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "no such job", http.StatusNotFound)
+		return
+	}
+	if zip == nil {
+		http.Error(w, "job has no result yet", http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"cards-%s.zip\"", j.ID))
+	w.Write(zip)
+}
+
+func (s *server) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-s.queue:
+			s.run(ctx, j)
+		}
+	}
+}
+
+// run renders a job's cards into a zip held in memory.
+func (s *server) run(ctx context.Context, j *job) {
+	s.mu.Lock()
+	j.Status = "running"
+	j.theme = s.theme
+	s.mu.Unlock()
+
+	o := s.options(j.Height)
+	elements := s.elements
+	if len(j.Elements) > 0 {
+		elements = nil
+		for _, e := range s.elements {
+			if slices.Contains(j.Elements, e.Symbol) {
+				elements = append(elements, e)
+			}
+		}
+	}
+
+	var sum summary
+	var zip bytes.Buffer
+	r, err := newRenderer(o)
+	if err == nil {
+		st := newMemStore("job " + j.ID)
+		sum, err = generateTiles(ctx, r, st, elements, o, nil)
+		if err == nil && sum.Cancelled {
+			err = errors.New("interrupted")
+		}
+		if err == nil {
+			err = writeZip(&zip, st)
+		}
+	}
+
+	s.mu.Lock()
+	now := time.Now().UTC()
+	j.Finished = &now
+	j.Summary = &sum
+	if err != nil {
+		j.Status = "failed"
+		j.Error = err.Error()
+	} else {
+		j.Status = "done"
+		j.zip = zip.Bytes()
+		j.Result = "/jobs/" + j.ID + "/result"
+	}
+	notifyURL := j.notifyURL
+	s.mu.Unlock()
+	logger.Info("Job finished", "id", j.ID, "summary", sum)
+
+	if notifyURL != "" {
+		rep := newReport("job", o, sum, err)
+		rep.Job, rep.Result = j.ID, j.Result
+		if nerr := notify(notifyURL, rep); nerr != nil {
+			logger.Warn("Notify failed", "id", j.ID, "error", nerr)
+		}
+	}
+}
+
+// expire forgets finished jobs older than -job-ttl.
+func (s *server) expire(ctx context.Context) {
+	t := time.NewTicker(max(time.Minute, s.jobTTL/10))
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		s.mu.Lock()
+		for id, j := range s.jobs {
+			if j.Finished != nil && time.Since(*j.Finished) > s.jobTTL {
+				delete(s.jobs, id)
+			}
+		}
+		s.mu.Unlock()
+		if s.limiter != nil {
+			s.limiter.forget(time.Now())
+		}
+	}
+}
+
+// watchedFiles are the files the cards are drawn from that -watch checks.
+// The element data isn't one of them: it is loaded once.
+func watchedFiles(o *options) []string {
+	var paths []string
+	for _, p := range []string{o.ColoursPath, o.AliasesPath, o.GroupsPath, o.NotesPath, o.SpectraPath, o.RulesPath, o.FontPath, o.Illustrations} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if o.Patterns != "" && o.Patterns != "auto" {
+		paths = append(paths, o.Patterns)
+	}
+	return paths
+}
+
+// fileStamp identifies the version of a file, or of the files in a folder,
+// by modification time and size. Missing files have an empty stamp, so
+// creating one counts as a change.
+func fileStamp(path string) string {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	stamp := fmt.Sprint(fi.ModTime().UnixNano(), fi.Size())
+	if fi.IsDir() {
+		entries, _ := os.ReadDir(path)
+		for _, de := range entries {
+			if info, err := de.Info(); err == nil {
+				stamp += fmt.Sprint(" ", de.Name(), info.ModTime().UnixNano(), info.Size())
+			}
+		}
+	}
+	return stamp
+}
+
+// watchedPaths are the files -watch checks: those every card is drawn
+// from, and the colours files in -themes.
+func (s *server) watchedPaths() []string {
+	paths := watchedFiles(s.opts)
+	themes, _ := filepath.Glob(filepath.Join(s.themesDir, "*.json"))
+	for _, p := range themes {
+		if !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// changedFiles returns the watched files whose stamps differ from those in
+// stamps, updating it. A theme file that was removed counts as changed.
+func (s *server) changedFiles(stamps map[string]string) []string {
+	var changed []string
+	paths := s.watchedPaths()
+	for p := range stamps {
+		if !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	for _, p := range paths {
+		if stamp := fileStamp(p); stamp != stamps[p] {
+			changed = append(changed, p)
+			if stamp == "" {
+				delete(stamps, p)
+			} else {
+				stamps[p] = stamp
+			}
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// reload throws away what was drawn from the changed files, provided the
+// cards still render with them: the cached renderers, with their card
+// backgrounds and fonts, that read one of them, and if the files jobs are
+// drawn from changed, finished jobs as answers to new requests, though
+// their results can still be downloaded. Jobs waiting to run will use the
+// new files; a job already running finishes with the old ones.
+func (s *server) reload(changed []string) {
+	shared := slices.ContainsFunc(changed, func(p string) bool { return slices.Contains(watchedFiles(s.opts), p) })
+	if shared {
+		if _, err := newRenderer(s.options(s.opts.Height)); err != nil {
+			logger.Warn("Reload failed, keeping the old files", "changed", strings.Join(changed, ", "), "error", err)
+			return
+		}
+	}
+	var bad []string
+	for _, p := range changed {
+		if slices.Contains(watchedFiles(s.opts), p) || fileStamp(p) == "" {
+			continue
+		}
+		o := s.options(s.opts.Height)
+		o.ColoursPath = p
+		if _, err := newRenderer(o); err != nil {
+			logger.Warn("Reload failed, keeping the old theme", "changed", p, "error", err)
+			bad = append(bad, p)
+		}
+	}
+	changed = slices.DeleteFunc(changed, func(p string) bool { return slices.Contains(bad, p) })
+	if len(changed) == 0 {
+		return
+	}
+	s.renderMu.Lock()
+	for k, cr := range s.renderers {
+		if slices.ContainsFunc(changed, func(p string) bool { return slices.Contains(cr.files, p) }) {
+			delete(s.renderers, k)
+		}
+	}
+	s.renderMu.Unlock()
+	if shared {
+		s.mu.Lock()
+		s.theme++
+		s.mu.Unlock()
+	}
+	logger.Info("Reloaded", "changed", strings.Join(changed, ", "))
+}
+
+// watch checks the watched files every interval, and reloads the ones
+// that changed.
+func (s *server) watch(ctx context.Context, interval time.Duration) {
+	stamps := map[string]string{}
+	s.changedFiles(stamps)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if changed := s.changedFiles(stamps); len(changed) > 0 {
+			s.reload(changed)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"periodic-table-tiles/elements"
+)
+
+// TestServerReload checks that -watch only throws away the renderers drawn
+// from the files that changed.
+func TestServerReload(t *testing.T) {
+	dir := t.TempDir()
+	themes := filepath.Join(dir, "themes")
+	if err := os.Mkdir(themes, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, json string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(json), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	colours, dark, light := filepath.Join(dir, "colours.json"), filepath.Join(themes, "dark.json"), filepath.Join(themes, "light.json")
+	write(colours, `{"unknown": "#999999"}`)
+	write(dark, `{"unknown": "#222222"}`)
+	write(light, `{"unknown": "#eeeeee"}`)
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, []string{"-colours", colours, "-height", "30"}); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{opts: o, renderers: map[rendererKey]*cachedRenderer{}, themesDir: themes}
+	stamps := map[string]string{}
+	s.changedFiles(stamps)
+	cached := func(path string) *cachedRenderer {
+		t.Helper()
+		cr, err := s.renderer(path, 30)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cr
+	}
+	reload := func(want ...string) {
+		t.Helper()
+		changed := s.changedFiles(stamps)
+		if !slices.Equal(changed, want) {
+			t.Fatalf("changed %v, want %v", changed, want)
+		}
+		s.reload(changed)
+	}
+	kept := func(path string, cr *cachedRenderer, want bool) {
+		t.Helper()
+		if got := s.renderers[rendererKey{path, 30}] == cr; got != want {
+			t.Errorf("%s: renderer kept %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+
+	def, d, l := cached(colours), cached(dark), cached(light)
+	write(dark, `{"unknown": "#333333", "metalloid": "#444444"}`)
+	reload(dark)
+	kept(dark, d, false)
+	kept(light, l, true)
+	kept(colours, def, true)
+	if s.theme != 0 {
+		t.Errorf("a theme changing marked the jobs stale")
+	}
+
+	// A broken theme keeps its old renderer.
+	write(light, `{"unknown": `)
+	reload(light)
+	kept(light, l, true)
+
+	d = cached(dark)
+	write(colours, `{"unknown": "#888888", "metalloid": "#444444"}`)
+	reload(colours)
+	kept(colours, def, false)
+	kept(dark, d, true)
+	if s.theme != 1 {
+		t.Errorf("-colours changing didn't mark the jobs stale")
+	}
+
+	if err := os.Remove(dark); err != nil {
+		t.Fatal(err)
+	}
+	reload(dark)
+	kept(dark, d, false)
+}
+
+func TestCheckNotifyURL(t *testing.T) {
+	s := &server{notifyHosts: []string{"hooks.example.com"}}
+	for _, tt := range []struct {
+		url string
+		ok  bool
+	}{
+		{"https://hooks.example.com/job", true},
+		{"https://HOOKS.example.com:8443/job", true},
+		{"http://hooks.example.com/job", false},
+		{"https://example.com/job", false},
+		{"https://169.254.169.254/latest/meta-data", false},
+		{"https://hooks.example.com.evil.test/job", false},
+		{"file:///etc/passwd", false},
+		{"hooks.example.com/job", false},
+	} {
+		if err := s.checkNotifyURL(tt.url); (err == nil) != tt.ok {
+			t.Errorf("%s: error %v, want ok %v", tt.url, err, tt.ok)
+		}
+	}
+	if err := (&server{}).checkNotifyURL("https://hooks.example.com/job"); err == nil {
+		t.Error("accepted a notify_url without -notify-hosts")
+	}
+}
+
+func TestNewJobNotifyURL(t *testing.T) {
+	s := &server{opts: &options{}, jobs: map[string]*job{}, queue: make(chan *job, 1)}
+	s.opts.Height = 60
+	req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"notify_url": "http://localhost:6379/"}`))
+	w := httptest.NewRecorder()
+	s.handleNewJob(w, req)
+	if w.Code != http.StatusBadRequest || len(s.queue) != 0 {
+		t.Errorf("status %d with %d jobs queued, want 400 and none", w.Code, len(s.queue))
+	}
+}
+
+func TestRenderPixelBudget(t *testing.T) {
+	es, _, err := elements.Embedded{}.Elements(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	colours := filepath.Join(t.TempDir(), "colours.json")
+	if err := os.WriteFile(colours, []byte(`{"unknown": "#999999"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name string
+		args []string
+		body string
+		code int
+	}{
+		{"small", nil, `{"elements": ["He"]}`, http.StatusOK},
+		{"big cards", nil, `{"heights": [8000]}`, http.StatusRequestEntityTooLarge},
+		{"supersampled", []string{"-supersample", "4"}, `{"elements": ["H", "He", "Li", "Be", "B", "C", "N", "O"], "heights": [3000]}`, http.StatusRequestEntityTooLarge},
+		{"too many cards", nil, `{"heights": [20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37]}`, http.StatusBadRequest},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+			o := addFlags(fs)
+			if err := parseFlags(fs, o, append([]string{"-colours", colours, "-height", "30"}, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+			s := &server{opts: o, elements: es, renderers: map[rendererKey]*cachedRenderer{}, themesDir: t.TempDir()}
+			w := httptest.NewRecorder()
+			s.handleRender(w, httptest.NewRequest(http.MethodPost, "/render", strings.NewReader(tt.body)))
+			if w.Code != tt.code {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.code, w.Body)
+			}
+			if w.Code != http.StatusOK && len(s.renderers) > 0 {
+				t.Errorf("made %d renderers for a request it turned down", len(s.renderers))
+			}
+		})
+	}
+}
+
+// TestRendererCacheLimit checks that the renderers cached between requests
+// are capped, throwing away the one used least recently.
+func TestRendererCacheLimit(t *testing.T) {
+	colours := filepath.Join(t.TempDir(), "colours.json")
+	if err := os.WriteFile(colours, []byte(`{"unknown": "#999999"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, []string{"-colours", colours}); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{opts: o, renderers: map[rendererKey]*cachedRenderer{}}
+	get := func(height int) *cachedRenderer {
+		t.Helper()
+		cr, err := s.renderer(colours, height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cr
+	}
+	first := get(minServeHeight)
+	for h := minServeHeight + 1; h < minServeHeight+maxCachedRenderers; h++ {
+		get(h)
+	}
+	if get(minServeHeight) != first {
+		t.Fatal("renderer not cached")
+	}
+	// The cache is full, so this throws away the second, as the first has
+	// just been used.
+	get(minServeHeight + maxCachedRenderers)
+	if len(s.renderers) != maxCachedRenderers {
+		t.Errorf("%d renderers cached, want %d", len(s.renderers), maxCachedRenderers)
+	}
+	if _, ok := s.renderers[rendererKey{colours, minServeHeight + 1}]; ok {
+		t.Error("kept the renderer used least recently")
+	}
+	if get(minServeHeight) != first {
+		t.Error("threw away a renderer used recently")
+	}
+}
+
+func TestTilePixelBudget(t *testing.T) {
+	colours := filepath.Join(t.TempDir(), "colours.json")
+	if err := os.WriteFile(colours, []byte(`{"unknown": "#999999"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, []string{"-colours", colours, "-height", "30", "-supersample", "4"}); err != nil {
+		t.Fatal(err)
+	}
+	s := &server{opts: o, elements: []Element{testHelium}, renderers: map[rendererKey]*cachedRenderer{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tiles/{element}", s.handleTile)
+	for _, tt := range []struct {
+		url  string
+		code int
+	}{
+		{"/tiles/He", http.StatusOK},
+		{"/tiles/He?height=8000", http.StatusRequestEntityTooLarge},
+	} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: status %d, want %d: %s", tt.url, w.Code, tt.code, w.Body)
+		}
+	}
+	if len(s.renderers) != 1 {
+		t.Errorf("%d renderers cached, want 1 for the card drawn", len(s.renderers))
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxRenderCards is the most cards one POST /render may ask for, counting
+// every element at every height in every theme. maxRenderPixels limits
+// their size too.
+const maxRenderCards = 2000
+
+// renderRequest is the body of POST /render: every element at every height
+// in every theme, streamed back as a zip. No elements means all of them,
+// no heights the server's -height and no themes the server's -colours.
+type renderRequest struct {
+	Elements []string `json:"elements"`
+	Heights  []int    `json:"heights"`
+	Themes   []string `json:"themes"`
+}
+
+// handleRender renders a set of cards while the client waits, writing each
+// into the zip as it is drawn so the download starts straight away. Its
+// entries are named <theme>/<height>/<card>. Everything is checked before
+// the first card, since once the zip has started an error can only cut it
+// short.
+func (s *server) handleRender(w http.ResponseWriter, req *http.Request) {
+	var rr renderRequest
+	if !s.decodeBody(w, req, &rr) {
+		return
+	}
+	symbols, err := s.findSymbols(rr.Elements)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	elements := s.elements
+	if len(symbols) > 0 {
+		elements = slices.DeleteFunc(slices.Clone(s.elements), func(e Element) bool { return !slices.Contains(symbols, e.Symbol) })
+	}
+	heights := rr.Heights
+	if len(heights) == 0 {
+		heights = []int{0}
+	}
+	for i, h := range heights {
+		if heights[i], err = s.heightParam(h); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	slices.Sort(heights)
+	heights = slices.Compact(heights)
+
+	found, err := findThemes(s.opts.ColoursPath, s.themesDir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	names := slices.Compact(slices.Sorted(slices.Values(rr.Themes)))
+	if len(names) == 0 {
+		names = []string{found[0].name}
+	}
+	var themes []theme
+	for _, name := range names {
+		i := slices.IndexFunc(found, func(t theme) bool { return t.name == name })
+		if i < 0 || strings.ContainsAny(name, `/\`) {
+			var known []string
+			for _, t := range found {
+				known = append(known, t.name)
+			}
+			http.Error(w, fmt.Sprintf("unknown theme %q (want one of %s)", name, strings.Join(known, ", ")), http.StatusBadRequest)
+			return
+		}
+		themes = append(themes, found[i])
+	}
+	if n := len(elements) * len(heights) * len(themes); n > maxRenderCards {
+		http.Error(w, fmt.Sprintf("%d cards asked for, more than the %d one request may render; use /jobs or split it up", n, maxRenderCards), http.StatusBadRequest)
+		return
+	}
+	var pixels int64
+	for _, h := range heights {
+		pixels += s.cardPixels(h) * int64(len(elements)*len(themes))
+	}
+	if tooManyPixels(w, pixels) {
+		return
+	}
+
+	// The renderers are cached by theme and height, for -watch to throw
+	// away when their files change.
+	type set struct {
+		dir string
+		r   *cachedRenderer
+	}
+	var sets []set
+	for _, t := range themes {
+		for _, h := range heights {
+			colours := t.path
+			if t.path == "" {
+				colours = filepath.Join(s.themesDir, "generated.json") // doesn't exist, so a palette is generated
+			}
+			r, err := s.renderer(colours, h)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("theme %s: %s", t.name, err), http.StatusInternalServerError)
+				return
+			}
+			sets = append(sets, set{path.Join(t.name, fmt.Sprint(h)), r})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="cards.zip"`)
+	zw := zip.NewWriter(w)
+	began := time.Now()
+	for _, set := range sets {
+		for _, e := range elements {
+			if err := req.Context().Err(); err != nil {
+				logger.Warn("Render cancelled", "error", err)
+				return
+			}
+			// PNGs are compressed already.
+			fw, err := zw.CreateHeader(&zip.FileHeader{Name: path.Join(set.dir, tileFilename(e)), Method: zip.Store, Modified: time.Now()})
+			if err == nil {
+				err = encodePNG(fw, set.r.tile(e), s.opts)
+			}
+			if err != nil {
+				// The status has gone; leaving the zip without its end
+				// tells the client it is incomplete.
+				logger.Error("Render failed", "error", err)
+				return
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		logger.Error("Render failed", "error", err)
+		return
+	}
+	logger.Info("Rendered", "cards", len(sets)*len(elements), "elapsed", time.Since(began).Round(time.Millisecond))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// loadAPIKeys reads an -api-keys file: one key a line, with blank lines
+// and # comments ignored.
+func loadAPIKeys(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var keys []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if key := strings.TrimSpace(line); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no keys", path)
+	}
+	return keys, nil
+}
+
+// requestKey returns the API key a request was made with, from an
+// "Authorization: Bearer" or "X-API-Key" header, or "".
+func requestKey(req *http.Request) string {
+	if key, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(key)
+	}
+	return req.Header.Get("X-API-Key")
+}
+
+// validKey reports whether key is one of keys, taking as long whichever it
+// matches so the keys can't be guessed by timing.
+func validKey(keys []string, key string) bool {
+	ok := 0
+	for _, k := range keys {
+		ok |= subtle.ConstantTimeCompare([]byte(k), []byte(key))
+	}
+	return key != "" && ok == 1
+}
+
+// rateLimiter lets each client make perMinute requests a minute, in bursts
+// of up to as many, from a bucket of tokens refilled continuously.
+type rateLimiter struct {
+	perMinute float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	return &rateLimiter{perMinute: float64(perMinute), buckets: map[string]*tokenBucket{}}
+}
+
+// allow takes a token for the client if it has one, or else returns how
+// long until it will.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: l.perMinute, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.perMinute, b.tokens+now.Sub(b.last).Minutes()*l.perMinute)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.perMinute * float64(time.Minute))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// forget drops the buckets of clients that have been quiet long enough
+// for theirs to fill up again, which are as good as new.
+func (l *rateLimiter) forget(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for client, b := range l.buckets {
+		if now.Sub(b.last) > time.Minute {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// clientID is who a request counts against for -rate-limit: its API key
+// if it has a valid one, or else the address it came from.
+func clientID(req *http.Request, validKey bool) string {
+	if validKey {
+		return "key " + requestKey(req)
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return host
+}
+
+// guard wraps the server's routes with the -rate-limit, the -api-keys
+// check and the -max-body limit on request bodies. Requests without a
+// valid key count against their address, so keys can't be guessed faster
+// than the limit allows. The OpenAPI document needs no key, so clients
+// can be set up before they have one.
+func (s *server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		keyed := s.apiKeys != nil && validKey(s.apiKeys, requestKey(req))
+		authorised := s.apiKeys == nil || keyed || req.URL.Path == openAPIPath
+		if s.limiter != nil {
+			if ok, wait := s.limiter.allow(clientID(req, keyed), time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, "too many requests, try again later", http.StatusTooManyRequests)
+				return
+			}
+		}
+		if !authorised {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="periodic-table-tiles"`)
+			http.Error(w, "missing or unknown API key", http.StatusUnauthorized)
+			return
+		}
+		if req.ContentLength > s.maxBody {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		req.Body = http.MaxBytesReader(w, req.Body, s.maxBody)
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"periodic-table-tiles/render"
+)
+
+// parseNISTLines reads the CSV or tab separated output of the NIST Atomic
+// Spectra Database line form. Wavelengths outside the visible range and
+// lines without an intensity are dropped.
+func parseNISTLines(r io.Reader) ([]render.SpectralLine, error) {
+	bs, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(strings.NewReader(string(bs)))
+	if first, _, _ := strings.Cut(string(bs), "\n"); strings.Count(first, "\t") > strings.Count(first, ",") {
+		cr.Comma = '\t'
+	}
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no rows")
+	}
+
+	// Observed wavelengths in air are best, then other observed ones, then
+	// those worked out by the Ritz principle.
+	wlCol, wlRank, intCol := -1, 0, -1
+	for i, h := range rows[0] {
+		h = strings.ToLower(nistField(h))
+		rank := 0
+		switch {
+		case strings.HasPrefix(h, "obs_wl_air"):
+			rank = 3
+		case strings.HasPrefix(h, "obs_wl"):
+			rank = 2
+		case strings.HasPrefix(h, "ritz_wl"):
+			rank = 1
+		case h == "intens":
+			intCol = i
+		}
+		if rank > wlRank {
+			wlCol, wlRank = i, rank
+		}
+	}
+	if wlCol < 0 || intCol < 0 {
+		return nil, fmt.Errorf("missing wavelength or intens column in header %q", rows[0])
+	}
+
+	var lines []render.SpectralLine
+	peak := 0.0
+	for _, row := range rows[1:] {
+		if len(row) <= max(wlCol, intCol) {
+			continue
+		}
+		wl, err := strconv.ParseFloat(nistField(row[wlCol]), 64)
+		if err != nil || wl < render.MinVisible || wl > render.MaxVisible {
+			continue
+		}
+		in := leadingNumber(nistField(row[intCol]))
+		if in <= 0 {
+			continue
+		}
+		lines = append(lines, render.SpectralLine{Wavelength: wl, Intensity: in})
+		peak = math.Max(peak, in)
+	}
+	for i := range lines {
+		lines[i].Intensity = math.Round(lines[i].Intensity/peak*1000) / 1000
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Wavelength < lines[j].Wavelength })
+	return lines, nil
+}
+
+// nistField strips the ="..." wrapping ASD uses to stop spreadsheets
+// reformatting numbers.
+func nistField(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "=")
+	return strings.Trim(s, "\" ")
+}
+
+// leadingNumber parses intensities such as "500", "90bl" or "(30)".
+func leadingNumber(s string) float64 {
+	s = strings.TrimLeft(s, "(*")
+	end := 0
+	for end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] == '.') {
+		end++
+	}
+	v, _ := strconv.ParseFloat(s[:end], 64)
+	return v
+}
+
+func nistURL(symbol string) string {
+	q := url.Values{}
+	q.Set("spectra", symbol+" I")
+	q.Set("low_w", fmt.Sprint(render.MinVisible))
+	q.Set("upp_w", fmt.Sprint(render.MaxVisible))
+	q.Set("unit", "1")   // nm
+	q.Set("format", "2") // CSV
+	q.Set("line_out", "0")
+	q.Set("show_obs_wl", "1")
+	q.Set("intens_out", "on")
+	q.Set("submit", "Retrieve Data")
+	return "https://physics.nist.gov/cgi-bin/ASD/lines1.pl?" + q.Encode()
+}
+
+// fetchNIST returns the ASD line list for symbol, downloading it only when
+// the cached copy is missing or older than maxAge. Only a download that
+// parses as a line list is cached, not ASD's error pages.
+func fetchNIST(ctx context.Context, symbol, cacheDir string, maxAge time.Duration) ([]byte, error) {
+	path := filepath.Join(cacheDir, symbol+".csv")
+	if st, err := os.Stat(path); err == nil && time.Since(st.ModTime()) < maxAge {
+		return os.ReadFile(path)
+	}
+	client := http.Client{Timeout: 30 * time.Second}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, nistURL(symbol), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("NIST ASD: %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parseNISTLines(bytes.NewReader(body)); err != nil {
+		return nil, fmt.Errorf("NIST ASD: %w", err)
+	}
+	os.MkdirAll(cacheDir, 0755)
+	if err := os.WriteFile(path, body, 0644); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "periodic-table-tiles")
+}
+
+// runSpectra implements "spectra import" and "spectra fetch", which merge
+// NIST line lists into the spectra file -spectra reads, starting from a
+// copy of the bundled data if it doesn't exist yet.
+func runSpectra(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: spectra import|fetch [flags] ...")
+	}
+	sub := args[0]
+	fs := flag.NewFlagSet("spectra "+sub, flag.ExitOnError)
+	out := fs.String("out", "spectra.json", "spectra file to merge the lines into, for -spectra")
+	maxLines := fs.Int("max-lines", 12, "keep only the strongest N lines per element (0 for all)")
+	var symbol *string
+	var cacheDir *string
+	var maxAge *time.Duration
+	switch sub {
+	case "import":
+		symbol = fs.String("element", "", "element symbol the line list belongs to")
+	case "fetch":
+		cacheDir = fs.String("cache", filepath.Join(defaultCacheDir(), "nist"), "directory for downloaded line lists")
+		maxAge = fs.Duration("max-age", 30*24*time.Hour, "re-download cached line lists older than this")
+	default:
+		return fmt.Errorf("unknown spectra command %q", sub)
+	}
+	fs.Parse(args[1:])
+
+	spectra, err := render.LoadSpectra(*out)
+	if os.IsNotExist(err) {
+		spectra, err = render.LoadSpectra("")
+	}
+	if err != nil {
+		return err
+	}
+
+	add := func(sym string, r io.Reader) error {
+		lines, err := parseNISTLines(r)
+		if err != nil {
+			return fmt.Errorf("%s: %w", sym, err)
+		}
+		if *maxLines > 0 && len(lines) > *maxLines {
+			sort.Slice(lines, func(i, j int) bool { return lines[i].Intensity > lines[j].Intensity })
+			lines = lines[:*maxLines]
+			sort.Slice(lines, func(i, j int) bool { return lines[i].Wavelength < lines[j].Wavelength })
+		}
+		spectra[sym] = lines
+		logger.Info("Spectrum", "element", sym, "lines", len(lines))
+		return nil
+	}
+
+	switch sub {
+	case "import":
+		if *symbol == "" || fs.NArg() != 1 {
+			return fmt.Errorf("usage: spectra import -element Fe lines.csv")
+		}
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		err = add(*symbol, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	case "fetch":
+		if fs.NArg() == 0 {
+			return fmt.Errorf("usage: spectra fetch Fe Na ...")
+		}
+		for _, sym := range fs.Args() {
+			body, err := fetchNIST(ctx, sym, *cacheDir, *maxAge)
+			if err != nil {
+				return fmt.Errorf("%s: %w", sym, err)
+			}
+			if err := add(sym, bytes.NewReader(body)); err != nil {
+				return err
+			}
+		}
+	}
+	return spectra.Save(*out)
+}
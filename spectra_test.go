@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"periodic-table-tiles/render"
+)
+
+func TestParseNISTLines(t *testing.T) {
+	for _, tt := range []struct {
+		name, src string
+		want      []render.SpectralLine
+	}{
+		{"csv", `obs_wl_air(nm),unc_obs_wl,intens,Aki(s^-1),Type
+="656.2711",="0.0003","500000",="4.4101e+07",
+="486.128",="0.0003","180000",="8.4193e+06",
+="434.0462",="0.0003","90000",="2.5304e+06",
+="121.567",="0.0003","1000",="6.265e+08",
+`, []render.SpectralLine{{Wavelength: 434.0462, Intensity: 0.18}, {Wavelength: 486.128, Intensity: 0.36}, {Wavelength: 656.2711, Intensity: 1}}},
+		{"tab separated", "obs_wl_air(nm)\tintens\n589.592\t80000\n588.995\t160000\n",
+			[]render.SpectralLine{{Wavelength: 588.995, Intensity: 1}, {Wavelength: 589.592, Intensity: 0.5}}},
+		{"intensity marks", `obs_wl_air(nm),intens
+500,"90bl"
+510,"(30)"
+520,"*60"
+530,""
+540,"blend"
+`, []render.SpectralLine{{Wavelength: 500, Intensity: 1}, {Wavelength: 510, Intensity: 0.333}, {Wavelength: 520, Intensity: 0.667}}},
+		// Air wavelengths are preferred to vacuum ones, wherever the
+		// columns are; Ritz ones are only used without observed ones.
+		{"air over vacuum", "obs_wl_vac(nm),ritz_wl_air(nm),obs_wl_air(nm),intens\n600.2,600.15,600.1,10\n",
+			[]render.SpectralLine{{Wavelength: 600.1, Intensity: 1}}},
+		{"vacuum over ritz", "ritz_wl_air(nm),obs_wl_vac(nm),intens\n600.15,600.2,10\n",
+			[]render.SpectralLine{{Wavelength: 600.2, Intensity: 1}}},
+		{"ritz", "RITZ_WL_AIR(NM),INTENS\n600.15,10\n", []render.SpectralLine{{Wavelength: 600.15, Intensity: 1}}},
+		{"short rows", "obs_wl_air(nm),intens\n600\n,\n610,5\n", []render.SpectralLine{{Wavelength: 610, Intensity: 1}}},
+		{"nothing visible", "obs_wl_air(nm),intens\n121.567,1000\n", nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNISTLines(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNISTLinesErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		{"empty", "", "no rows"},
+		{"no intensity", "obs_wl_air(nm),unc_obs_wl\n656.2711,0.0003\n", "missing wavelength or intens column"},
+		{"no wavelength", "intens\n500\n", "missing wavelength or intens column"},
+		{"error page", "<html><body>Error: unrecognized spectrum</body></html>\n", "missing wavelength or intens column"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseNISTLines(strings.NewReader(tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
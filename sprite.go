@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+
+	"periodic-table-tiles/render"
+)
+
+// spriteID is the id of an element's card in the sprite, the same on
+// every run so pages can link to it.
+func spriteID(e Element) string { return fmt.Sprintf("el-%d", e.Number) }
+
+// runSprite writes every card into one SVG sprite of <symbol>s, so a web
+// page can show any of them with <use href="elements.svg#el-26"/> while
+// only downloading one file.
+func runSprite(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sprite", flag.ExitOnError)
+	o := addFlags(fs)
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	var outliner *render.SVGOutliner
+	if o.textToPath {
+		if outliner, err = render.NewTextOutliner(o.FontPath); err != nil {
+			return err
+		}
+	}
+	const fname = "elements.svg"
+	return saveFile(o, fname, func(st store) error {
+		return st.put(fname, func(w io.Writer) error {
+			return render.WriteOutlined(w, outliner, func(w io.Writer) error { return writeSpriteSVG(w, r, elements) })
+		})
+	})
+}
+
+// writeSpriteSVG writes the sprite. A symbol's content takes its styles
+// from the <use> showing it rather than from the sprite, so each one sets
+// its own font.
+func writeSpriteSVG(w io.Writer, r *render.Renderer, elements []Element) error {
+	l := r.Layout()
+	font := html.EscapeString(render.FontFamily(r.Options.FontPath))
+	var b bytes.Buffer
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg">` + "\n")
+	for _, e := range elements {
+		fmt.Fprintf(&b, `<symbol id="%s" viewBox="0 0 %d %d"><title>%s</title><g font-family="%s, sans-serif" font-weight="bold">`,
+			spriteID(e), r.TileW, r.TileH, html.EscapeString(e.Name), font)
+		render.WriteCardSVG(&b, r, l, e)
+		b.WriteString("</g></symbol>\n")
+	}
+	b.WriteString("</svg>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// A minimal writer for SQLite database files, enough to save a few tables
+// of rows in one go without needing cgo or a driver. It writes the file
+// format described at https://www.sqlite.org/fileformat.html directly:
+// every table is a rowid B-tree built bottom up, with no indexes, so
+// columns can't be UNIQUE and the primary key has to be the rowid. The
+// reader in package elements goes the other way, for -data.
+
+const (
+	sqlitePageSize = 4096
+	sqliteVersion  = 3045000 // the library version the file claims to be written by
+)
+
+// sqliteTable is a table to write. Its first column must be declared
+// INTEGER PRIMARY KEY, and the first value of each row is used as the
+// rowid. Values may be nil, int, int64, float64 or string.
+type sqliteTable struct {
+	name string
+	sql  string // the CREATE TABLE statement
+	rows [][]any
+}
+
+// sqliteDB collects the pages of a database as they are built. Page n of
+// the file is pages[n-1].
+type sqliteDB struct {
+	pages [][]byte
+}
+
+func (db *sqliteDB) newPage() (int, []byte) {
+	p := make([]byte, sqlitePageSize)
+	db.pages = append(db.pages, p)
+	return len(db.pages), p
+}
+
+// writeSQLite writes a database holding tables to w.
+func writeSQLite(w io.Writer, tables []sqliteTable) error {
+	db := &sqliteDB{}
+	db.newPage() // the schema table, filled in last
+	var schema []sqliteCell
+	for i, t := range tables {
+		var cells []sqliteCell
+		for _, row := range t.rows {
+			id, ok := row[0].(int)
+			if !ok {
+				return fmt.Errorf("%s: rowid %v isn't an int", t.name, row[0])
+			}
+			// The rowid column is stored as NULL; SQLite reads it from the
+			// key.
+			rec, err := sqliteRecord(append([]any{nil}, row[1:]...))
+			if err != nil {
+				return fmt.Errorf("%s: %w", t.name, err)
+			}
+			cells = append(cells, db.leafCell(int64(id), rec))
+		}
+		root := db.buildTree(cells)
+		rec, err := sqliteRecord([]any{"table", t.name, t.name, root, t.sql})
+		if err != nil {
+			return err
+		}
+		schema = append(schema, db.leafCell(int64(i+1), rec))
+	}
+
+	page1 := db.pages[0]
+	if !writeLeaf(page1, 100, schema) {
+		return fmt.Errorf("the schema doesn't fit on the first page")
+	}
+	copy(page1, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(page1[16:], sqlitePageSize)
+	page1[18], page1[19] = 1, 1 // legacy journal mode
+	page1[21], page1[22], page1[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(page1[24:], 1) // file change counter
+	binary.BigEndian.PutUint32(page1[28:], uint32(len(db.pages)))
+	binary.BigEndian.PutUint32(page1[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(page1[44:], 4) // schema format
+	binary.BigEndian.PutUint32(page1[56:], 1) // UTF-8
+	binary.BigEndian.PutUint32(page1[92:], 1) // valid for change counter 1
+	binary.BigEndian.PutUint32(page1[96:], sqliteVersion)
+
+	for _, p := range db.pages {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqliteCell is a cell of a table B-tree leaf page and its rowid.
+type sqliteCell struct {
+	rowid int64
+	data  []byte
+}
+
+// leafCell makes the leaf cell for a row, moving any payload too big for
+// a page onto a chain of overflow pages.
+func (db *sqliteDB) leafCell(rowid int64, rec []byte) sqliteCell {
+	var c []byte
+	c = appendVarint(c, uint64(len(rec)))
+	c = appendVarint(c, uint64(rowid))
+	u := sqlitePageSize
+	maxLocal := u - 35
+	if len(rec) <= maxLocal {
+		return sqliteCell{rowid, append(c, rec...)}
+	}
+	minLocal := (u-12)*32/255 - 23
+	local := minLocal + (len(rec)-minLocal)%(u-4)
+	if local > maxLocal {
+		local = minLocal
+	}
+	c = append(c, rec[:local]...)
+	rest := rec[local:]
+	n, p := db.newPage()
+	c = binary.BigEndian.AppendUint32(c, uint32(n))
+	for {
+		// Each overflow page starts with the number of the next.
+		rest = rest[copy(p[4:], rest):]
+		if len(rest) == 0 {
+			return sqliteCell{rowid, c}
+		}
+		var next []byte
+		n, next = db.newPage()
+		binary.BigEndian.PutUint32(p, uint32(n))
+		p = next
+	}
+}
+
+// writeLeaf lays cells out on a table leaf page whose header starts at
+// off, reporting whether they fit.
+func writeLeaf(p []byte, off int, cells []sqliteCell) bool {
+	data := make([][]byte, len(cells))
+	for i, c := range cells {
+		data[i] = c.data
+	}
+	return writeBTreePage(p, off, 0x0d, data, 0)
+}
+
+// writeBTreePage writes a B-tree page of the given type with its header at
+// off: the cell pointers after the header and the cells packed against the
+// end of the page. Interior pages have a right-most child as well.
+func writeBTreePage(p []byte, off int, kind byte, cells [][]byte, right int) bool {
+	hdr := 8
+	if kind == 0x05 {
+		hdr = 12
+	}
+	end := len(p)
+	for _, c := range cells {
+		end -= len(c)
+	}
+	if end < off+hdr+2*len(cells) {
+		return false
+	}
+	p[off] = kind
+	binary.BigEndian.PutUint16(p[off+3:], uint16(len(cells)))
+	binary.BigEndian.PutUint16(p[off+5:], uint16(end%65536))
+	if kind == 0x05 {
+		binary.BigEndian.PutUint32(p[off+8:], uint32(right))
+	}
+	at := len(p)
+	for i, c := range cells {
+		at -= len(c)
+		copy(p[at:], c)
+		binary.BigEndian.PutUint16(p[off+hdr+2*i:], uint16(at))
+	}
+	return true
+}
+
+// buildTree packs cells, in rowid order, into leaf pages, and adds interior
+// pages over them until one page is left, returning its number.
+func (db *sqliteDB) buildTree(cells []sqliteCell) int {
+	type child struct {
+		page   int
+		maxKey int64
+	}
+	var level []child
+	for len(cells) > 0 || len(level) == 0 {
+		n := fitCells(len(cells), func(i int) int { return len(cells[i].data) }, 8)
+		pg, p := db.newPage()
+		writeLeaf(p, 0, cells[:n])
+		var maxKey int64
+		if n > 0 {
+			maxKey = cells[n-1].rowid
+		}
+		level = append(level, child{pg, maxKey})
+		cells = cells[n:]
+	}
+	for len(level) > 1 {
+		var up []child
+		for len(level) > 0 {
+			// Each page takes cells for all but its last child, which is
+			// the right-most pointer.
+			var cs [][]byte
+			for _, c := range level {
+				cs = append(cs, appendVarint(binary.BigEndian.AppendUint32(nil, uint32(c.page)), uint64(c.maxKey)))
+			}
+			n := fitCells(len(cs)-1, func(i int) int { return len(cs[i]) }, 12) + 1
+			pg, p := db.newPage()
+			writeBTreePage(p, 0, 0x05, cs[:n-1], level[n-1].page)
+			up = append(up, child{pg, level[n-1].maxKey})
+			level = level[n:]
+		}
+		level = up
+	}
+	return level[0].page
+}
+
+// fitCells returns how many of the next cells fit on a page with a header
+// of hdr bytes, and at least one so a page is never empty.
+func fitCells(n int, size func(i int) int, hdr int) int {
+	used := hdr
+	for i := range n {
+		used += size(i) + 2
+		if used > sqlitePageSize {
+			return max(1, i)
+		}
+	}
+	return n
+}
+
+// sqliteRecord encodes a row in the record format.
+func sqliteRecord(values []any) ([]byte, error) {
+	var types, body []byte
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			types = appendVarint(types, 0)
+		case int:
+			types = appendVarint(types, 6)
+			body = binary.BigEndian.AppendUint64(body, uint64(v))
+		case int64:
+			types = appendVarint(types, 6)
+			body = binary.BigEndian.AppendUint64(body, uint64(v))
+		case float64:
+			types = appendVarint(types, 7)
+			body = binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+		case string:
+			types = appendVarint(types, uint64(len(v))*2+13)
+			body = append(body, v...)
+		default:
+			return nil, fmt.Errorf("can't store %T", v)
+		}
+	}
+	// The header's size includes the varint giving it.
+	n := len(types) + 1
+	for len(appendVarint(nil, uint64(n)))+len(types) != n {
+		n++
+	}
+	var b bytes.Buffer
+	b.Write(appendVarint(nil, uint64(n)))
+	b.Write(types)
+	b.Write(body)
+	return b.Bytes(), nil
+}
+
+// appendVarint appends SQLite's big-endian variable-length integer: seven
+// bits a byte with the top bit set on all but the last, and all eight bits
+// of a ninth byte.
+func appendVarint(b []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		var buf [9]byte
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	var buf [8]byte
+	i := len(buf) - 1
+	buf[i] = byte(v & 0x7f)
+	for v >>= 7; v > 0; v >>= 7 {
+		i--
+		buf[i] = byte(v&0x7f) | 0x80
+	}
+	return append(b, buf[i:]...)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"periodic-table-tiles/elements"
+	"periodic-table-tiles/render"
+)
+
+// writeTestDB writes tables to a database file in a temporary directory.
+func writeTestDB(t *testing.T, tables []sqliteTable) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := writeSQLite(&b, tables); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "periodic.db")
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	embedded, _, err := elements.Embedded{}.Elements(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hydrogen := Element{
+		Number: 1, Symbol: "H", Name: "Hydrogen", Mass: 1.008, Type: "reactive nonmetal", XPos: 1, YPos: 1,
+		Melt: 13.99, Boil: 20.271, Valence: 1, Summary: "The lightest element.", Phase: "Gas",
+		Density: 0.08988, Configuration: "1s1", Electronegativity: 2.2,
+	}
+	if hydrogen.Weight, err = elements.ParseAtomicWeight("[1.00784, 1.00811]"); err != nil {
+		t.Fatal(err)
+	}
+	// A summary longer than a page goes on overflow pages.
+	long := hydrogen
+	long.Summary = strings.Repeat("Ünïcödé and a long summary. ", 1000)
+	// Summaries nearly a page long put each element on a leaf of its own,
+	// under an interior page.
+	var many []Element
+	for _, e := range embedded {
+		e.Summary = fmt.Sprintf("%-3000s", e.Name)
+		many = append(many, e)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		elements []Element
+	}{
+		{"embedded", embedded},
+		{"every field", []Element{hydrogen}},
+		{"overflow", []Element{long}},
+		{"many pages", many},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestDB(t, exportTables(tt.elements, render.Colours{"reactive nonmetal": "#a0ffa0"}))
+			got, _, err := elements.SQLite(path).Elements(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.elements) {
+				t.Fatalf("read back %d elements, want %d", len(got), len(tt.elements))
+			}
+			for i, want := range tt.elements {
+				if !reflect.DeepEqual(got[i], want) {
+					t.Fatalf("element %d read back as\n%+v\nwant\n%+v", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestSQLiteIntegrity has SQLite itself check the files, if sqlite3 is
+// installed, including a table big enough for interior pages over
+// interior pages, which the elements never need.
+func TestSQLiteIntegrity(t *testing.T) {
+	sqlite3, err := exec.LookPath("sqlite3")
+	if err != nil {
+		t.Skip("no sqlite3 to check the files with")
+	}
+	embedded, _, err := elements.Embedded{}.Elements(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := sqliteTable{name: "big", sql: "CREATE TABLE big (id INTEGER PRIMARY KEY, name TEXT, x REAL, n INTEGER)"}
+	for i := 1; i <= 200000; i++ {
+		big.rows = append(big.rows, []any{i, fmt.Sprint("row ", i), float64(i) / 8, int64(-i)})
+	}
+	for _, tt := range []struct {
+		name, query, want string
+		tables            []sqliteTable
+	}{
+		{"export", "SELECT count(*), sum(number) FROM elements", "118|7021", exportTables(embedded, nil)},
+		{"big", "SELECT count(*), sum(n), max(name), sum(x) FROM big", "200000|-20000100000|row 99999|2500012500.0", []sqliteTable{big}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestDB(t, tt.tables)
+			out, err := exec.Command(sqlite3, path, "PRAGMA integrity_check; "+tt.query+";").CombinedOutput()
+			if err != nil {
+				t.Fatalf("%v: %s", err, out)
+			}
+			if got, want := string(out), "ok\n"+tt.want+"\n"; got != want {
+				t.Errorf("sqlite3 says %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSQLiteWriteErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, want string
+		row        []any
+	}{
+		{"rowid", "things: rowid 1 isn't an int", []any{"1", "one"}},
+		{"value", "things: can't store bool", []any{1, true}},
+		{"bytes", "things: can't store []uint8", []any{1, []byte("one")}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			table := sqliteTable{name: "things", sql: "CREATE TABLE things (id INTEGER PRIMARY KEY, v)", rows: [][]any{tt.row}}
+			err := writeSQLite(&bytes.Buffer{}, []sqliteTable{table})
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// store is where generated files go: a local directory, a zip or tar
+// archive, a cloud bucket when -outdir is a URL such as s3://bucket/prefix,
+// or memory. Every one is written through io.Writers and read back as an
+// fs.FS, so the rest of the tool doesn't care which it has. Names are slash
+// separated and relative to the store's root.
+type store interface {
+	// Open reads back a file written earlier.
+	fs.FS
+	// put writes a file, replacing any existing one. A failed write never
+	// leaves a partial file behind.
+	put(name string, write func(w io.Writer) error) error
+	// sha256 returns the hex SHA-256 hash and size of a stored file.
+	sha256(name string) (sum string, size int64, err error)
+	// Close finishes writing. Archives are only written out here.
+	Close() error
+	String() string
+}
+
+// openStore opens the output location named by -outdir.
+func openStore(o *options) (store, error) {
+	if scheme, rest, ok := strings.Cut(o.outdir, "://"); ok {
+		open, found := cloudStores[scheme]
+		if !found {
+			return nil, fmt.Errorf("unsupported -outdir scheme %q (want s3, gs or az)", scheme)
+		}
+		if o.optimizer != "" {
+			return nil, fmt.Errorf("-optimizer needs a local -outdir, not %s", o.outdir)
+		}
+		return open(rest)
+	}
+	if write, ok := archiveFormats[strings.ToLower(filepath.Ext(o.outdir))]; ok {
+		if o.optimizer != "" || o.resume {
+			return nil, fmt.Errorf("-optimizer and -resume need an -outdir folder, not archive %s", o.outdir)
+		}
+		return &archiveStore{memStore: newMemStore(o.outdir), path: o.outdir, write: write}, nil
+	}
+	if err := os.MkdirAll(o.outdir, 0755); err != nil {
+		return nil, err
+	}
+	return dirStore(o.outdir), nil
+}
+
+// storeSHA256 hashes a file by reading it back from st.
+func storeSHA256(st fs.FS, name string) (string, int64, error) {
+	f, err := st.Open(name)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// dirStore is a local output directory.
+type dirStore string
+
+func (d dirStore) path(name string) string { return filepath.Join(string(d), filepath.FromSlash(name)) }
+
+func (d dirStore) Open(name string) (fs.File, error) { return os.DirFS(string(d)).Open(name) }
+
+func (d dirStore) put(name string, write func(w io.Writer) error) error {
+	return atomicWrite(d.path(name), write)
+}
+
+func (d dirStore) sha256(name string) (string, int64, error) { return storeSHA256(d, name) }
+
+func (d dirStore) Close() error   { return nil }
+func (d dirStore) String() string { return string(d) }
+
+// memStore keeps files in memory, remembering the order they were first
+// written in.
+type memStore struct {
+	name  string
+	files map[string]*memFile
+	order []string
+}
+
+func newMemStore(name string) *memStore {
+	return &memStore{name: name, files: map[string]*memFile{}}
+}
+
+func (m *memStore) Open(name string) (fs.File, error) {
+	f, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return f.open(), nil
+}
+
+func (m *memStore) put(name string, write func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	if _, ok := m.files[name]; !ok {
+		m.order = append(m.order, name)
+	}
+	m.files[name] = &memFile{name: name, data: buf.Bytes(), modTime: time.Now()}
+	return nil
+}
+
+func (m *memStore) sha256(name string) (string, int64, error) { return storeSHA256(m, name) }
+
+func (m *memStore) Close() error   { return nil }
+func (m *memStore) String() string { return m.name }
+
+// memFile is a file held in memory, and its own fs.FileInfo.
+type memFile struct {
+	name    string
+	data    []byte
+	modTime time.Time
+}
+
+func (f *memFile) Name() string       { return path.Base(f.name) }
+func (f *memFile) Size() int64        { return int64(len(f.data)) }
+func (f *memFile) Mode() fs.FileMode  { return 0644 }
+func (f *memFile) ModTime() time.Time { return f.modTime }
+func (f *memFile) IsDir() bool        { return false }
+func (f *memFile) Sys() any           { return nil }
+
+// open returns an fs.File reading f from the start.
+func (f *memFile) open() fs.File { return &openMemFile{bytes.NewReader(f.data), f} }
+
+type openMemFile struct {
+	*bytes.Reader
+	info *memFile
+}
+
+func (o *openMemFile) Stat() (fs.FileInfo, error) { return o.info, nil }
+func (o *openMemFile) Close() error               { return nil }
+
+// archiveFormats writes the contents of a memStore to w as the archive
+// format for a file extension.
+var archiveFormats = map[string]func(w io.Writer, m *memStore) error{
+	".zip": writeZip,
+	".tar": writeTar,
+}
+
+// archiveStore collects the output in memory and writes it out as a single
+// archive file when closed. The manifest is rewritten after every card, so
+// streaming files into the archive as they come would leave 118 copies of
+// it.
+type archiveStore struct {
+	*memStore
+	path  string
+	write func(w io.Writer, m *memStore) error
+}
+
+func (a *archiveStore) Close() error {
+	return atomicWrite(a.path, func(w io.Writer) error { return a.write(w, a.memStore) })
+}
+
+func writeZip(w io.Writer, m *memStore) error {
+	zw := zip.NewWriter(w)
+	for _, name := range m.order {
+		f := m.files[name]
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: f.modTime})
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeTar(w io.Writer, m *memStore) error {
+	tw := tar.NewWriter(w)
+	for _, name := range m.order {
+		f := m.files[name]
+		hdr := &tar.Header{Name: name, Mode: int64(f.Mode()), Size: f.Size(), ModTime: f.modTime, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+)
+
+func TestMemStore(t *testing.T) {
+	m := newMemStore("test")
+	for _, f := range []struct{ name, data string }{
+		{"H.png", "first"},
+		{"cards/He.png", "helium"},
+		{"H.png", "hydrogen"},
+	} {
+		if err := m.put(f.name, func(w io.Writer) error {
+			_, err := io.WriteString(w, f.data)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.put("bad.png", func(io.Writer) error { return errors.New("failed") }); err == nil {
+		t.Error("put kept going after its write failed")
+	}
+
+	bs, err := fs.ReadFile(m, "H.png")
+	if err != nil || string(bs) != "hydrogen" {
+		t.Errorf("H.png = %q, %v; want the second write", bs, err)
+	}
+	info, err := fs.Stat(m, "cards/He.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name() != "He.png" || info.Size() != 6 || info.IsDir() || info.Mode() != 0644 {
+		t.Errorf("cards/He.png info = %q, %d, %v, %v", info.Name(), info.Size(), info.IsDir(), info.Mode())
+	}
+	if _, size, err := m.sha256("cards/He.png"); err != nil || size != 6 {
+		t.Errorf("sha256 size = %d, %v; want 6", size, err)
+	}
+	for _, name := range []string{"bad.png", "He.png", "../H.png"} {
+		if _, err := m.Open(name); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Open(%q) = %v, want fs.ErrNotExist", name, err)
+		}
+	}
+	if want := []string{"H.png", "cards/He.png"}; len(m.order) != 2 || m.order[0] != want[0] || m.order[1] != want[1] {
+		t.Errorf("order = %q, want %q", m.order, want)
+	}
+
+	var zbuf bytes.Buffer
+	if err := writeZip(&zbuf, m); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(zbuf.Bytes()), int64(zbuf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs, err := fs.ReadFile(zr, "cards/He.png"); err != nil || string(bs) != "helium" {
+		t.Errorf("zip cards/He.png = %q, %v", bs, err)
+	}
+
+	var tbuf bytes.Buffer
+	if err := writeTar(&tbuf, m); err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(&tbuf)
+	hdr, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bs, _ := io.ReadAll(tr); hdr.Name != "H.png" || hdr.Mode != 0644 || string(bs) != "hydrogen" {
+		t.Errorf("first tar entry = %q %o %q", hdr.Name, hdr.Mode, bs)
+	}
+}
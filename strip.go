@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+
+	"periodic-table-tiles/render"
+)
+
+// How the properties taught as periodic trends change going down a group
+// and going across a period from left to right.
+var (
+	groupTrends = []string{
+		"Atomic radius increases", "Ionisation energy decreases",
+		"Electronegativity decreases", "Metallic character increases",
+	}
+	periodTrends = []string{
+		"Atomic radius decreases", "Ionisation energy increases",
+		"Electronegativity increases", "Metallic character decreases",
+	}
+)
+
+// runStrip draws the cards of one group top to bottom, or one period left
+// to right, with an arrow along them labelled with the trends that way.
+func runStrip(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("strip", flag.ExitOnError)
+	o := addFlags(fs)
+	group := fs.Int("group", 0, "the group (column) to draw, from 1 to 18")
+	period := fs.Int("period", 0, "the period (row) to draw, from 1 to 7, with its lanthanides or actinides")
+	trends := fs.Bool("trends", true, "label the strip with how atomic radius, ionisation energy, electronegativity and metallic character change along it")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if (*group == 0) == (*period == 0) {
+		return fmt.Errorf("give one of -group or -period")
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	var members []Element
+	for _, e := range elements {
+		info := describeElement(e, "")
+		if *group > 0 && info.Group == *group || *period > 0 && info.Period == *period {
+			members = append(members, e)
+		}
+	}
+	if err := r.CheckStrict(members); err != nil {
+		return err
+	}
+
+	fname, labels := fmt.Sprintf("period_%d.png", *period), periodTrends
+	if *group > 0 {
+		fname, labels = fmt.Sprintf("group_%d.png", *group), groupTrends
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("no elements for %s", fname)
+	}
+	if !*trends {
+		labels = nil
+	}
+	face, err := r.CardFont(float64(r.TileH) / 8)
+	if err != nil {
+		return err
+	}
+	return saveAsset(o, fname, drawStrip(r, members, *group > 0, face, labels))
+}
+
+// drawStrip draws the cards in a row, or a column if down is set, with an arrow
+// alongside pointing the way the labels describe. The labels go below a
+// row and to the right of a column.
+func drawStrip(r *render.Renderer, elements []Element, down bool, face font.Face, labels []string) *image.RGBA {
+	pad := r.TileH / 20
+	size := face.Metrics().Height.Round()
+	textW := 0
+	for _, l := range labels {
+		textW = max(textW, render.MeasureText(face, l).Round())
+	}
+	lineH := size * 5 / 4
+	textH := len(labels) * lineH
+
+	cardsW, cardsH := r.TileW+2*pad, r.TileH+2*pad
+	step := image.Pt(r.TileW+pad, 0)
+	if down {
+		cardsH = len(elements)*(r.TileH+pad) + pad
+		step = image.Pt(0, r.TileH+pad)
+	} else {
+		cardsW = len(elements)*(r.TileW+pad) + pad
+	}
+	w, h := cardsW, cardsH
+	if len(labels) > 0 {
+		if down {
+			w, h = cardsW+2*size+textW+pad, max(cardsH, textH+2*pad)
+		} else {
+			w, h = max(cardsW, textW+2*pad), cardsH+2*size+textH
+		}
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for i, e := range elements {
+		tile := r.Tile(e)
+		at := image.Pt(pad, pad).Add(step.Mul(i))
+		draw.Draw(img, tile.Bounds().Add(at), tile, image.Point{}, draw.Over)
+	}
+	if len(labels) == 0 {
+		return img
+	}
+
+	arrow := overlayShape{kind: "arrow", stroke: color.NRGBA{0, 0, 0, 255}, width: float64(size) / 6, opacity: 1, blend: blendNormal}
+	var textAt image.Point // baseline of the first label
+	if down {
+		x := float64(cardsW + size/2)
+		arrow.x0, arrow.y0, arrow.x1, arrow.y1 = x, float64(pad), x, float64(cardsH-pad)
+		textAt = image.Pt(cardsW+3*size/2, (h-textH)/2+face.Metrics().Ascent.Round())
+	} else {
+		y := float64(cardsH + size/2)
+		arrow.x0, arrow.y0, arrow.x1, arrow.y1 = float64(pad), y, float64(cardsW-pad), y
+		textAt = image.Pt(0, cardsH+3*size/2+face.Metrics().Ascent.Round())
+	}
+	drawOverlay(img, r.Options.FontPath, []overlayShape{arrow})
+	for i, l := range labels {
+		x := textAt.X
+		if !down {
+			x = (w - render.MeasureText(face, l).Round()) / 2
+		}
+		render.DrawText(img, face, x, textAt.Y+i*lineH, l, color.Black)
+	}
+	return img
+}
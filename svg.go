@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+
+	"periodic-table-tiles/render"
+)
+
+// svgInteractive is the style and script added by -interactive: cards fade
+// in one after another, and the one under the pointer is brought to the
+// front and enlarged.
+const svgInteractive = `<style>
+.card { animation: appear 0.4s both; animation-delay: calc(var(--i) * 12ms); cursor: default; }
+.card .face { transform-box: fill-box; transform-origin: center; transition: transform 0.15s; }
+.card:hover .face { transform: scale(1.35); }
+@keyframes appear { from { opacity: 0; } }
+</style>
+<script>
+document.currentScript.ownerSVGElement.querySelectorAll(".card").forEach(function (c) {
+  c.addEventListener("mouseenter", function () {
+    c.style.animation = "none";
+    c.parentNode.appendChild(c);
+  });
+});
+</script>
+`
+
+// svgGreyed is the filter for cards faded by -dim-others, matching greyed.
+const svgGreyed = `<filter id="greyed"><feColorMatrix type="saturate" values="0"/>` +
+	`<feComponentTransfer><feFuncR type="linear" slope="0.4" intercept="0.6"/><feFuncG type="linear" slope="0.4" intercept="0.6"/><feFuncB type="linear" slope="0.4" intercept="0.6"/></feComponentTransfer></filter>
+`
+
+// writeTableSVG writes the full table as an SVG, laid out like renderTable
+// with every card as vector shapes and text, the overlay on top and any
+// footer below. Each card has a tooltip giving its element's details.
+func writeTableSVG(w io.Writer, r *render.Renderer, elements []Element, layout string, hl highlight, interactive bool, overlay []overlayShape, footer string) error {
+	t := newTableLayout(r, elements, layout)
+	l := r.Layout()
+	tableH := t.H
+	if footer != "" {
+		h, _ := footerHeight(r)
+		t.H += h
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="%s, sans-serif" font-weight="bold">`+"\n",
+		t.W, t.H, t.W, t.H, html.EscapeString(render.FontFamily(r.Options.FontPath)))
+	if interactive {
+		b.WriteString(svgInteractive)
+	}
+	if hl.dim {
+		b.WriteString(svgGreyed)
+	}
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", t.W, t.H)
+
+	for i, e := range elements {
+		at := t.pos(e)
+		fmt.Fprintf(&b, `<g class="card" transform="translate(%d %d)" style="--i:%d">`, at.X, at.Y, i)
+		fmt.Fprintf(&b, "<title>%s (%s)\nAtomic number %d\nAtomic mass %s\n%s</title>",
+			html.EscapeString(e.Name), html.EscapeString(e.Symbol), e.Number, html.EscapeString(r.FormatMass(e)), html.EscapeString(e.Type))
+		if hl.dimmed(e) {
+			b.WriteString(`<g class="face" filter="url(#greyed)">`)
+		} else {
+			b.WriteString(`<g class="face">`)
+		}
+		render.WriteCardSVG(&b, r, l, e)
+		b.WriteString("</g></g>\n")
+	}
+	for _, e := range elements {
+		if hl.numbers[e.Number] {
+			at := t.pos(e)
+			fmt.Fprintf(&b, `<g transform="translate(%d %d)">%s</g>`+"\n", at.X, at.Y,
+				render.SVGCardShape(r, 0, fmt.Sprintf(`fill="none" stroke="#000" stroke-width="%.1f" stroke-linejoin="round"`, hl.outlineWidth(r))))
+		}
+	}
+	writeOverlaySVG(&b, overlay)
+	if footer != "" {
+		// Not cut short as on the PNG, since the viewer's font may differ.
+		h, size := footerHeight(r)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="%.1f" fill="%s">%s</text>`+"\n",
+			t.gap*2, tableH+h*2/3, size, render.RGBHex(footerColour), html.EscapeString(footer))
+	}
+	b.WriteString("</svg>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
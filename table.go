@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strings"
+	"text/template"
+
+	"periodic-table-tiles/render"
+)
+
+// Full table layouts accepted by -layout.
+const (
+	layoutGrid      = "grid"
+	layoutHoneycomb = "honeycomb"
+)
+
+// runTable renders every card into one poster of the whole periodic table.
+func runTable(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("table", flag.ExitOnError)
+	o := addFlags(fs)
+	layout := fs.String("layout", layoutGrid, "grid, or honeycomb to interlock the rows of -shape hex tiles")
+	format := fs.String("format", "png", "png, svg for a scalable vector table, or tiff for print")
+	interactive := fs.Bool("interactive", false, "with -format svg, animate the cards in and enlarge them with a tooltip on hover")
+	overlayPath := fs.String("overlay", "", "JSON file of boxes, arrows, circles and labels to draw over the table")
+	regions := fs.String("regions", "", "comma-separated series to outline and label, such as \"transition metals,halogens,noble gases,lanthanides\"")
+	regionStyle := fs.String("region-style", render.StrokeSolid, "stroke of the -regions outlines: solid, dashed or dotted")
+	highlightList := fs.String("highlight", "", "comma-separated elements, by symbol or atomic number, to outline")
+	dimOthers := fs.Bool("dim-others", false, "with -highlight, fade every other card to grey")
+	background := fs.String("background", "", "PNG or JPEG to draw the table over, scaled to cover it")
+	tileOpacity := fs.Float64("tile-opacity", 1, "with -background, opacity of the cards from 0 to 1")
+	blend := fs.String("blend", blendNormal, "with -background, how the cards combine with it: normal, multiply, screen or overlay")
+	plotterMode := fs.Bool("plotter", false, "with -format svg, draw everything as stroked lines for a pen plotter: no fills, and text turned into glyph outlines from -font")
+	penWidth := fs.Float64("pen-width", 1, "with -plotter, width in px of the pen every line is drawn with")
+	footerTemplate := fs.String("footer", "", "text/template for a citation line under the table, such as \"Data: {{base .Data}} {{.DataVersion}} / generated by {{.Tool}} {{.Version}}\"")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if err := checkLayout(*layout, o.Shape); err != nil {
+		return err
+	}
+	switch *format {
+	case "png", "svg", "tiff":
+	default:
+		return fmt.Errorf("unknown -format %q (want png, svg or tiff)", *format)
+	}
+	if *format == "svg" && o.ColourSpace != render.ColourSpaceSRGB {
+		return fmt.Errorf("-colour-space %s needs -format png or tiff", o.ColourSpace)
+	}
+	if *format == "png" && o.ColourSpace == render.ColourSpaceCMYK {
+		return fmt.Errorf("-colour-space %s needs -format tiff", o.ColourSpace)
+	}
+	if *plotterMode && (*format != "svg" || *interactive) {
+		return fmt.Errorf("-plotter needs -format svg, without -interactive")
+	}
+	if *dimOthers && *highlightList == "" {
+		return fmt.Errorf("-dim-others needs -highlight")
+	}
+
+	var bg *backdrop
+	if *background != "" {
+		if *format == "svg" {
+			return fmt.Errorf("-background only works with -format png or tiff")
+		}
+		var err error
+		if bg, err = loadBackdrop(*background, *tileOpacity, *blend); err != nil {
+			return err
+		}
+	}
+	var footerTmpl *template.Template
+	if *footerTemplate != "" {
+		var err error
+		if footerTmpl, err = parseFooter(*footerTemplate); err != nil {
+			return err
+		}
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	var outliner *render.SVGOutliner
+	switch {
+	case *plotterMode:
+		outliner, err = render.NewPlotter(o.FontPath, *penWidth)
+	case o.textToPath && *format == "svg":
+		outliner, err = render.NewTextOutliner(o.FontPath)
+	}
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	if err := r.CheckStrict(elements); err != nil {
+		return err
+	}
+
+	var footer string
+	if footerTmpl != nil {
+		if footer, err = footerText(footerTmpl, o); err != nil {
+			return err
+		}
+	}
+
+	hl, err := parseHighlight(*highlightList, elements)
+	if err != nil {
+		return err
+	}
+	hl.dim = *dimOthers
+
+	var overlay []overlayShape
+	if *regions != "" {
+		spec := &overlaySpec{Items: regionItems(*regions, *regionStyle)}
+		overlay, err = resolveOverlay(spec, r, newTableLayout(r, elements, *layout), elements)
+		if err != nil {
+			return fmt.Errorf("-regions: %w", err)
+		}
+	}
+	if *overlayPath != "" {
+		spec, err := loadOverlay(*overlayPath)
+		if err != nil {
+			return err
+		}
+		shapes, err := resolveOverlay(spec, r, newTableLayout(r, elements, *layout), elements)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *overlayPath, err)
+		}
+		overlay = append(overlay, shapes...)
+	}
+
+	switch *format {
+	case "png", "tiff":
+		img := renderTable(r, elements, *layout, hl, bg)
+		if err := drawOverlay(img, o.FontPath, overlay); err != nil {
+			return err
+		}
+		if footer != "" {
+			if img, err = addFooter(img, r, footer); err != nil {
+				return err
+			}
+		}
+		if *format == "png" {
+			return saveAsset(o, "periodic_table.png", img)
+		}
+		const fname = "periodic_table.tif"
+		return saveFile(o, fname, func(st store) error {
+			return st.put(fname, func(w io.Writer) error { return writeTIFF(w, img, o) })
+		})
+	case "svg":
+		const fname = "periodic_table.svg"
+		return saveFile(o, fname, func(st store) error {
+			return st.put(fname, func(w io.Writer) error {
+				return render.WriteOutlined(w, outliner, func(w io.Writer) error {
+					return writeTableSVG(w, r, elements, *layout, hl, *interactive, overlay, footer)
+				})
+			})
+		})
+	}
+	panic("unreachable")
+}
+
+func checkLayout(layout, shape string) error {
+	switch layout {
+	case layoutGrid:
+	case layoutHoneycomb:
+		if shape != render.ShapeHex {
+			return fmt.Errorf("-layout %s needs -shape %s", layoutHoneycomb, render.ShapeHex)
+		}
+	default:
+		return fmt.Errorf("unknown -layout %q (want %s or %s)", layout, layoutGrid, layoutHoneycomb)
+	}
+	return nil
+}
+
+// tableLayout places the cards of the full table. In a honeycomb each row
+// overlaps the one above by the height of a hexagon's point and every other
+// row is shifted half a tile across, so the hexagons interlock.
+type tableLayout struct {
+	layout       string
+	gap          int
+	stepX, stepY int
+	W, H         int // size of the whole table
+}
+
+func newTableLayout(r *render.Renderer, elements []Element, layout string) tableLayout {
+	gap := max(1, r.TileH/40)
+	t := tableLayout{layout: layout, gap: gap, stepX: r.TileW + gap, stepY: r.TileH + gap}
+	if layout == layoutHoneycomb {
+		t.stepY = r.TileH*3/4 + gap
+	}
+	cols, rows := 0, 0
+	for _, e := range elements {
+		cols, rows = max(cols, e.XPos), max(rows, e.YPos)
+	}
+	t.W = cols*t.stepX + gap*3
+	t.H = (rows-1)*t.stepY + r.TileH + gap*4
+	if layout == layoutHoneycomb {
+		t.W += t.stepX / 2
+	}
+	return t
+}
+
+// pos returns the top-left corner of an element's card.
+func (t tableLayout) pos(e Element) image.Point {
+	x, y := (e.XPos-1)*t.stepX, (e.YPos-1)*t.stepY
+	if t.layout == layoutHoneycomb && e.YPos%2 == 0 {
+		x += t.stepX / 2
+	}
+	return image.Pt(x+t.gap*2, y+t.gap*2)
+}
+
+// highlight is the set of elements picked out by -highlight.
+type highlight struct {
+	numbers map[int]bool
+	dim     bool // fade the other cards to grey
+}
+
+// parseHighlight looks up a comma-separated list of symbols or atomic
+// numbers.
+func parseHighlight(list string, elements []Element) (highlight, error) {
+	h := highlight{numbers: map[int]bool{}}
+	if list == "" {
+		return h, nil
+	}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		i := indexElement(elements, name)
+		if i < 0 {
+			return h, fmt.Errorf("-highlight: unknown element %q", name)
+		}
+		h.numbers[elements[i].Number] = true
+	}
+	return h, nil
+}
+
+// dimmed reports whether a card is faded.
+func (h highlight) dimmed(e Element) bool { return h.dim && !h.numbers[e.Number] }
+
+// outlineWidth is the width of the outline around highlighted cards, which
+// fills the gap between cards.
+func (h highlight) outlineWidth(r *render.Renderer) float64 {
+	return math.Max(2, float64(r.TileH)/20)
+}
+
+// renderTable draws the cards at their places in the table, outlining the
+// highlighted ones, on white or over bg if it isn't nil.
+func renderTable(r *render.Renderer, elements []Element, layout string, hl highlight, bg *backdrop) *image.RGBA {
+	t := newTableLayout(r, elements, layout)
+	var img *image.RGBA
+	if bg != nil {
+		img = bg.canvas(t.W, t.H)
+	} else {
+		img = image.NewRGBA(image.Rect(0, 0, t.W, t.H))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	}
+	for _, e := range elements {
+		tile := r.Tile(e)
+		if hl.dimmed(e) {
+			tile = greyed(tile)
+		}
+		if bg != nil {
+			bg.drawTile(img, tile, t.pos(e))
+			continue
+		}
+		draw.Draw(img, tile.Bounds().Add(t.pos(e)), tile, image.Point{}, draw.Over)
+	}
+	// Outlines go on last so that the next card doesn't cover their outer
+	// half.
+	for _, e := range elements {
+		if !hl.numbers[e.Number] {
+			continue
+		}
+		at := t.pos(e)
+		pts := r.Outline()(r.TileW, r.TileH, 0)
+		for i := range pts {
+			pts[i].X += float64(at.X)
+			pts[i].Y += float64(at.Y)
+		}
+		render.StrokePolygon(img, pts, hl.outlineWidth(r), color.Black)
+	}
+	return img
+}
+
+// greyed returns a copy of a card in pale greys.
+func greyed(tile *image.RGBA) *image.RGBA {
+	g := image.NewRGBA(tile.Bounds())
+	for i := 0; i < len(tile.Pix); i += 4 {
+		p := tile.Pix[i : i+4 : i+4]
+		y := (299*int(p[0]) + 587*int(p[1]) + 114*int(p[2])) / 1000
+		// Premultiplied, so white at this pixel's opacity is its alpha.
+		v := uint8(int(p[3]) - (int(p[3])-y)*2/5)
+		g.Pix[i], g.Pix[i+1], g.Pix[i+2], g.Pix[i+3] = v, v, v, p[3]
+	}
+	return g
+}
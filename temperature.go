@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+	"time"
+
+	"periodic-table-tiles/render"
+)
+
+// phaseAt returns an element's state at standard pressure and k kelvin.
+func phaseAt(e Element, k float64) string {
+	switch {
+	case e.Melt == 0:
+		return render.PhaseUnknown
+	case k < e.Melt:
+		return render.PhaseSolid
+	case e.Boil == 0:
+		return render.PhaseUnknown
+	case k < e.Boil:
+		return render.PhaseLiquid
+	}
+	return render.PhaseGas
+}
+
+// addPhaseColours gives the states of matter their default colours, unless
+// -colours has its own.
+func addPhaseColours(r *render.Renderer) {
+	for phase, c := range render.PhaseColours {
+		if _, ok := r.Colours[phase]; !ok {
+			r.Colours[phase] = c
+		}
+	}
+}
+
+// runTemperature renders an animated GIF of the full table as it is heated,
+// each card's border showing whether its element is solid, liquid or gas.
+func runTemperature(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("temperature", flag.ExitOnError)
+	o := addFlags(fs)
+	layout := fs.String("layout", layoutGrid, "grid, or honeycomb to interlock the rows of -shape hex tiles")
+	from := fs.Float64("from", 0, "starting temperature in kelvin")
+	to := fs.Float64("to", 6000, "final temperature in kelvin")
+	step := fs.Float64("step", 50, "kelvin between frames")
+	af := addAnimFlags(fs, 80*time.Millisecond, 2*time.Second)
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if err := checkLayout(*layout, o.Shape); err != nil {
+		return err
+	}
+	if err := af.check(); err != nil {
+		return err
+	}
+	if *step <= 0 || *to < *from || *from < 0 {
+		return errors.New("need 0 <= -from <= -to and a positive -step")
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	// The cards are coloured by state rather than category, and every
+	// state has a colour.
+	addPhaseColours(r)
+	cards := slices.Clone(elements)
+	for i := range cards {
+		cards[i].Type = render.PhaseSolid
+	}
+	if err := r.CheckStrict(cards); err != nil {
+		return err
+	}
+	var temps []float64
+	for k := *from; k <= *to; k += *step {
+		temps = append(temps, k)
+	}
+	anim, err := temperatureAnimation(r, elements, *layout, temps)
+	if err != nil {
+		return err
+	}
+	return saveAnimation(o, "temperature", anim, af)
+}
+
+// temperatureAnimation has a frame for each temperature. After the first,
+// only the cards that change state and the caption change.
+func temperatureAnimation(r *render.Renderer, elements []Element, layout string, temps []float64) (animation, error) {
+	addPhaseColours(r)
+	// Every card in every state, drawn once.
+	tiles := map[int]map[string]*image.RGBA{}
+	for _, e := range elements {
+		tiles[e.Number] = map[string]*image.RGBA{}
+		for _, k := range temps {
+			p := phaseAt(e, k)
+			if _, ok := tiles[e.Number][p]; !ok {
+				e.Type = p
+				tiles[e.Number][p] = r.Tile(e)
+			}
+		}
+	}
+
+	t := newTableLayout(r, elements, layout)
+	box := captionBox(t)
+	face, err := captionFont(r, box)
+	if err != nil {
+		return animation{}, fmt.Errorf("loading font: %w", err)
+	}
+	label := func(k float64) string { return fmt.Sprintf("%.0f K", k) }
+
+	// A sheet of every card in every state has all the colours.
+	sheetW := 0
+	for _, e := range elements {
+		sheetW += r.TileW * len(tiles[e.Number])
+	}
+	sheet := image.NewRGBA(image.Rect(0, 0, max(sheetW, box.Dx()), r.TileH+box.Dy()))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	x := 0
+	for _, e := range elements {
+		for _, tile := range tiles[e.Number] {
+			draw.Draw(sheet, tile.Bounds().Add(image.Pt(x, 0)), tile, image.Point{}, draw.Over)
+			x += r.TileW
+		}
+	}
+	drawCaption(sheet, face, box.Sub(box.Min).Add(image.Pt(0, r.TileH)), label(temps[len(temps)-1]))
+
+	canvas := image.NewRGBA(image.Rect(0, 0, t.W, t.H))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	shown := map[int]string{}
+	i := 0
+	next := func() (image.Rectangle, bool) {
+		if i == len(temps) {
+			return image.Rectangle{}, false
+		}
+		k := temps[i]
+		area := box
+		for _, e := range elements {
+			p := phaseAt(e, k)
+			if shown[e.Number] == p {
+				continue
+			}
+			shown[e.Number] = p
+			tile := tiles[e.Number][p]
+			at := tile.Bounds().Add(t.pos(e))
+			draw.Draw(canvas, at, image.NewUniform(color.White), image.Point{}, draw.Src)
+			draw.Draw(canvas, at, tile, image.Point{}, draw.Over)
+			area = area.Union(at)
+		}
+		drawCaption(canvas, face, box, label(k))
+		i++
+		if i == 1 {
+			return canvas.Bounds(), true
+		}
+		return area, true
+	}
+	return animation{canvas: canvas, colours: sheet, next: next}, nil
+}
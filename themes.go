@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"periodic-table-tiles/render"
+)
+
+// theme is a named set of category colours: -colours, every colours file
+// in the -dir folder, and the generated palette.
+type theme struct {
+	name string
+	path string // "" for the generated palette
+}
+
+func findThemes(coloursPath, dir string) ([]theme, error) {
+	var themes []theme
+	if _, err := os.Stat(coloursPath); err == nil {
+		themes = append(themes, theme{strings.TrimSuffix(filepath.Base(coloursPath), ".json"), coloursPath})
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	for _, p := range paths {
+		if p != coloursPath {
+			themes = append(themes, theme{strings.TrimSuffix(filepath.Base(p), ".json"), p})
+		}
+	}
+	return append(themes, theme{name: "generated"}), nil
+}
+
+func runThemes(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] != "preview" {
+		return fmt.Errorf("usage: themes preview [flags]")
+	}
+	fs := flag.NewFlagSet("themes preview", flag.ExitOnError)
+	o := addFlags(fs)
+	dir := fs.String("dir", "themes", "folder of colours files to compare")
+	if err := parseFlags(fs, o, args[1:]); err != nil {
+		return err
+	}
+
+	themes, err := findThemes(o.ColoursPath, *dir)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	// The first element of each category stands for it.
+	var samples []Element
+	for _, c := range categories {
+		if i := slices.IndexFunc(elements, func(e Element) bool { return e.Type == c }); i >= 0 {
+			samples = append(samples, elements[i])
+		}
+	}
+
+	var rows []*image.RGBA
+	for _, t := range themes {
+		to := *o
+		to.ColoursPath = t.path
+		if t.path == "" {
+			to.ColoursPath = filepath.Join(*dir, "generated.json") // doesn't exist, so a palette is generated
+		}
+		r, err := newRenderer(&to)
+		if err != nil {
+			return fmt.Errorf("theme %s: %w", t.name, err)
+		}
+		rows = append(rows, themeRow(r, t.name, samples))
+	}
+	return saveAsset(o, "themes.png", stackRows(rows))
+}
+
+// themeRow draws a theme's name followed by its sample cards.
+func themeRow(r *render.Renderer, name string, samples []Element) *image.RGBA {
+	pad := r.TileH / 20
+	labelW := r.TileW * 3 / 2
+	img := image.NewRGBA(image.Rect(0, 0, labelW+len(samples)*(r.TileW+pad), r.TileH+pad))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	label := render.FitText(r.NameFont, name, labelW-2*pad)
+	render.DrawText(img, r.NameFont, pad, (r.TileH+r.NameFont.Metrics().Ascent.Round())/2, label, color.Black)
+	for i, e := range samples {
+		tile := r.Tile(e)
+		at := image.Pt(labelW+i*(r.TileW+pad), 0)
+		draw.Draw(img, tile.Bounds().Add(at), tile, image.Point{}, draw.Over)
+	}
+	return img
+}
+
+// stackRows puts images one below another.
+func stackRows(rows []*image.RGBA) *image.RGBA {
+	w, h := 0, 0
+	for _, r := range rows {
+		w, h = max(w, r.Bounds().Dx()), h+r.Bounds().Dy()
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	y := 0
+	for _, r := range rows {
+		draw.Draw(img, r.Bounds().Add(image.Pt(0, y)), r, image.Point{}, draw.Src)
+		y += r.Bounds().Dy()
+	}
+	return img
+}
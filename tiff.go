@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"slices"
+
+	"periodic-table-tiles/render"
+)
+
+// TIFF tag numbers and field types the print output uses.
+const (
+	tiffImageWidth      = 256
+	tiffImageLength     = 257
+	tiffBitsPerSample   = 258
+	tiffCompression     = 259
+	tiffPhotometric     = 262
+	tiffStripOffsets    = 273
+	tiffSamplesPerPixel = 277
+	tiffRowsPerStrip    = 278
+	tiffStripByteCounts = 279
+	tiffXResolution     = 282
+	tiffYResolution     = 283
+	tiffPlanarConfig    = 284
+	tiffResolutionUnit  = 296
+	tiffInkSet          = 332
+	tiffExtraSamples    = 338
+	tiffICCProfile      = 34675
+
+	tiffShort     = 3
+	tiffLong      = 4
+	tiffRational  = 5
+	tiffUndefined = 7
+)
+
+type tiffField struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte // little endian
+}
+
+// writeTIFF writes img as a TIFF for print, deflate compressed. RGB
+// images keep their transparency and are tagged with the -colour-space
+// ICC profile; cmyk flattens the image onto white paper and separates it
+// into cyan, magenta, yellow and black inks with full black generation,
+// untagged, for the print shop's own profile to take over.
+func writeTIFF(w io.Writer, img image.Image, o *options) error {
+	b := img.Bounds()
+	nrgba := image.NewNRGBA(b)
+	draw.Draw(nrgba, b, img, b.Min, draw.Src)
+	if o.ColourSpace == render.ColourSpaceDisplayP3 {
+		nrgba = toDisplayP3(nrgba)
+	}
+	pix := nrgba.Pix
+	if o.ColourSpace == render.ColourSpaceCMYK {
+		for i := 0; i < len(pix); i += 4 {
+			a := uint32(pix[i+3])
+			paper := func(v uint8) uint8 { return uint8((uint32(v)*a + 255*(255-a)) / 255) }
+			pix[i], pix[i+1], pix[i+2], pix[i+3] = color.RGBToCMYK(paper(pix[i]), paper(pix[i+1]), paper(pix[i+2]))
+		}
+	}
+	var strip bytes.Buffer
+	zw := zlib.NewWriter(&strip)
+	if _, err := zw.Write(pix); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	le := binary.LittleEndian
+	short := func(tag uint16, vs ...uint16) tiffField {
+		var d []byte
+		for _, v := range vs {
+			d = le.AppendUint16(d, v)
+		}
+		return tiffField{tag, tiffShort, uint32(len(vs)), d}
+	}
+	long := func(tag uint16, v uint32) tiffField { return tiffField{tag, tiffLong, 1, le.AppendUint32(nil, v)} }
+	dpi := o.dpi
+	if dpi <= 0 {
+		dpi = 72
+	}
+	res := le.AppendUint32(le.AppendUint32(nil, uint32(math.Round(dpi*1000))), 1000)
+
+	fields := []tiffField{
+		long(tiffImageWidth, uint32(b.Dx())),
+		long(tiffImageLength, uint32(b.Dy())),
+		short(tiffBitsPerSample, 8, 8, 8, 8),
+		short(tiffCompression, 8), // deflate
+		short(tiffSamplesPerPixel, 4),
+		long(tiffRowsPerStrip, uint32(b.Dy())),
+		long(tiffStripByteCounts, uint32(strip.Len())),
+		{tiffXResolution, tiffRational, 1, res},
+		{tiffYResolution, tiffRational, 1, res},
+		short(tiffPlanarConfig, 1),   // chunky
+		short(tiffResolutionUnit, 2), // inches
+	}
+	if o.ColourSpace == render.ColourSpaceCMYK {
+		fields = append(fields, short(tiffPhotometric, 5), short(tiffInkSet, 1)) // separated, CMYK
+	} else {
+		icc := iccProfiles[o.ColourSpace].profile
+		fields = append(fields,
+			short(tiffPhotometric, 2),  // RGB
+			short(tiffExtraSamples, 2), // unassociated alpha
+			tiffField{tiffICCProfile, tiffUndefined, uint32(len(icc)), icc},
+		)
+	}
+	// The strip's offset is only known once the rest is laid out.
+	fields = append(fields, long(tiffStripOffsets, 0))
+	slices.SortFunc(fields, func(a, b tiffField) int { return int(a.tag) - int(b.tag) })
+
+	// The header, then the directory, then the values too big to fit in
+	// it, then the strip.
+	const header = 8
+	next := header + 2 + 12*len(fields) + 4
+	for _, f := range fields {
+		if len(f.data) > 4 {
+			next += len(f.data) + len(f.data)%2
+		}
+	}
+	for i := range fields {
+		if fields[i].tag == tiffStripOffsets {
+			fields[i].data = le.AppendUint32(nil, uint32(next))
+		}
+	}
+
+	out := []byte("II*\x00")
+	out = le.AppendUint32(out, header)
+	out = le.AppendUint16(out, uint16(len(fields)))
+	var extra []byte
+	for _, f := range fields {
+		out = le.AppendUint16(out, f.tag)
+		out = le.AppendUint16(out, f.typ)
+		out = le.AppendUint32(out, f.count)
+		if len(f.data) > 4 {
+			out = le.AppendUint32(out, uint32(header+2+12*len(fields)+4+len(extra)))
+			extra = append(extra, f.data...)
+			if len(f.data)%2 != 0 {
+				extra = append(extra, 0) // values start on a word boundary
+			}
+		} else {
+			out = append(out, f.data...)
+			out = append(out, make([]byte, 4-len(f.data))...)
+		}
+	}
+	out = le.AppendUint32(out, 0) // no more directories
+	out = append(out, extra...)
+	if _, err := w.Write(out); err != nil {
+		return err
+	}
+	_, err := w.Write(strip.Bytes())
+	return err
+}
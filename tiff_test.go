@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+
+	"golang.org/x/image/tiff"
+
+	"periodic-table-tiles/render"
+)
+
+// readTIFF reads the fields of a TIFF's first directory, by tag, and the
+// inflated pixels of its one strip.
+func readTIFF(t *testing.T, bs []byte) (map[uint16]tiffField, []byte) {
+	t.Helper()
+	le := binary.LittleEndian
+	if string(bs[:4]) != "II*\x00" {
+		t.Fatalf("not a little-endian TIFF: % x", bs[:4])
+	}
+	dir := bs[le.Uint32(bs[4:]):]
+	fields := map[uint16]tiffField{}
+	var last uint16
+	for i := range int(le.Uint16(dir)) {
+		entry := dir[2+12*i:]
+		f := tiffField{le.Uint16(entry), le.Uint16(entry[2:]), le.Uint32(entry[4:]), entry[8:12]}
+		if f.tag <= last {
+			t.Errorf("tag %d after %d; they must be in order", f.tag, last)
+		}
+		last = f.tag
+		size := map[uint16]uint32{tiffShort: 2, tiffLong: 4, tiffRational: 8, tiffUndefined: 1}[f.typ] * f.count
+		if size > 4 {
+			off := le.Uint32(f.data)
+			if off%2 != 0 || int(off+size) > len(bs) {
+				t.Fatalf("tag %d's %d bytes at %d run past the end or aren't word aligned", f.tag, size, off)
+			}
+			f.data = bs[off : off+size]
+		} else {
+			f.data = f.data[:size]
+		}
+		fields[f.tag] = f
+	}
+	off, n := le.Uint32(fields[tiffStripOffsets].data), le.Uint32(fields[tiffStripByteCounts].data)
+	zr, err := zlib.NewReader(bytes.NewReader(bs[off : off+n]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fields, pix
+}
+
+func TestWriteTIFF(t *testing.T) {
+	// White, black, red, transparent, and black at half opacity.
+	img := image.NewNRGBA(image.Rect(0, 0, 5, 1))
+	for x, c := range []color.NRGBA{{255, 255, 255, 255}, {0, 0, 0, 255}, {255, 0, 0, 255}, {}, {0, 0, 0, 128}} {
+		img.SetNRGBA(x, 0, c)
+	}
+	le := binary.LittleEndian
+	for _, tt := range []struct {
+		space       string
+		photometric uint16
+		pix         []byte
+	}{
+		{render.ColourSpaceSRGB, 2, img.Pix},
+		// Separated onto white paper, with all the grey in the black ink.
+		{render.ColourSpaceCMYK, 5, []byte{0, 0, 0, 0, 0, 0, 0, 255, 0, 255, 255, 0, 0, 0, 0, 0, 0, 0, 0, 128}},
+	} {
+		t.Run(tt.space, func(t *testing.T) {
+			o := &options{Options: render.Options{ColourSpace: tt.space}, dpi: 300}
+			var b bytes.Buffer
+			if err := writeTIFF(&b, img, o); err != nil {
+				t.Fatal(err)
+			}
+			fields, pix := readTIFF(t, b.Bytes())
+			if w, h := le.Uint32(fields[tiffImageWidth].data), le.Uint32(fields[tiffImageLength].data); w != 5 || h != 1 {
+				t.Errorf("size %dx%d, want 5x1", w, h)
+			}
+			if p := le.Uint16(fields[tiffPhotometric].data); p != tt.photometric {
+				t.Errorf("photometric %d, want %d", p, tt.photometric)
+			}
+			res := fields[tiffXResolution].data
+			if dpi := float64(le.Uint32(res)) / float64(le.Uint32(res[4:])); dpi != 300 || le.Uint16(fields[tiffResolutionUnit].data) != 2 {
+				t.Errorf("resolution %g, want 300 per inch", dpi)
+			}
+			icc, tagged := fields[tiffICCProfile]
+			if tt.space == render.ColourSpaceCMYK {
+				if tagged {
+					t.Error("CMYK is tagged with a profile")
+				}
+			} else if !bytes.Equal(icc.data, iccProfiles[tt.space].profile) {
+				t.Errorf("not tagged with the %s profile", tt.space)
+			}
+			if !bytes.Equal(pix, tt.pix) {
+				t.Errorf("pixels\n% x\nwant\n% x", pix, tt.pix)
+			}
+		})
+	}
+
+	// Other readers understand the RGB ones, with unassociated alpha.
+	var b bytes.Buffer
+	if err := writeTIFF(&b, img, &options{Options: render.Options{ColourSpace: render.ColourSpaceDisplayP3}}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := tiff.Decode(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := toDisplayP3(img)
+	for x := range 5 {
+		if got := color.NRGBAModel.Convert(decoded.At(x, 0)); got != want.At(x, 0) {
+			t.Errorf("pixel %d is %v, want %v", x, got, want.At(x, 0))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A reader for the small part of TOML that -config files use: key = value
+// pairs, [table] headers one level deep, basic and literal strings,
+// integers, floats, booleans, one-line arrays and # comments. Dotted keys,
+// inline tables, dates and multi-line strings and arrays aren't supported.
+
+// parseTOML parses a document into a map of strings, int64s, float64s,
+// bools and []anys, with a map[string]any for each table.
+func parseTOML(src string) (map[string]any, error) {
+	doc := map[string]any{}
+	table := doc
+	for i, raw := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		line := i + 1
+		text := strings.TrimSpace(stripYAMLComment(raw))
+		if text == "" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(text, "["); ok {
+			name, ok = strings.CutSuffix(name, "]")
+			name = strings.TrimSpace(name)
+			if !ok || name == "" || strings.ContainsAny(name, "[].") {
+				return nil, fmt.Errorf("line %d: bad table header %s", line, text)
+			}
+			if _, dup := doc[name]; dup {
+				return nil, fmt.Errorf("line %d: %s is defined twice", line, name)
+			}
+			table = map[string]any{}
+			doc[name] = table
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want key = value", line)
+		}
+		key = strings.TrimSpace(key)
+		// A quoted key may hold anything; a bare one is a single word.
+		if unquoted, err := strconv.Unquote(key); err == nil {
+			key = unquoted
+		} else if key == "" || strings.ContainsAny(key, " \t.") {
+			return nil, fmt.Errorf("line %d: bad key %q", line, key)
+		}
+		if _, dup := table[key]; dup {
+			return nil, fmt.Errorf("line %d: %s is defined twice", line, key)
+		}
+		v, err := tomlValue(strings.TrimSpace(value), line)
+		if err != nil {
+			return nil, err
+		}
+		table[key] = v
+	}
+	return doc, nil
+}
+
+// tomlValue parses a value: an array of values or a scalar.
+func tomlValue(s string, line int) (any, error) {
+	if inner, ok := strings.CutPrefix(s, "["); ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+		if !ok {
+			return nil, fmt.Errorf("line %d: arrays must be on one line", line)
+		}
+		vs := []any{}
+		for _, item := range splitFlow(inner) {
+			if item == "" {
+				continue // a trailing comma
+			}
+			v, err := tomlValue(item, line)
+			if err != nil {
+				return nil, err
+			}
+			vs = append(vs, v)
+		}
+		return vs, nil
+	}
+	switch {
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad string %s", line, s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") || strings.Contains(s[1:len(s)-1], "'") {
+			return nil, fmt.Errorf("line %d: bad string %s", line, s)
+		}
+		return s[1 : len(s)-1], nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	}
+	digits := strings.ReplaceAll(s, "_", "")
+	if n, err := strconv.ParseInt(digits, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(digits, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("line %d: bad value %s (strings must be quoted)", line, s)
+}
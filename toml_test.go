@@ -0,0 +1,96 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	for _, tt := range []struct {
+		name, src string
+		want      map[string]any
+	}{
+		{"empty", "# nothing but a comment\n\n", map[string]any{}},
+		{"values", `
+string = "plain"
+int = -1_000
+float = 6.022e23
+yes = true
+no = false
+list = [1, "two", 3.0, [4], ]
+empty = []
+`, map[string]any{
+			"string": "plain", "int": int64(-1000), "float": 6.022e23, "yes": true, "no": false,
+			"list": []any{int64(1), "two", 3.0, []any{int64(4)}}, "empty": []any{},
+		}},
+		{"quoting", `
+basic = "tab\t\"quoted\" # not a comment"
+literal = 'C:\fonts\bold.ttf # nor this'
+"quoted key" = 1
+"a.b" = 2
+commas = ["a, b", 'c, d']
+`, map[string]any{
+			"basic": "tab\t\"quoted\" # not a comment", "literal": `C:\fonts\bold.ttf # nor this`,
+			"quoted key": int64(1), "a.b": int64(2), "commas": []any{"a, b", "c, d"},
+		}},
+		{"comments", `
+# before
+height = 300 # after a value
+   # indented
+[server] # after a header
+port = 8080
+`, map[string]any{"height": int64(300), "server": map[string]any{"port": int64(8080)}}},
+		{"tables", `
+height = 600
+[server]
+addr = "localhost:8080"
+[ cards ]
+shape = "hex"
+height = 300
+`, map[string]any{
+			"height": int64(600),
+			"server": map[string]any{"addr": "localhost:8080"},
+			"cards":  map[string]any{"shape": "hex", "height": int64(300)},
+		}},
+		{"windows line ends", "a = 1\r\n[b]\r\nc = 2\r\n", map[string]any{"a": int64(1), "b": map[string]any{"c": int64(2)}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		{"no value", "height\n", "line 1: want key = value"},
+		{"unquoted string", "shape = hex\n", "line 1: bad value hex (strings must be quoted)"},
+		{"duplicate key", "a = 1\n\na = 2\n", "line 3: a is defined twice"},
+		{"duplicate table", "[a]\n[b]\n[a]\n", "line 3: a is defined twice"},
+		{"nested table", "[a.b]\n", "line 1: bad table header [a.b]"},
+		{"array of tables", "[[a]]\n", "line 1: bad table header [[a]]"},
+		{"unclosed header", "[a\n", "line 1: bad table header [a"},
+		{"dotted key", "a.b = 1\n", `line 1: bad key "a.b"`},
+		{"empty key", "= 1\n", `line 1: bad key ""`},
+		{"spaced key", "a b = 1\n", `line 1: bad key "a b"`},
+		{"multi-line array", "a = [1,\n2]\n", "line 1: arrays must be on one line"},
+		{"basic string", `a = "open`, `line 1: bad string "open`},
+		{"literal string", "a = 'it's'\n", "line 1: bad string 'it's'"},
+		{"in an array", "a = [1, two]\n", "line 1: bad value two"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+)
+
+// runVerify renders the cards again and compares each with the one of the
+// same name in a reference folder made earlier with the same flags,
+// listing the elements that look different. It fails if any do, so it can
+// guard a font upgrade or a change to the renderer.
+func runVerify(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	o := addFlags(fs)
+	against := fs.String("against", "", "folder of reference cards to compare with")
+	threshold := fs.Float64("threshold", 0.001, "fraction of a card's pixels that may look different before it counts as changed")
+	if err := parseFlags(fs, o, args); err != nil {
+		return err
+	}
+	if *against == "" {
+		return errors.New("usage: verify -against golden/ [flags]")
+	}
+
+	r, err := newRenderer(o)
+	if err != nil {
+		return err
+	}
+	elements, err := loadElements(ctx, o)
+	if err != nil {
+		return err
+	}
+	changed := 0
+	for _, e := range elements {
+		if ctx.Err() != nil {
+			return errors.New("interrupted")
+		}
+		fname := tileFilename(e)
+		f, err := os.Open(filepath.Join(*against, fname))
+		if err != nil {
+			fmt.Printf("%-3s missing: %v\n", e.Symbol, err)
+			changed++
+			continue
+		}
+		golden, _, err := image.Decode(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", fname, err)
+		}
+		tile := r.Tile(e)
+		if golden.Bounds().Size() != tile.Bounds().Size() {
+			fmt.Printf("%-3s changed: %v now, %v in %s\n", e.Symbol, tile.Bounds().Size(), golden.Bounds().Size(), *against)
+			changed++
+			continue
+		}
+		if diff := perceptualDiff(tile, golden); diff > *threshold {
+			fmt.Printf("%-3s changed: %.2f%% of pixels look different\n", e.Symbol, diff*100)
+			changed++
+		}
+	}
+	if changed > 0 {
+		return fmt.Errorf("%d of %d cards differ from %s", changed, len(elements), *against)
+	}
+	logger.Info("All cards match", "cards", len(elements), "against", *against)
+	return nil
+}
+
+// perceptualDiff returns the fraction of pixels in two images of the same
+// size that look different: those whose colours, on white, are further
+// apart than a small threshold in the YIQ colour space, which weighs
+// brightness over hue the way eyes do. Anti-aliasing that has moved by a
+// shade or two doesn't count.
+func perceptualDiff(a, b image.Image) float64 {
+	const maxDelta = yiqMaxDelta * perceptualThreshold * perceptualThreshold
+	ab, bb := a.Bounds(), b.Bounds()
+	n := 0
+	for y := 0; y < ab.Dy(); y++ {
+		for x := 0; x < ab.Dx(); x++ {
+			if yiqDelta(a.At(ab.Min.X+x, ab.Min.Y+y), b.At(bb.Min.X+x, bb.Min.Y+y)) > maxDelta {
+				n++
+			}
+		}
+	}
+	return float64(n) / float64(ab.Dx()*ab.Dy())
+}
+
+// yiqMaxDelta is the greatest distance between two colours, red and cyan;
+// black and white are a little closer. Pixels look different when they
+// are over perceptualThreshold of that apart.
+const (
+	yiqMaxDelta         = 35215
+	perceptualThreshold = 0.1
+)
+
+// yiqDelta is the squared perceptual distance between two colours, each
+// composited on white, with channels from 0 to 255.
+func yiqDelta(c1, c2 color.Color) float64 {
+	onWhite := func(c color.Color) (float64, float64, float64) {
+		r, g, b, a := c.RGBA()
+		w := float64(0xffff - a)
+		return (float64(r) + w) / 257, (float64(g) + w) / 257, (float64(b) + w) / 257
+	}
+	r1, g1, b1 := onWhite(c1)
+	r2, g2, b2 := onWhite(c2)
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	y := dr*0.29889531 + dg*0.58662247 + db*0.11448223
+	i := dr*0.59597799 - dg*0.27417610 - db*0.32180189
+	q := dr*0.21147017 - dg*0.52261711 + db*0.31114694
+	return 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+}
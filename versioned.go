@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// versionOutdir names the output after the data and settings it is drawn
+// with, for -out-versioned: -outdir with the dataset's version, the
+// colours file, the height and a hash of the other shared flags added, as in
+// elements-v2021-dark-512-3f9c0a1e, or cards-….zip for an archive. Data
+// that gives no version is named by the start of its hash.
+func versionOutdir(o *options) {
+	data := o.provenance["data"]
+	version := data.Version
+	if version == "" {
+		version = "sha" + data.SHA256[:min(len(data.SHA256), 8)]
+	} else if unicode.IsDigit([]rune(version)[0]) {
+		version = "v" + version
+	}
+	theme := strings.TrimSuffix(filepath.Base(o.ColoursPath), filepath.Ext(o.ColoursPath))
+
+	h := sha256.New()
+	for _, k := range slices.Sorted(maps.Keys(o.settings)) {
+		// A mode's own flags change which files it writes, not how the
+		// cards look, and clean has to find the same folder.
+		if sharedFlags[k] {
+			fmt.Fprintf(h, "%s=%s\n", k, o.settings[k])
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(o.provenance)) {
+		fmt.Fprintf(h, "%s=%s\n", k, o.provenance[k].SHA256)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))[:8]
+
+	name := strings.Join([]string{pathSafe(version), pathSafe(theme), fmt.Sprint(o.Height), hash}, "-")
+	dir, ext := o.outdir, ""
+	if _, ok := archiveFormats[strings.ToLower(filepath.Ext(dir))]; ok {
+		ext = filepath.Ext(dir)
+		dir = strings.TrimSuffix(dir, ext)
+	}
+	o.outdir = strings.TrimRight(dir, `/\`) + "-" + name + ext
+	o.outVersioned = false // named once, however often the elements are loaded
+	logger.Info("Versioned output", "outdir", o.outdir)
+}
+
+// sharedFlags are the names of the flags addFlags registers for every
+// mode.
+var sharedFlags = func() map[string]bool {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	addFlags(fs)
+	m := map[string]bool{}
+	fs.VisitAll(func(f *flag.Flag) { m[f.Name] = true })
+	return m
+}()
+
+// pathSafe replaces anything but letters, digits, dots and dashes, for a
+// file name.
+func pathSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-') {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A reader for the small part of YAML that config files like batch specs
+// use, so they can be written by hand without a YAML library: block
+// mappings and sequences nested by indentation, flow [lists] and {maps} on
+// one line, quoted and plain scalars, and # comments. Anchors, tags,
+// multi-line strings and multiple documents aren't supported.
+
+type yamlLine struct {
+	num    int // 1-based, for errors
+	indent int
+	text   string
+}
+
+// parseYAML parses a document into map[string]any, []any, strings,
+// int64s, float64s, bools and nils.
+func parseYAML(src string) (any, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(raw, "---") || strings.HasPrefix(raw, "%") {
+			continue
+		}
+		text := strings.TrimRight(stripYAMLComment(raw), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", i+1)
+		}
+		lines = append(lines, yamlLine{i + 1, len(text) - len(trimmed), trimmed})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	p := &yamlParser{lines: lines}
+	v, err := p.node(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[p.i].num)
+	}
+	return v, nil
+}
+
+// stripYAMLComment removes a # comment, which starts a line or follows a
+// space, outside quotes.
+func stripYAMLComment(s string) string {
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		}
+	}
+	return s
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+func isYAMLItem(text string) bool { return text == "-" || strings.HasPrefix(text, "- ") }
+
+// node parses the block starting at the current line, which is indented
+// by indent.
+func (p *yamlParser) node(indent int) (any, error) {
+	if isYAMLItem(p.lines[p.i].text) {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	list := []any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isYAMLItem(p.lines[p.i].text) {
+		ln := p.lines[p.i]
+		rest := strings.TrimLeft(strings.TrimPrefix(ln.text, "-"), " ")
+		switch {
+		case rest == "":
+			// The item is the block below.
+			p.i++
+			v, err := p.child(indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		case yamlKey(rest) >= 0:
+			// "- key: value" starts a mapping indented to the key.
+			p.lines[p.i] = yamlLine{ln.num, indent + len(ln.text) - len(rest), rest}
+			v, err := p.mapping(p.lines[p.i].indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		default:
+			v, err := yamlValue(rest, ln.num)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+			p.i++
+		}
+	}
+	return list, nil
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	m := map[string]any{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && !isYAMLItem(p.lines[p.i].text) {
+		ln := p.lines[p.i]
+		k := yamlKey(ln.text)
+		if k < 0 {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", ln.num)
+		}
+		name, err := yamlKeyName(strings.TrimSpace(ln.text[:k]), ln.num)
+		if err != nil {
+			return nil, err
+		}
+		if _, dup := m[name]; dup {
+			return nil, fmt.Errorf("line %d: %q given twice", ln.num, name)
+		}
+		value := strings.TrimSpace(ln.text[k+1:])
+		p.i++
+		if value != "" {
+			if m[name], err = yamlValue(value, ln.num); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// A sequence under a key may be indented as far as the key itself.
+		if p.i < len(p.lines) && p.lines[p.i].indent == indent && isYAMLItem(p.lines[p.i].text) {
+			m[name], err = p.sequence(indent)
+		} else {
+			m[name], err = p.child(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// child parses the block indented further than indent, or returns nil if
+// there isn't one.
+func (p *yamlParser) child(indent int) (any, error) {
+	if p.i >= len(p.lines) || p.lines[p.i].indent <= indent {
+		return nil, nil
+	}
+	return p.node(p.lines[p.i].indent)
+}
+
+// yamlKey returns the index of the colon ending the key of a "key: value"
+// line, or -1 if it isn't one.
+func yamlKey(s string) int {
+	if strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{") {
+		return -1
+	}
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case i == 0 && (c == '"' || c == '\''):
+			quote = c
+		case c == ':' && (i == len(s)-1 || s[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlKeyName returns a mapping's key. A plain key is the text as
+// written, so null: and 1.0: aren't turned into "<nil>" and "1".
+func yamlKeyName(s string, line int) (string, error) {
+	if !strings.HasPrefix(s, `"`) && !strings.HasPrefix(s, "'") {
+		return s, nil
+	}
+	v, err := yamlScalar(s, line)
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+// yamlValue parses a value on one line: a flow list or mapping, or a
+// scalar.
+func yamlValue(s string, line int) (any, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("line %d: unclosed [", line)
+		}
+		list := []any{}
+		for _, item := range splitFlow(s[1 : len(s)-1]) {
+			v, err := yamlValue(item, line)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case strings.HasPrefix(s, "{"):
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("line %d: unclosed {", line)
+		}
+		m := map[string]any{}
+		for _, item := range splitFlow(s[1 : len(s)-1]) {
+			k := yamlKey(item)
+			if k < 0 {
+				return nil, fmt.Errorf("line %d: expected \"key: value\" in %s", line, s)
+			}
+			key, err := yamlKeyName(strings.TrimSpace(item[:k]), line)
+			if err != nil {
+				return nil, err
+			}
+			if m[key], err = yamlValue(strings.TrimSpace(item[k+1:]), line); err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	}
+	return yamlScalar(s, line)
+}
+
+// splitFlow splits the inside of a flow collection at its top-level
+// commas.
+func splitFlow(s string) []string {
+	var items []string
+	depth, start := 0, 0
+	var quote rune
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(items) > 0 {
+		items = append(items, last)
+	}
+	return items
+}
+
+// yamlScalar parses a quoted or plain scalar. Plain ones are booleans,
+// nulls and numbers if they look like them, and strings otherwise.
+func yamlScalar(s string, line int) (any, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad string %s", line, s)
+		}
+		return v, nil
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("line %d: bad string %s", line, s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	switch s {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~", "":
+		return nil, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
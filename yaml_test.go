@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	for _, tt := range []struct {
+		name, src string
+		want      any
+	}{
+		{"empty", "# nothing but a comment\n\n", nil},
+		{"scalars", `
+string: plain text here
+int: -42
+float: 2.5e3
+yes: true
+no: False
+none: ~
+null: null
+empty:
+1.0: plain keys are as written
+`, map[string]any{
+			"string": "plain text here", "int": int64(-42), "float": 2500.0,
+			"yes": true, "no": false, "none": nil, "null": nil, "empty": nil,
+			"1.0": "plain keys are as written",
+		}},
+		{"quoting", `
+double: "tab\there \"quoted\" # not a comment"
+single: 'it''s # not a comment either'
+number: "42"
+"quoted key": 1
+'colon: inside': 2
+hash#inside: plain#text
+url: http://example.com/a
+`, map[string]any{
+			"double": "tab\there \"quoted\" # not a comment", "single": "it's # not a comment either",
+			"number": "42", "quoted key": int64(1), "colon: inside": int64(2),
+			"hash#inside": "plain#text", "url": "http://example.com/a",
+		}},
+		{"comments", `---
+# before
+a: 1 # after a value
+b:   # after a key
+  c: 2
+# between
+  d: 3
+`, map[string]any{"a": int64(1), "b": map[string]any{"c": int64(2), "d": int64(3)}}},
+		{"nested", `
+jobs:
+  - name: small
+    height: 300
+    options:
+      shape: hex
+      flags: [-lewis, -valence]
+  -
+    name: defaults
+  - plain
+elements:
+- H
+- He
+deep:
+  deeper:
+    deepest: {a: 1, b: [x, "y, z"], c: {d: e}}
+`, map[string]any{
+			"jobs": []any{
+				map[string]any{"name": "small", "height": int64(300), "options": map[string]any{
+					"shape": "hex", "flags": []any{"-lewis", "-valence"},
+				}},
+				map[string]any{"name": "defaults"},
+				"plain",
+			},
+			"elements": []any{"H", "He"},
+			"deep": map[string]any{"deeper": map[string]any{"deepest": map[string]any{
+				"a": int64(1), "b": []any{"x", "y, z"}, "c": map[string]any{"d": "e"},
+			}}},
+		}},
+		{"top-level sequence", "- 1\n- [2, 3]\n- []\n- {}\n", []any{int64(1), []any{int64(2), int64(3)}, []any{}, map[string]any{}}},
+		{"windows line ends", "a: 1\r\nb: 2\r\n", map[string]any{"a": int64(1), "b": int64(2)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		{"tab", "a:\n\tb: 1\n", "line 2: indent with spaces, not tabs"},
+		{"duplicate", "a: 1\nb: 2\na: 3\n", `line 3: "a" given twice`},
+		{"not a mapping", "a: 1\njust text\n", `line 2: expected "key: value"`},
+		{"indentation", "a:\n    b: 1\n  c: 2\n", "line 3: unexpected indentation"},
+		{"unclosed list", "a: [1, 2\n", "line 1: unclosed ["},
+		{"unclosed map", "a: {b: 1\n", "line 1: unclosed {"},
+		{"flow map", "a: {b}\n", `line 1: expected "key: value" in {b}`},
+		{"double quotes", `a: "open`, `line 1: bad string "open`},
+		{"single quotes", "a: 'open", "line 1: bad string 'open"},
+		{"bad key", "\"a: 1\n", `line 1: expected "key: value"`},
+		{"in a list", "- a: 1\n  b: [\n", "line 2: unclosed ["},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}